@@ -4,8 +4,8 @@ type BrokerHandler struct {
 	UserHandler *UserHandler
 }
 
-func NewBroker() *BrokerHandler {
+func NewBroker(userUsecase IUserUsecase) *BrokerHandler {
 	return &BrokerHandler{
-		UserHandler: NewUserHandler(),
+		UserHandler: NewUserHandler(userUsecase),
 	}
 }
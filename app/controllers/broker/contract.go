@@ -0,0 +1,7 @@
+package broker
+
+import "context"
+
+type IUserUsecase interface {
+	Updated(ctx context.Context, userID string) error
+}
@@ -0,0 +1,7 @@
+package broker
+
+type UserUpdatedEvent struct {
+	ID    string `json:"id" validate:"required"`
+	Email string `json:"email" validate:"omitempty,email"`
+	Phone string `json:"phone" validate:"omitempty,phone_id"`
+}
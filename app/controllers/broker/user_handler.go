@@ -1,21 +1,34 @@
 package broker
 
 import (
-	"fmt"
+	"encoding/json"
 	"github.com/ThreeDotsLabs/watermill/message"
+	"service/pkg/logger"
+	"service/pkg/validation"
 )
 
 type UserHandler struct {
+	userUsecase IUserUsecase
 }
 
-func NewUserHandler() *UserHandler {
-	return &UserHandler{}
+func NewUserHandler(userUsecase IUserUsecase) *UserHandler {
+	return &UserHandler{
+		userUsecase: userUsecase,
+	}
 }
 
 func (h *UserHandler) Updated(msg *message.Message) error {
-	fmt.Printf(
-		"\n> Received message: %s\n> %s\n> metadata: %v\n\n",
-		msg.UUID, string(msg.Payload), msg.Metadata,
-	)
-	return nil
+	event := UserUpdatedEvent{}
+	if err := json.Unmarshal(msg.Payload, &event); err != nil {
+		return validation.ErrMalformed.Wrap(err)
+	}
+	if err := validation.Struct(msg.Context(), &event); err != nil {
+		return err
+	}
+
+	// the payload carries personal data, only the IDs are logged
+	logger.Logger.Debug("user updated message",
+		logger.F("message_id", msg.UUID),
+		logger.F("user_id", event.ID))
+	return h.userUsecase.Updated(msg.Context(), event.ID)
 }
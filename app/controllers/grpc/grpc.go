@@ -2,11 +2,16 @@ package grpc
 
 import (
 	"context"
+	"service/app/controllers/grpc/user"
 	"service/app/usecases"
 )
 
-type Grpc struct{}
+type Grpc struct {
+	UserServer *user.UserServer
+}
 
 func NewGrpc(ctx context.Context, usecase *usecases.Usecase) *Grpc {
-	return &Grpc{}
+	return &Grpc{
+		UserServer: user.NewUserServer(usecase.UserUsecase, usecase.PermissionUsecase),
+	}
 }
@@ -0,0 +1,19 @@
+package user
+
+import (
+	"context"
+	"service/app/controllers/restapi/user"
+	"service/app/models"
+	"service/pkg/pagination"
+)
+
+type IUserUsecase interface {
+	Register(ctx context.Context, request *user.RegistrationRequest) (interface{}, error)
+	Get(ctx context.Context, id string) (*models.User, error)
+	List(ctx context.Context, req pagination.Request) (*pagination.Page[models.User], error)
+	Update(ctx context.Context, request *user.UpdateRequest) (*models.User, error)
+}
+
+type IPermissionChecker interface {
+	HasPermission(ctx context.Context, userID, permission string) (bool, error)
+}
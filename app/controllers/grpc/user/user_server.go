@@ -0,0 +1,161 @@
+package user
+
+import (
+	"context"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"net/url"
+	"service/app/controllers/restapi/user"
+	"service/app/models"
+	"service/pkg/apperror"
+	"service/pkg/auth"
+	"service/pkg/otel"
+	"service/pkg/pagination"
+	"service/pkg/validation"
+	userv1 "service/proto/user/v1"
+	"strconv"
+)
+
+// UserServer implements userv1.UserServiceServer with the usecases behind
+// the /user REST routes, so both apply the same rules. Register is open like
+// POST /user/register; the other calls need an authenticated caller with
+// user:read, or user:write to update, so they are refused while
+// grpc.auth.enabled is off.
+type UserServer struct {
+	userv1.UnimplementedUserServiceServer
+	userUsecase IUserUsecase
+	permissions IPermissionChecker
+}
+
+func NewUserServer(userUsecase IUserUsecase, permissions IPermissionChecker) *UserServer {
+	return &UserServer{
+		userUsecase: userUsecase,
+		permissions: permissions,
+	}
+}
+
+func (s *UserServer) Register(ctx context.Context, in *userv1.RegisterRequest) (*userv1.RegisterResponse, error) {
+	ctx, span := otel.AddSpan(ctx, "user_grpc.register")
+	defer span.End()
+
+	req := user.RegistrationRequest{
+		Name:     in.GetName(),
+		Email:    in.GetEmail(),
+		Phone:    in.GetPhone(),
+		Password: in.GetPassword(),
+	}
+	if err := validation.Struct(ctx, &req); err != nil {
+		return nil, apperror.GRPCError(err)
+	}
+
+	if _, err := s.userUsecase.Register(ctx, &req); err != nil {
+		return nil, apperror.GRPCError(err)
+	}
+	return &userv1.RegisterResponse{}, nil
+}
+
+func (s *UserServer) Get(ctx context.Context, in *userv1.GetRequest) (*userv1.GetResponse, error) {
+	ctx, span := otel.AddSpan(ctx, "user_grpc.get")
+	defer span.End()
+
+	if err := auth.Require(ctx, s.permissions, "user:read"); err != nil {
+		return nil, apperror.GRPCError(err)
+	}
+
+	req := struct {
+		ID string `validate:"required,max=64"`
+	}{ID: in.GetId()}
+	if err := validation.Struct(ctx, &req); err != nil {
+		return nil, apperror.GRPCError(err)
+	}
+
+	found, err := s.userUsecase.Get(ctx, req.ID)
+	if err != nil {
+		return nil, apperror.GRPCError(err)
+	}
+	return &userv1.GetResponse{User: toProto(found)}, nil
+}
+
+func (s *UserServer) List(ctx context.Context, in *userv1.ListRequest) (*userv1.ListResponse, error) {
+	ctx, span := otel.AddSpan(ctx, "user_grpc.list")
+	defer span.End()
+
+	if err := auth.Require(ctx, s.permissions, "user:read"); err != nil {
+		return nil, apperror.GRPCError(err)
+	}
+
+	// parsed like the query of GET /user/data so both page alike
+	query := url.Values{}
+	if in.GetPage() != 0 {
+		query.Set("page", strconv.Itoa(int(in.GetPage())))
+	}
+	if in.GetLimit() != 0 {
+		query.Set("limit", strconv.Itoa(int(in.GetLimit())))
+	}
+	if in.GetCursor() != "" {
+		query.Set("cursor", in.GetCursor())
+	}
+	if in.GetSort() != "" {
+		query.Set("sort", in.GetSort())
+	}
+	req, err := pagination.Parse(query, user.ListOptions)
+	if err != nil {
+		return nil, apperror.GRPCError(err)
+	}
+
+	page, err := s.userUsecase.List(ctx, req)
+	if err != nil {
+		return nil, apperror.GRPCError(err)
+	}
+
+	out := &userv1.ListResponse{
+		Users:      make([]*userv1.User, len(page.Items)),
+		Limit:      int32(page.Limit),
+		Page:       int32(page.Page),
+		Total:      page.Total,
+		NextCursor: page.Next,
+		PrevCursor: page.Prev,
+	}
+	for i := range page.Items {
+		out.Users[i] = toProto(&page.Items[i])
+	}
+	return out, nil
+}
+
+func (s *UserServer) Update(ctx context.Context, in *userv1.UpdateRequest) (*userv1.UpdateResponse, error) {
+	ctx, span := otel.AddSpan(ctx, "user_grpc.update")
+	defer span.End()
+
+	if err := auth.Require(ctx, s.permissions, "user:write"); err != nil {
+		return nil, apperror.GRPCError(err)
+	}
+
+	// unset optional fields stay nil and are left unchanged
+	req := user.UpdateRequest{
+		ID:    in.GetId(),
+		Name:  in.Name,
+		Email: in.Email,
+		Phone: in.Phone,
+	}
+	if err := validation.Struct(ctx, &req); err != nil {
+		return nil, apperror.GRPCError(err)
+	}
+
+	updated, err := s.userUsecase.Update(ctx, &req)
+	if err != nil {
+		return nil, apperror.GRPCError(err)
+	}
+	return &userv1.UpdateResponse{User: toProto(updated)}, nil
+}
+
+func toProto(u *models.User) *userv1.User {
+	out := &userv1.User{
+		Id:    u.ID,
+		Name:  u.Name,
+		Email: u.Email,
+		Phone: u.Phone,
+	}
+	if !u.CreatedAt.IsZero() {
+		out.CreatedAt = timestamppb.New(u.CreatedAt)
+	}
+	return out
+}
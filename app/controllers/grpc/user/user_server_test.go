@@ -0,0 +1,365 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"net"
+	"service/app/controllers/restapi/user"
+	"service/app/models"
+	"service/pkg/apperror"
+	"service/pkg/auth"
+	"service/pkg/pagination"
+	"service/pkg/validation"
+	userv1 "service/proto/user/v1"
+	"slices"
+	"testing"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+)
+
+func init() {
+	// registered by the user usecase in the service
+	err := validation.RegisterUnique("unique_email", func(ctx context.Context, email string) (bool, error) {
+		if email == "down@example.com" {
+			return false, errors.New("connection refused")
+		}
+		return email != "taken@example.com", nil
+	}, map[string]string{validation.LangEnglish: "{0} is already registered"})
+	if err != nil {
+		panic(err)
+	}
+}
+
+var created = time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+// fakeUsecase serves one user and fails every call with err when set.
+type fakeUsecase struct {
+	err        error
+	registered *user.RegistrationRequest
+	listed     pagination.Request
+	updated    *user.UpdateRequest
+}
+
+func (f *fakeUsecase) Register(ctx context.Context, request *user.RegistrationRequest) (interface{}, error) {
+	f.registered = request
+	return nil, f.err
+}
+
+func (f *fakeUsecase) Get(ctx context.Context, id string) (*models.User, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &models.User{ID: id, Name: "Ana", Email: "ana@example.com", Phone: "+6281234567890", CreatedAt: created}, nil
+}
+
+func (f *fakeUsecase) List(ctx context.Context, req pagination.Request) (*pagination.Page[models.User], error) {
+	f.listed = req
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &pagination.Page[models.User]{
+		Items: []models.User{{ID: "u1", Name: "Ana"}, {ID: "u2", Name: "Budi"}},
+		Limit: req.Limit,
+		Page:  req.Page,
+		Total: 12,
+		Next:  "3",
+		Prev:  "1",
+	}, nil
+}
+
+func (f *fakeUsecase) Update(ctx context.Context, request *user.UpdateRequest) (*models.User, error) {
+	f.updated = request
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &models.User{ID: request.ID, Name: *request.Name}, nil
+}
+
+// fakePermissions grants each subject its listed permissions; checking the
+// subject "down" fails.
+type fakePermissions map[string][]string
+
+func (f fakePermissions) HasPermission(ctx context.Context, userID, permission string) (bool, error) {
+	if userID == "down" {
+		return false, apperror.Unavailable("permission.unavailable", "try again")
+	}
+	return slices.Contains(f[userID], permission), nil
+}
+
+var testPermissions = fakePermissions{
+	"reader": {"user:read"},
+	"writer": {"user:read", "user:write"},
+}
+
+// authenticateSubject stands in for the auth interceptor: the "subject"
+// metadata becomes the principal.
+func authenticateSubject(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	if md, ok := metadata.FromIncomingContext(ctx); ok && len(md.Get("subject")) > 0 {
+		ctx = auth.WithPrincipal(ctx, &auth.Principal{Subject: md.Get("subject")[0]})
+	}
+	return handler(ctx, req)
+}
+
+// as calls on behalf of subject.
+func as(subject string) context.Context {
+	return metadata.AppendToOutgoingContext(context.Background(), "subject", subject)
+}
+
+func newTestClient(t *testing.T, usecase IUserUsecase) userv1.UserServiceClient {
+	t.Helper()
+	s := grpc.NewServer(grpc.UnaryInterceptor(authenticateSubject))
+	userv1.RegisterUserServiceServer(s, NewUserServer(usecase, testPermissions))
+	lis := bufconn.Listen(1 << 20)
+	go func() { _ = s.Serve(lis) }()
+	t.Cleanup(s.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	return userv1.NewUserServiceClient(conn)
+}
+
+// errorInfo returns the ErrorInfo detail of a status error.
+func errorInfo(t *testing.T, err error) *errdetails.ErrorInfo {
+	t.Helper()
+	for _, d := range status.Convert(err).Details() {
+		if info, ok := d.(*errdetails.ErrorInfo); ok {
+			return info
+		}
+	}
+	t.Fatalf("no ErrorInfo in %v", err)
+	return nil
+}
+
+func TestGetMapsUser(t *testing.T) {
+	client := newTestClient(t, &fakeUsecase{})
+
+	resp, err := client.Get(as("reader"), &userv1.GetRequest{Id: "u1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &userv1.User{Id: "u1", Name: "Ana", Email: "ana@example.com", Phone: "+6281234567890"}
+	got := resp.GetUser()
+	if got.GetCreatedAt().AsTime() != created {
+		t.Errorf("created_at = %v, want %v", got.GetCreatedAt().AsTime(), created)
+	}
+	got.CreatedAt = nil
+	if !proto.Equal(got, want) {
+		t.Errorf("user = %v, want %v", got, want)
+	}
+}
+
+func TestListMapsPage(t *testing.T) {
+	usecase := &fakeUsecase{}
+	client := newTestClient(t, usecase)
+
+	resp, err := client.List(as("reader"), &userv1.ListRequest{Page: 2, Limit: 5, Sort: "name"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if usecase.listed.Page != 2 || usecase.listed.Limit != 5 || usecase.listed.Sort[0].Field != "name" {
+		t.Errorf("request = %+v", usecase.listed)
+	}
+	if len(resp.GetUsers()) != 2 || resp.GetUsers()[1].GetName() != "Budi" {
+		t.Errorf("users = %v", resp.GetUsers())
+	}
+	if resp.GetPage() != 2 || resp.GetLimit() != 5 || resp.GetTotal() != 12 ||
+		resp.GetNextCursor() != "3" || resp.GetPrevCursor() != "1" {
+		t.Errorf("page = %v", resp)
+	}
+
+	_, err = client.List(as("reader"), &userv1.ListRequest{Cursor: "tampered"})
+	if status.Code(err) != codes.InvalidArgument || errorInfo(t, err).GetReason() != "pagination.invalid" {
+		t.Errorf("tampered cursor: %v", err)
+	}
+}
+
+func TestUpdateKeepsUnsetFields(t *testing.T) {
+	usecase := &fakeUsecase{}
+	client := newTestClient(t, usecase)
+
+	name := "Citra"
+	resp, err := client.Update(as("writer"), &userv1.UpdateRequest{Id: "u1", Name: &name})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if usecase.updated.Email != nil || usecase.updated.Phone != nil || *usecase.updated.Name != name {
+		t.Errorf("request = %+v", usecase.updated)
+	}
+	if resp.GetUser().GetName() != name {
+		t.Errorf("user = %v", resp.GetUser())
+	}
+}
+
+func TestRegisterValidation(t *testing.T) {
+	valid := func() *userv1.RegisterRequest {
+		return &userv1.RegisterRequest{Name: "Ana", Email: "ana@example.com", Password: "correct horse"}
+	}
+	for _, tc := range []struct {
+		name   string
+		change func(r *userv1.RegisterRequest)
+		code   codes.Code
+		reason string
+	}{
+		{name: "valid", change: func(r *userv1.RegisterRequest) {}, code: codes.OK},
+		{name: "bad email", change: func(r *userv1.RegisterRequest) { r.Email = "ana" }, code: codes.InvalidArgument, reason: "request.invalid"},
+		{name: "short password", change: func(r *userv1.RegisterRequest) { r.Password = "short" }, code: codes.InvalidArgument, reason: "request.invalid"},
+		{name: "taken email", change: func(r *userv1.RegisterRequest) { r.Email = "taken@example.com" }, code: codes.InvalidArgument, reason: "request.invalid"},
+		{name: "lookup down", change: func(r *userv1.RegisterRequest) { r.Email = "down@example.com" }, code: codes.Unavailable, reason: "validation.unavailable"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			usecase := &fakeUsecase{}
+			client := newTestClient(t, usecase)
+			req := valid()
+			tc.change(req)
+
+			_, err := client.Register(context.Background(), req)
+			if code := status.Code(err); code != tc.code {
+				t.Fatalf("code = %s, want %s: %v", code, tc.code, err)
+			}
+			if tc.code == codes.OK {
+				if usecase.registered == nil || usecase.registered.Email != req.GetEmail() {
+					t.Errorf("usecase got %+v", usecase.registered)
+				}
+				return
+			}
+			if usecase.registered != nil {
+				t.Error("invalid request reached the usecase")
+			}
+			if reason := errorInfo(t, err).GetReason(); reason != tc.reason {
+				t.Errorf("reason = %q, want %q", reason, tc.reason)
+			}
+		})
+	}
+}
+
+func TestErrorCodes(t *testing.T) {
+	for _, tc := range []struct {
+		err     error
+		code    codes.Code
+		message string
+	}{
+		{apperror.NotFound("user.not_found", "user not found").WithDetail("id", "u1"), codes.NotFound, "user not found"},
+		{apperror.Conflict("user.exists", "user exists"), codes.AlreadyExists, "user exists"},
+		{apperror.Validation("user.invalid", "invalid user"), codes.InvalidArgument, "invalid user"},
+		{apperror.Unauthorized("auth.invalid_token", "invalid bearer token"), codes.Unauthenticated, "invalid bearer token"},
+		{apperror.Forbidden("auth.forbidden", "forbidden"), codes.PermissionDenied, "forbidden"},
+		{apperror.RateLimited("rate_limit.exceeded", "slow down"), codes.ResourceExhausted, "slow down"},
+		{apperror.Unavailable("database.unavailable", "try again"), codes.Unavailable, "try again"},
+		// wrapped app errors keep their kind, plain ones are internal and
+		// their message stays on the server
+		{errors.Join(errors.New("context"), apperror.NotFound("user.not_found", "user not found")), codes.NotFound, "user not found"},
+		{errors.New("pq: connection reset"), codes.Internal, ""},
+	} {
+		t.Run(tc.code.String(), func(t *testing.T) {
+			client := newTestClient(t, &fakeUsecase{err: tc.err})
+
+			_, err := client.Get(as("reader"), &userv1.GetRequest{Id: "u1"})
+			st := status.Convert(err)
+			if st.Code() != tc.code {
+				t.Fatalf("code = %s, want %s: %v", st.Code(), tc.code, err)
+			}
+			if tc.message != "" && st.Message() != tc.message {
+				t.Errorf("message = %q, want %q", st.Message(), tc.message)
+			}
+			if tc.code == codes.Internal && st.Message() == tc.err.Error() {
+				t.Errorf("internal message exposed: %q", st.Message())
+			}
+			var want *apperror.Error
+			if errors.As(tc.err, &want) {
+				info := errorInfo(t, err)
+				if info.GetReason() != want.Code {
+					t.Errorf("reason = %q, want %q", info.GetReason(), want.Code)
+				}
+				for k, v := range want.Details {
+					if info.GetMetadata()[k] != v {
+						t.Errorf("metadata %s = %q, want %v", k, info.GetMetadata()[k], v)
+					}
+				}
+			}
+		})
+	}
+
+	client := newTestClient(t, &fakeUsecase{})
+	_, err := client.Get(as("reader"), &userv1.GetRequest{})
+	if status.Code(err) != codes.InvalidArgument || errorInfo(t, err).GetReason() != "request.invalid" {
+		t.Errorf("missing id: %v", err)
+	}
+}
+
+func TestPermissions(t *testing.T) {
+	name := "Citra"
+	calls := map[string]func(client userv1.UserServiceClient, ctx context.Context) error{
+		"get": func(client userv1.UserServiceClient, ctx context.Context) error {
+			_, err := client.Get(ctx, &userv1.GetRequest{Id: "u1"})
+			return err
+		},
+		"list": func(client userv1.UserServiceClient, ctx context.Context) error {
+			_, err := client.List(ctx, &userv1.ListRequest{})
+			return err
+		},
+		"update": func(client userv1.UserServiceClient, ctx context.Context) error {
+			_, err := client.Update(ctx, &userv1.UpdateRequest{Id: "u1", Name: &name})
+			return err
+		},
+		"register": func(client userv1.UserServiceClient, ctx context.Context) error {
+			_, err := client.Register(ctx, &userv1.RegisterRequest{Name: "Ana", Email: "ana@example.com", Password: "correct horse"})
+			return err
+		},
+	}
+	for _, tc := range []struct {
+		call    string
+		subject string
+		code    codes.Code
+		reason  string
+	}{
+		{call: "get", code: codes.Unauthenticated, reason: "auth.missing_token"},
+		{call: "list", code: codes.Unauthenticated, reason: "auth.missing_token"},
+		{call: "update", code: codes.Unauthenticated, reason: "auth.missing_token"},
+		{call: "register", code: codes.OK},
+		{call: "get", subject: "nobody", code: codes.PermissionDenied, reason: "auth.forbidden"},
+		{call: "list", subject: "nobody", code: codes.PermissionDenied, reason: "auth.forbidden"},
+		{call: "update", subject: "reader", code: codes.PermissionDenied, reason: "auth.forbidden"},
+		{call: "update", subject: "writer", code: codes.OK},
+		{call: "get", subject: "down", code: codes.Unavailable, reason: "permission.unavailable"},
+	} {
+		t.Run(tc.call+" as "+tc.subject, func(t *testing.T) {
+			usecase := &fakeUsecase{}
+			client := newTestClient(t, usecase)
+			ctx := context.Background()
+			if tc.subject != "" {
+				ctx = as(tc.subject)
+			}
+
+			err := calls[tc.call](client, ctx)
+			if code := status.Code(err); code != tc.code {
+				t.Fatalf("code = %s, want %s: %v", code, tc.code, err)
+			}
+			if tc.code == codes.OK {
+				return
+			}
+			info := errorInfo(t, err)
+			if info.GetReason() != tc.reason {
+				t.Errorf("reason = %q, want %q", info.GetReason(), tc.reason)
+			}
+			if tc.code == codes.PermissionDenied && info.GetMetadata()["permission"] == "" {
+				t.Error("missing permission not named")
+			}
+			if usecase.updated != nil || usecase.listed.Limit != 0 {
+				t.Error("refused call reached the usecase")
+			}
+		})
+	}
+}
@@ -0,0 +1,17 @@
+package permission
+
+import (
+	"context"
+	"service/app/models"
+)
+
+type IPermissionUsecase interface {
+	ListRoles(ctx context.Context) ([]models.Role, error)
+	CreateRole(ctx context.Context, request *CreateRoleRequest) (*models.Role, error)
+	DeleteRole(ctx context.Context, roleID uint) error
+	Grant(ctx context.Context, roleID uint, permission string) error
+	Revoke(ctx context.Context, roleID uint, permission string) error
+	AssignRole(ctx context.Context, userID string, roleID uint) error
+	UnassignRole(ctx context.Context, userID string, roleID uint) error
+	UserPermissions(ctx context.Context, userID string) ([]string, error)
+}
@@ -0,0 +1,264 @@
+package permission
+
+import (
+	"net/http"
+	"service/app/controllers/restapi/render"
+	"service/pkg/apperror"
+	"service/pkg/otel"
+	"service/pkg/validation"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+var errInvalidRoleID = apperror.Validation("role.invalid_id", "role id must be a positive number")
+
+type PermissionHandler struct {
+	permissionUsecase IPermissionUsecase
+}
+
+func NewPermissionHandler(permissionUsecase IPermissionUsecase) *PermissionHandler {
+	return &PermissionHandler{
+		permissionUsecase: permissionUsecase,
+	}
+}
+
+// @Summary List roles
+// @Tags permissions
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} models.Role
+// @Failure 401 {object} apperror.ErrorResponse
+// @Failure 403 {object} apperror.ErrorResponse
+// @Router /permission/roles [get]
+func (h *PermissionHandler) ListRoles(c *gin.Context) {
+	ctx, span := otel.AddSpan(c.Request.Context(), "permission.list_roles")
+	defer span.End()
+
+	roles, err := h.permissionUsecase.ListRoles(ctx)
+	if err != nil {
+		apperror.AbortHTTP(c, err)
+		return
+	}
+
+	render.Data(c, http.StatusOK, roles)
+}
+
+// @Summary Create role
+// @Tags permissions
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body CreateRoleRequest true "role"
+// @Success 201 {object} models.Role
+// @Failure 400 {object} apperror.ErrorResponse
+// @Failure 401 {object} apperror.ErrorResponse
+// @Failure 403 {object} apperror.ErrorResponse
+// @Failure 409 {object} apperror.ErrorResponse
+// @Failure 415 {object} apperror.ErrorResponse
+// @Router /permission/roles [post]
+func (h *PermissionHandler) CreateRole(c *gin.Context) {
+	ctx, span := otel.AddSpan(c.Request.Context(), "permission.create_role")
+	defer span.End()
+
+	req := CreateRoleRequest{}
+	if err := validation.Bind(c, &req); err != nil {
+		apperror.AbortHTTP(c, err)
+		return
+	}
+
+	role, err := h.permissionUsecase.CreateRole(ctx, &req)
+	if err != nil {
+		apperror.AbortHTTP(c, err)
+		return
+	}
+
+	render.Data(c, http.StatusCreated, role)
+}
+
+// @Summary Delete role
+// @Tags permissions
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "role ID"
+// @Success 204
+// @Failure 400 {object} apperror.ErrorResponse
+// @Failure 401 {object} apperror.ErrorResponse
+// @Failure 403 {object} apperror.ErrorResponse
+// @Failure 404 {object} apperror.ErrorResponse
+// @Router /permission/roles/{id} [delete]
+func (h *PermissionHandler) DeleteRole(c *gin.Context) {
+	ctx, span := otel.AddSpan(c.Request.Context(), "permission.delete_role")
+	defer span.End()
+
+	roleID, err := roleIDParam(c, "id")
+	if err != nil {
+		apperror.AbortHTTP(c, err)
+		return
+	}
+
+	if err := h.permissionUsecase.DeleteRole(ctx, roleID); err != nil {
+		apperror.AbortHTTP(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// @Summary Grant permission to role
+// @Tags permissions
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "role ID"
+// @Param request body GrantRequest true "permission"
+// @Success 204
+// @Failure 400 {object} apperror.ErrorResponse
+// @Failure 401 {object} apperror.ErrorResponse
+// @Failure 403 {object} apperror.ErrorResponse
+// @Failure 404 {object} apperror.ErrorResponse
+// @Failure 415 {object} apperror.ErrorResponse
+// @Router /permission/roles/{id}/permissions [post]
+func (h *PermissionHandler) Grant(c *gin.Context) {
+	ctx, span := otel.AddSpan(c.Request.Context(), "permission.grant")
+	defer span.End()
+
+	roleID, err := roleIDParam(c, "id")
+	if err != nil {
+		apperror.AbortHTTP(c, err)
+		return
+	}
+	req := GrantRequest{}
+	if err := validation.Bind(c, &req); err != nil {
+		apperror.AbortHTTP(c, err)
+		return
+	}
+
+	if err := h.permissionUsecase.Grant(ctx, roleID, req.Permission); err != nil {
+		apperror.AbortHTTP(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// @Summary Revoke permission from role
+// @Tags permissions
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "role ID"
+// @Param permission path string true "permission name"
+// @Success 204
+// @Failure 400 {object} apperror.ErrorResponse
+// @Failure 401 {object} apperror.ErrorResponse
+// @Failure 403 {object} apperror.ErrorResponse
+// @Failure 404 {object} apperror.ErrorResponse
+// @Router /permission/roles/{id}/permissions/{permission} [delete]
+func (h *PermissionHandler) Revoke(c *gin.Context) {
+	ctx, span := otel.AddSpan(c.Request.Context(), "permission.revoke")
+	defer span.End()
+
+	roleID, err := roleIDParam(c, "id")
+	if err != nil {
+		apperror.AbortHTTP(c, err)
+		return
+	}
+
+	if err := h.permissionUsecase.Revoke(ctx, roleID, c.Param("permission")); err != nil {
+		apperror.AbortHTTP(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// @Summary Assign role to user
+// @Tags permissions
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param user_id path string true "user ID"
+// @Param request body AssignRoleRequest true "role"
+// @Success 204
+// @Failure 400 {object} apperror.ErrorResponse
+// @Failure 401 {object} apperror.ErrorResponse
+// @Failure 403 {object} apperror.ErrorResponse
+// @Failure 404 {object} apperror.ErrorResponse
+// @Failure 415 {object} apperror.ErrorResponse
+// @Router /permission/users/{user_id}/roles [post]
+func (h *PermissionHandler) AssignRole(c *gin.Context) {
+	ctx, span := otel.AddSpan(c.Request.Context(), "permission.assign_role")
+	defer span.End()
+
+	req := AssignRoleRequest{}
+	if err := validation.Bind(c, &req); err != nil {
+		apperror.AbortHTTP(c, err)
+		return
+	}
+
+	if err := h.permissionUsecase.AssignRole(ctx, c.Param("user_id"), req.RoleID); err != nil {
+		apperror.AbortHTTP(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// @Summary Unassign role from user
+// @Tags permissions
+// @Produce json
+// @Security BearerAuth
+// @Param user_id path string true "user ID"
+// @Param role_id path int true "role ID"
+// @Success 204
+// @Failure 400 {object} apperror.ErrorResponse
+// @Failure 401 {object} apperror.ErrorResponse
+// @Failure 403 {object} apperror.ErrorResponse
+// @Router /permission/users/{user_id}/roles/{role_id} [delete]
+func (h *PermissionHandler) UnassignRole(c *gin.Context) {
+	ctx, span := otel.AddSpan(c.Request.Context(), "permission.unassign_role")
+	defer span.End()
+
+	roleID, err := roleIDParam(c, "role_id")
+	if err != nil {
+		apperror.AbortHTTP(c, err)
+		return
+	}
+
+	if err := h.permissionUsecase.UnassignRole(ctx, c.Param("user_id"), roleID); err != nil {
+		apperror.AbortHTTP(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// @Summary List user permissions
+// @Tags permissions
+// @Produce json
+// @Security BearerAuth
+// @Param user_id path string true "user ID"
+// @Success 200 {object} UserPermissionsResponse
+// @Failure 401 {object} apperror.ErrorResponse
+// @Failure 403 {object} apperror.ErrorResponse
+// @Router /permission/users/{user_id}/permissions [get]
+func (h *PermissionHandler) UserPermissions(c *gin.Context) {
+	ctx, span := otel.AddSpan(c.Request.Context(), "permission.user_permissions")
+	defer span.End()
+
+	perms, err := h.permissionUsecase.UserPermissions(ctx, c.Param("user_id"))
+	if err != nil {
+		apperror.AbortHTTP(c, err)
+		return
+	}
+
+	render.Data(c, http.StatusOK, UserPermissionsResponse{Permissions: perms})
+}
+
+func roleIDParam(c *gin.Context, name string) (uint, error) {
+	id, err := strconv.ParseUint(c.Param(name), 10, 64)
+	if err != nil || id == 0 {
+		return 0, errInvalidRoleID.WithDetail("value", c.Param(name))
+	}
+	return uint(id), nil
+}
@@ -0,0 +1,21 @@
+package permission
+
+// RolesCacheResource names the cached responses built from roles.
+const RolesCacheResource = "roles"
+
+type CreateRoleRequest struct {
+	Name        string `json:"name" validate:"required,max=100"`
+	Description string `json:"description" validate:"max=255"`
+}
+
+type GrantRequest struct {
+	Permission string `json:"permission" validate:"required,max=100"`
+}
+
+type AssignRoleRequest struct {
+	RoleID uint `json:"role_id" validate:"required"`
+}
+
+type UserPermissionsResponse struct {
+	Permissions []string `json:"permissions"`
+}
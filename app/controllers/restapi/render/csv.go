@@ -0,0 +1,82 @@
+package render
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"path"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// writeCSV writes rows, a slice of structs, with a header of their json
+// field names. Nested values are written as JSON.
+func writeCSV(c *gin.Context, status int, rows any) {
+	v := reflect.ValueOf(rows)
+	elem := v.Type().Elem()
+	if elem.Kind() == reflect.Pointer {
+		elem = elem.Elem()
+	}
+
+	var columns []int
+	var header []string
+	for i := 0; i < elem.NumField(); i++ {
+		f := elem.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if !f.IsExported() || name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		columns = append(columns, i)
+		header = append(header, name)
+	}
+
+	filename := path.Base(c.Request.URL.Path) + ".csv"
+	c.Header("Content-Type", MIMECSV+"; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Status(status)
+
+	w := csv.NewWriter(c.Writer)
+	_ = w.Write(header)
+	record := make([]string, len(columns))
+	for i := 0; i < v.Len(); i++ {
+		row := reflect.Indirect(v.Index(i))
+		for j, col := range columns {
+			if !row.IsValid() {
+				record[j] = ""
+				continue
+			}
+			record[j] = csvValue(row.Field(col).Interface())
+		}
+		_ = w.Write(record)
+	}
+	w.Flush()
+}
+
+func csvValue(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case time.Time:
+		if v.IsZero() {
+			return ""
+		}
+		return v.Format(time.RFC3339)
+	case fmt.Stringer:
+		return v.String()
+	}
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Struct, reflect.Map, reflect.Slice, reflect.Array, reflect.Pointer, reflect.Interface:
+		if rv.Kind() != reflect.Struct && rv.IsNil() {
+			return ""
+		}
+		b, _ := json.Marshal(value)
+		return string(b)
+	}
+	return fmt.Sprint(value)
+}
@@ -0,0 +1,106 @@
+// Package render writes handler results in the encoding the client asks
+// for in Accept: JSON by default, MessagePack, protobuf for results that
+// are protobuf messages and CSV for lists. Errors stay JSON, see apperror.
+package render
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	ginrender "github.com/gin-gonic/gin/render"
+	"google.golang.org/protobuf/proto"
+	"net/http"
+	"reflect"
+	"service/pkg/pagination"
+	"slices"
+	"strings"
+)
+
+const (
+	MIMEJSON     = binding.MIMEJSON
+	MIMEMsgPack  = binding.MIMEMSGPACK2
+	MIMEProtobuf = binding.MIMEPROTOBUF
+	MIMECSV      = "text/csv"
+)
+
+// aliases are the other names clients use for an offered type.
+var aliases = map[string]string{
+	binding.MIMEMSGPACK:               MIMEMsgPack,
+	"application/protobuf":            MIMEProtobuf,
+	"application/vnd.google.protobuf": MIMEProtobuf,
+}
+
+// Data writes data with status. A slice of structs can also be sent as CSV.
+func Data(c *gin.Context, status int, data any) {
+	offered := []string{MIMEJSON, MIMEMsgPack}
+	if _, ok := data.(proto.Message); ok {
+		offered = append(offered, MIMEProtobuf)
+	}
+	if isRows(data) {
+		offered = append(offered, MIMECSV)
+	}
+
+	switch negotiate(c, offered) {
+	case MIMEMsgPack:
+		c.Render(status, ginrender.MsgPack{Data: data})
+	case MIMEProtobuf:
+		c.Render(status, ginrender.ProtoBuf{Data: data})
+	case MIMECSV:
+		writeCSV(c, status, data)
+	default:
+		c.JSON(status, data)
+	}
+}
+
+// List writes a page in the list envelope, or its rows alone as CSV, and
+// sets the Link header to the neighbouring pages.
+func List[T any](c *gin.Context, page *pagination.Page[T]) {
+	if link := pagination.Link(c.Request.URL, page); link != "" {
+		c.Header("Link", link)
+	}
+	if negotiate(c, []string{MIMEJSON, MIMEMsgPack, MIMECSV}) == MIMECSV {
+		writeCSV(c, http.StatusOK, page.Items)
+		return
+	}
+	Data(c, http.StatusOK, pagination.NewEnvelope(page))
+}
+
+// negotiate picks the first offered type the client accepts, JSON when it
+// accepts none of them. Like gin, it follows the order of Accept and
+// ignores q-values.
+func negotiate(c *gin.Context, offered []string) string {
+	if !slices.Contains(c.Writer.Header().Values("Vary"), "Accept") {
+		c.Writer.Header().Add("Vary", "Accept")
+	}
+	if c.GetHeader("Accept") == "" {
+		return MIMEJSON
+	}
+	if c.Accepted == nil {
+		for _, part := range strings.Split(c.GetHeader("Accept"), ",") {
+			mime, _, _ := strings.Cut(part, ";")
+			mime = strings.TrimSpace(mime)
+			if alias, ok := aliases[mime]; ok {
+				mime = alias
+			}
+			if mime != "" {
+				c.Accepted = append(c.Accepted, mime)
+			}
+		}
+	}
+	if format := c.NegotiateFormat(offered...); format != "" {
+		return format
+	}
+	return MIMEJSON
+}
+
+// isRows reports whether data is a slice of structs or struct pointers.
+func isRows(data any) bool {
+	t := reflect.TypeOf(data)
+	if t == nil || t.Kind() != reflect.Slice {
+		return false
+	}
+	elem := t.Elem()
+	if elem.Kind() == reflect.Pointer {
+		elem = elem.Elem()
+	}
+	return elem.Kind() == reflect.Struct
+}
@@ -0,0 +1,170 @@
+package render
+
+import (
+	"encoding/csv"
+	"net/http"
+	"net/http/httptest"
+	"service/pkg/pagination"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+func newContext(path, accept string) (*gin.Context, *httptest.ResponseRecorder) {
+	rec := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rec)
+	c.Request = httptest.NewRequest(http.MethodGet, path, nil)
+	if accept != "" {
+		c.Request.Header.Set("Accept", accept)
+	}
+	return c, rec
+}
+
+func TestNegotiate(t *testing.T) {
+	offered := []string{MIMEJSON, MIMEMsgPack, MIMEProtobuf, MIMECSV}
+	tests := []struct {
+		name   string
+		accept string
+		want   string
+	}{
+		{"no accept", "", MIMEJSON},
+		{"json", "application/json", MIMEJSON},
+		{"msgpack", "application/msgpack", MIMEMsgPack},
+		{"msgpack alias", "application/x-msgpack", MIMEMsgPack},
+		{"protobuf", "application/x-protobuf", MIMEProtobuf},
+		{"protobuf alias", "application/protobuf", MIMEProtobuf},
+		{"google protobuf alias", "application/vnd.google.protobuf", MIMEProtobuf},
+		{"csv", "text/csv", MIMECSV},
+		{"wildcard subtype", "text/*", MIMECSV},
+		{"anything", "*/*", MIMEJSON},
+		{"first listed wins", "text/csv, application/json", MIMECSV},
+		{"q-values ignored", "application/json;q=0.1, text/csv;q=0.9", MIMEJSON},
+		{"parameters stripped", "application/x-msgpack; q=0.5", MIMEMsgPack},
+		{"first offered match", "image/png, text/csv", MIMECSV},
+		// JSON rather than 406, errors are JSON anyway
+		{"nothing offered", "image/png", MIMEJSON},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, rec := newContext("/users", tt.accept)
+			if got := negotiate(c, offered); got != tt.want {
+				t.Errorf("negotiate(%q) = %q, want %q", tt.accept, got, tt.want)
+			}
+			negotiate(c, offered)
+			if vary := rec.Header().Values("Vary"); len(vary) != 1 || vary[0] != "Accept" {
+				t.Errorf("Vary = %v, want [Accept] once", vary)
+			}
+		})
+	}
+}
+
+type row struct {
+	ID      int       `json:"id"`
+	Name    string    `json:"name,omitempty"`
+	Secret  string    `json:"-"`
+	Created time.Time `json:"created_at"`
+	Tags    []string  `json:"tags"`
+	Parent  *row      `json:"parent"`
+	Plain   string
+	hidden  string
+}
+
+func TestData(t *testing.T) {
+	rows := []row{{ID: 1, Name: "a"}}
+	tests := []struct {
+		name   string
+		data   any
+		accept string
+		want   string
+	}{
+		{"json", rows, "", "application/json; charset=utf-8"},
+		{"msgpack", rows, "application/x-msgpack", "application/msgpack; charset=utf-8"},
+		{"protobuf message", wrapperspb.String("a"), "application/protobuf", "application/x-protobuf"},
+		{"protobuf needs a message", rows, "application/protobuf", "application/json; charset=utf-8"},
+		{"csv rows", rows, "text/csv", "text/csv; charset=utf-8"},
+		{"csv needs rows", gin.H{"id": 1}, "text/csv", "application/json; charset=utf-8"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, rec := newContext("/users", tt.accept)
+			Data(c, http.StatusCreated, tt.data)
+			if rec.Code != http.StatusCreated {
+				t.Errorf("status = %d, want %d", rec.Code, http.StatusCreated)
+			}
+			if got := rec.Header().Get("Content-Type"); got != tt.want {
+				t.Errorf("Content-Type = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	created := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	rows := []*row{
+		{ID: 1, Name: "a, b", Secret: "s", Created: created, Tags: []string{"x"}, Parent: &row{ID: 9}, Plain: "p", hidden: "h"},
+		{ID: 2},
+		nil,
+	}
+	c, rec := newContext("/api/v1/users", "text/csv")
+	writeCSV(c, http.StatusOK, rows)
+
+	if got := rec.Header().Get("Content-Disposition"); got != `attachment; filename="users.csv"` {
+		t.Errorf("Content-Disposition = %q", got)
+	}
+	records, err := csv.NewReader(strings.NewReader(rec.Body.String())).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{
+		{"id", "name", "created_at", "tags", "parent", "Plain"},
+		{"1", "a, b", "2026-01-02T03:04:05Z", `["x"]`, `{"id":9,"created_at":"0001-01-01T00:00:00Z","tags":null,"parent":null,"Plain":""}`, "p"},
+		{"2", "", "", "", "", ""},
+		{"", "", "", "", "", ""},
+	}
+	if len(records) != len(want) {
+		t.Fatalf("records = %q, want %q", records, want)
+	}
+	for i := range want {
+		if strings.Join(records[i], "|") != strings.Join(want[i], "|") {
+			t.Errorf("record %d = %q, want %q", i, records[i], want[i])
+		}
+	}
+}
+
+func TestList(t *testing.T) {
+	page := &pagination.Page[row]{Items: []row{{ID: 1}, {ID: 2}}, Limit: 2, Page: 2, Total: 5, Next: "3", Prev: "1"}
+
+	t.Run("json", func(t *testing.T) {
+		c, rec := newContext("/users?page=2&limit=2", "")
+		List(c, page)
+		if got := rec.Header().Get("Link"); got != `</users?limit=2&page=3>; rel="next", </users?limit=2&page=1>; rel="prev"` {
+			t.Errorf("Link = %q", got)
+		}
+		if !strings.HasPrefix(rec.Body.String(), `{"data":[{"id":1,`) || !strings.Contains(rec.Body.String(), `"meta":{"limit":2,"page":2,"total":5}`) {
+			t.Errorf("body = %s", rec.Body)
+		}
+	})
+
+	t.Run("csv", func(t *testing.T) {
+		c, rec := newContext("/users?page=2&limit=2", "text/csv")
+		List(c, page)
+		if rec.Header().Get("Link") == "" {
+			t.Error("no Link header")
+		}
+		records, err := csv.NewReader(strings.NewReader(rec.Body.String())).ReadAll()
+		if err != nil {
+			t.Fatal(err)
+		}
+		// the rows alone, without the envelope
+		if len(records) != 3 || records[0][0] != "id" || records[1][0] != "1" || records[2][0] != "2" {
+			t.Errorf("records = %q", records)
+		}
+	})
+}
@@ -1,16 +1,20 @@
 package restapi
 
 import (
+	"service/app/controllers/restapi/permission"
 	"service/app/controllers/restapi/user"
 	"service/app/usecases"
+	"service/config"
 )
 
 type Restapi struct {
-	UserHandler *user.UserHandler
+	UserHandler       *user.UserHandler
+	PermissionHandler *permission.PermissionHandler
 }
 
-func NewRestapi(usecase *usecases.Usecase) *Restapi {
+func NewRestapi(cfg *config.Config, usecase *usecases.Usecase) *Restapi {
 	return &Restapi{
-		UserHandler: user.NewUserHandler(usecase.UserUsecase),
+		UserHandler:       user.NewUserHandler(usecase.UserUsecase, &cfg.Stream),
+		PermissionHandler: permission.NewPermissionHandler(usecase.PermissionUsecase),
 	}
 }
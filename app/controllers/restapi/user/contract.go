@@ -1,8 +1,14 @@
 package user
 
-import "context"
+import (
+	"context"
+	"service/app/models"
+	"service/pkg/eventstream"
+	"service/pkg/pagination"
+)
 
 type IUserUsecase interface {
-	List(ctx context.Context) map[string]interface{}
+	List(ctx context.Context, req pagination.Request) (*pagination.Page[models.User], error)
 	Register(ctx context.Context, request *RegistrationRequest) (interface{}, error)
+	Events(ctx context.Context, request *EventsRequest) (*eventstream.Subscription, error)
 }
@@ -0,0 +1,212 @@
+package user
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"net/http"
+	"service/pkg/apperror"
+	"service/pkg/eventstream"
+	"service/pkg/otel"
+	"service/pkg/validation"
+	"time"
+)
+
+// EventUpdated is published when another service changed a user.
+const EventUpdated = "user.updated"
+
+// Event is the data of the user events.
+type Event struct {
+	ID string `json:"id"`
+}
+
+var ErrNotWebSocket = apperror.Validation("request.websocket_required", "expected a WebSocket upgrade request")
+
+var upgrader = websocket.Upgrader{
+	// callers authenticate with a bearer token rather than a cookie, so a
+	// page of another origin cannot open a stream on their behalf
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// @Summary Stream user events
+// @Description Server-Sent Events of user changes. Every event carries its id; reconnect with Last-Event-ID to receive
+// @Description the events missed meanwhile, or a stream.reset event when they are no longer kept. Idle streams get a
+// @Description comment every stream.heartbeat. A client that falls behind is disconnected and should reconnect.
+// @Tags users
+// @Produce text/event-stream
+// @Security BearerAuth
+// @Param type query []string false "event types, all when omitted" collectionFormat(multi) Enums(user.updated)
+// @Param user_id query []string false "users to follow, all when omitted" collectionFormat(multi)
+// @Param last_event_id query string false "resume after this event"
+// @Param Last-Event-ID header string false "resume after this event, takes precedence over last_event_id"
+// @Success 200 {object} eventstream.Event
+// @Failure 400 {object} apperror.ErrorResponse
+// @Failure 401 {object} apperror.ErrorResponse
+// @Failure 403 {object} apperror.ErrorResponse
+// @Router /user/events [get]
+func (h *UserHandler) Events(c *gin.Context) {
+	sub, ok := h.subscribe(c)
+	if !ok {
+		return
+	}
+	defer sub.Close()
+
+	header := c.Writer.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	// keep reverse proxies from buffering the stream
+	header.Set("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	rc := http.NewResponseController(c.Writer)
+	send := func(format string, args ...any) bool {
+		// an unsupported deadline only means a stalled client is noticed
+		// later, when the connection times out
+		_ = rc.SetWriteDeadline(time.Now().Add(h.stream.WriteTimeout))
+		if _, err := fmt.Fprintf(c.Writer, format, args...); err != nil {
+			return false
+		}
+		c.Writer.Flush()
+		return true
+	}
+
+	heartbeat := time.NewTicker(h.stream.Heartbeat)
+	defer heartbeat.Stop()
+
+	// the comment sends the headers right away
+	if !send(": connected\n\n") {
+		return
+	}
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case <-heartbeat.C:
+			if !send(": heartbeat\n\n") {
+				return
+			}
+		case event, ok := <-sub.Events():
+			if !ok {
+				// the client reconnects by itself and resumes
+				return
+			}
+			data, err := json.Marshal(event)
+			if err != nil {
+				return
+			}
+			if !send("id: %s\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data) {
+				return
+			}
+		}
+	}
+}
+
+// @Summary Stream user events over WebSocket
+// @Description The events of GET /user/events as JSON text messages, for clients that prefer WebSocket. The server pings
+// @Description every stream.heartbeat and closes with 1013 when the client fell behind, 1001 when shutting down.
+// @Tags users
+// @Security BearerAuth
+// @Param type query []string false "event types, all when omitted" collectionFormat(multi) Enums(user.updated)
+// @Param user_id query []string false "users to follow, all when omitted" collectionFormat(multi)
+// @Param last_event_id query string false "resume after this event"
+// @Success 101
+// @Failure 400 {object} apperror.ErrorResponse
+// @Failure 401 {object} apperror.ErrorResponse
+// @Failure 403 {object} apperror.ErrorResponse
+// @Router /user/events/ws [get]
+func (h *UserHandler) EventsSocket(c *gin.Context) {
+	if !websocket.IsWebSocketUpgrade(c.Request) {
+		apperror.AbortHTTP(c, ErrNotWebSocket)
+		return
+	}
+	// subscribed first so a refused stream still gets an error response
+	sub, ok := h.subscribe(c)
+	if !ok {
+		return
+	}
+	defer sub.Close()
+
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// Upgrade already answered with an error
+		return
+	}
+	defer conn.Close()
+
+	// the hijacked connection outlives the request context; the reader
+	// notices the client leaving instead
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go h.readSocket(conn, cancel)
+
+	heartbeat := time.NewTicker(h.stream.Heartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-heartbeat.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(h.stream.WriteTimeout)); err != nil {
+				return
+			}
+		case event, ok := <-sub.Events():
+			if !ok {
+				code, reason := websocket.CloseGoingAway, "server shutting down"
+				if errors.Is(sub.Err(), eventstream.ErrLagged) {
+					code, reason = websocket.CloseTryAgainLater, "fell behind, reconnect with last_event_id"
+				}
+				_ = conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason),
+					time.Now().Add(h.stream.WriteTimeout))
+				return
+			}
+			_ = conn.SetWriteDeadline(time.Now().Add(h.stream.WriteTimeout))
+			if err := conn.WriteJSON(event); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// readSocket discards what the client sends and calls done once it is
+// gone or stopped answering pings.
+func (h *UserHandler) readSocket(conn *websocket.Conn, done context.CancelFunc) {
+	defer done()
+
+	timeout := 2 * h.stream.Heartbeat
+	conn.SetReadLimit(512)
+	_ = conn.SetReadDeadline(time.Now().Add(timeout))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(timeout))
+	})
+	for {
+		if _, _, err := conn.NextReader(); err != nil {
+			return
+		}
+	}
+}
+
+// subscribe binds the filters and subscribes, or answers with the error.
+func (h *UserHandler) subscribe(c *gin.Context) (*eventstream.Subscription, bool) {
+	ctx, span := otel.AddSpan(c.Request.Context(), "user.events")
+	defer span.End()
+
+	req := EventsRequest{}
+	if err := validation.Bind(c, &req); err != nil {
+		apperror.AbortHTTP(c, err)
+		return nil, false
+	}
+	if id := c.GetHeader("Last-Event-ID"); id != "" {
+		req.LastEventID = id
+	}
+
+	sub, err := h.userUsecase.Events(ctx, &req)
+	if err != nil {
+		apperror.AbortHTTP(c, err)
+		return nil, false
+	}
+	return sub, true
+}
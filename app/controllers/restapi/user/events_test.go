@@ -0,0 +1,202 @@
+package user
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"service/config"
+	"service/pkg/eventstream"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// streamUsecase serves Events from a hub like the user usecase does.
+type streamUsecase struct {
+	IUserUsecase
+	hub *eventstream.Hub
+}
+
+func (u streamUsecase) Events(ctx context.Context, request *EventsRequest) (*eventstream.Subscription, error) {
+	return u.hub.Subscribe(eventstream.Filter{Types: request.Types, Subjects: request.UserIDs}, request.LastEventID)
+}
+
+// newStreamServer serves the event handlers of a hub without pub/sub, so
+// Publish delivers right away.
+func newStreamServer(t *testing.T, cfg config.Stream) (*httptest.Server, *eventstream.Hub) {
+	t.Helper()
+	hub := eventstream.NewHub(&cfg, nil)
+	h := NewUserHandler(streamUsecase{hub: hub}, &cfg)
+	r := gin.New()
+	r.GET("/user/events", h.Events)
+	r.GET("/user/events/ws", h.EventsSocket)
+	srv := httptest.NewServer(r)
+	// the hub ends open streams before the server waits for them
+	t.Cleanup(srv.Close)
+	t.Cleanup(hub.Close)
+	return srv, hub
+}
+
+func streamConfig() config.Stream {
+	cfg := config.NewConfig().Stream
+	cfg.History = 2
+	cfg.Heartbeat = time.Hour
+	return cfg
+}
+
+func publishUser(t *testing.T, hub *eventstream.Hub, id string) {
+	t.Helper()
+	if err := hub.Publish(context.Background(), EventUpdated, id, Event{ID: id}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// sseEvent is one event of a text/event-stream, comments skipped.
+type sseEvent struct {
+	id, event, data string
+}
+
+func readSSE(t *testing.T, lines *bufio.Scanner) sseEvent {
+	t.Helper()
+	var e sseEvent
+	for lines.Scan() {
+		line := lines.Text()
+		switch {
+		case line == "" && e != (sseEvent{}):
+			return e
+		case strings.HasPrefix(line, "id: "):
+			e.id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "event: "):
+			e.event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			e.data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+	t.Fatalf("stream ended: %v", lines.Err())
+	return e
+}
+
+func openSSE(t *testing.T, url, lastEventID string) (*http.Response, *bufio.Scanner) {
+	t.Helper()
+	req, _ := http.NewRequest(http.MethodGet, url, nil)
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = resp.Body.Close() })
+	lines := bufio.NewScanner(resp.Body)
+	// the connected comment proves the subscription is registered
+	if !lines.Scan() || lines.Text() != ": connected" {
+		t.Fatalf("first line %q", lines.Text())
+	}
+	return resp, lines
+}
+
+func TestEventsSSE(t *testing.T) {
+	srv, hub := newStreamServer(t, streamConfig())
+
+	live, lines := openSSE(t, srv.URL+"/user/events?user_id=u1", "")
+	if ct := live.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	publishUser(t, hub, "u2")
+	publishUser(t, hub, "u1")
+	first := readSSE(t, lines)
+	if first.event != EventUpdated || first.id == "" {
+		t.Fatalf("event = %+v", first)
+	}
+	var payload eventstream.Event
+	if err := json.Unmarshal([]byte(first.data), &payload); err != nil || payload.Subject != "u1" || payload.ID != first.id {
+		t.Errorf("data = %s: %v", first.data, err)
+	}
+
+	// resuming replays what was missed, the header wins over the query
+	publishUser(t, hub, "u1")
+	_, resumed := openSSE(t, srv.URL+"/user/events?last_event_id=unknown", first.id)
+	if got := readSSE(t, resumed); got.event != EventUpdated || got.id <= first.id {
+		t.Errorf("replayed %+v", got)
+	}
+
+	// the history keeps two events, so the first one is gone
+	publishUser(t, hub, "u3")
+	_, reset := openSSE(t, srv.URL+"/user/events", first.id)
+	if got := readSSE(t, reset); got.event != eventstream.TypeReset {
+		t.Errorf("expired id: %+v, want %s", got, eventstream.TypeReset)
+	}
+
+	// closing the hub ends the streams and the client reconnects
+	hub.Close()
+	for lines.Scan() {
+	}
+	if lines.Err() != nil {
+		t.Errorf("stream ended with %v", lines.Err())
+	}
+}
+
+func TestEventsRefused(t *testing.T) {
+	srv, _ := newStreamServer(t, streamConfig())
+	disabled := streamConfig()
+	disabled.Enabled = false
+	off, _ := newStreamServer(t, disabled)
+
+	for _, tc := range []struct {
+		url    string
+		status int
+		code   string
+	}{
+		{srv.URL + "/user/events?type=user.deleted", http.StatusBadRequest, "request.invalid"},
+		{off.URL + "/user/events", http.StatusServiceUnavailable, "stream.disabled"},
+		{srv.URL + "/user/events/ws", http.StatusBadRequest, "request.websocket_required"},
+	} {
+		resp, err := http.Get(tc.url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var body struct {
+			Error struct{ Code string } `json:"error"`
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&body)
+		_ = resp.Body.Close()
+		if resp.StatusCode != tc.status || body.Error.Code != tc.code {
+			t.Errorf("%s: %d %q, want %d %q", tc.url, resp.StatusCode, body.Error.Code, tc.status, tc.code)
+		}
+	}
+}
+
+func TestEventsSocket(t *testing.T) {
+	srv, hub := newStreamServer(t, streamConfig())
+	wsURL := "ws" + strings.TrimPrefix(srv.URL, "http") + "/user/events/ws?user_id=u1"
+
+	// the handler subscribes before answering the upgrade, so the
+	// subscription exists once Dial returns
+	conn, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+
+	publishUser(t, hub, "u2")
+	publishUser(t, hub, "u1")
+	var event eventstream.Event
+	if err := conn.ReadJSON(&event); err != nil || event.Type != EventUpdated || event.Subject != "u1" {
+		t.Fatalf("event = %+v: %v", event, err)
+	}
+
+	hub.Close()
+	if err := conn.ReadJSON(&event); !websocket.IsCloseError(err, websocket.CloseGoingAway) {
+		t.Errorf("after Close: %v, want 1001", err)
+	}
+}
@@ -1,3 +1,38 @@
 package user
 
-type RegistrationRequest struct{}
+import "service/pkg/pagination"
+
+// CacheResource names the cached responses built from users.
+const CacheResource = "users"
+
+// ListOptions are the paging limits and sortable fields of the user list.
+var ListOptions = pagination.Options{
+	Sortable:    []string{"created_at", "name", "email"},
+	DefaultSort: []pagination.Sort{{Field: "created_at", Desc: true}},
+	Key:         "id",
+}
+
+// EventsRequest selects the events of a user stream; without filters it
+// carries every user event.
+type EventsRequest struct {
+	Types   []string `form:"type" validate:"max=10,dive,oneof=user.updated"`
+	UserIDs []string `form:"user_id" validate:"max=100,dive,required,max=64"`
+	// LastEventID resumes after that event. The Last-Event-ID header, sent
+	// by EventSource when it reconnects, takes precedence.
+	LastEventID string `form:"last_event_id" validate:"max=64"`
+}
+
+// UpdateRequest changes the fields that are set.
+type UpdateRequest struct {
+	ID    string  `json:"id" validate:"required,max=64"`
+	Name  *string `json:"name" validate:"omitnil,min=1,max=100"`
+	Email *string `json:"email" validate:"omitnil,email"`
+	Phone *string `json:"phone" validate:"omitnil,phone_id"`
+}
+
+type RegistrationRequest struct {
+	Name     string `json:"name" validate:"required,max=100"`
+	Email    string `json:"email" validate:"required,email,unique_email"`
+	Phone    string `json:"phone" validate:"omitempty,phone_id"`
+	Password string `json:"password" validate:"required,min=8,max=72"`
+}
@@ -1,56 +1,89 @@
 package user
 
 import (
+	"net/http"
+	"service/app/controllers/restapi/render"
+	"service/config"
+	"service/pkg/apperror"
 	"service/pkg/otel"
+	"service/pkg/pagination"
+	"service/pkg/validation"
 
 	"github.com/gin-gonic/gin"
 )
 
 type UserHandler struct {
 	userUsecase IUserUsecase
+	stream      *config.Stream
 }
 
-func NewUserHandler(userUsecase IUserUsecase) *UserHandler {
+func NewUserHandler(userUsecase IUserUsecase, stream *config.Stream) *UserHandler {
 	return &UserHandler{
 		userUsecase: userUsecase,
+		stream:      stream,
 	}
 }
 
 // @Summary Get List
-// @Description get list
+// @Description list users, by page or by cursor
 // @Tags users
-// @Accept json
 // @Produce json
-// @Param id path int true "User ID"
-// @Success 200 {object} map[string]interface{}
-// @Failure 400 {object} map[string]interface{}
-// @Router /users/list [get]
+// @Param page query int false "1-based page, exclusive with cursor"
+// @Param limit query int false "page size, at most 100"
+// @Param cursor query string false "next_cursor or prev_cursor of a previous response"
+// @Param sort query string false "comma separated created_at, name, email; prefix - to sort descending"
+// @Success 200 {object} pagination.Envelope[models.User]
+// @Failure 400 {object} apperror.ErrorResponse
+// @Router /user/data [get]
 func (h *UserHandler) List(c *gin.Context) {
-	ctx, span := otel.AddSpan(c.Request.Context(), "user.get")
+	ctx, span := otel.AddSpan(c.Request.Context(), "user.list")
 	defer span.End()
 
-	data := h.userUsecase.List(ctx)
+	req, err := pagination.Bind(c, ListOptions)
+	if err != nil {
+		apperror.AbortHTTP(c, err)
+		return
+	}
+
+	page, err := h.userUsecase.List(ctx, req)
+	if err != nil {
+		apperror.AbortHTTP(c, err)
+		return
+	}
 
-	c.JSON(200, data)
+	render.List(c, page)
 }
 
+// @Summary Register
+// @Description register a user. Retrying with the same Idempotency-Key replays the first response.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param Idempotency-Key header string false "makes the request safe to retry"
+// @Param request body RegistrationRequest true "user"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} apperror.ErrorResponse
+// @Failure 409 {object} apperror.ErrorResponse
+// @Failure 415 {object} apperror.ErrorResponse
+// @Failure 429 {object} apperror.ErrorResponse
+// @Router /user/register [post]
 func (h *UserHandler) Register(c *gin.Context) {
 	ctx, span := otel.AddSpan(c.Request.Context(), "user.get")
 	defer span.End()
 
 	req := RegistrationRequest{}
 
-	err := c.Bind(&req)
+	err := validation.Bind(c, &req)
 	if err != nil {
-		c.JSON(400, gin.H{"error": err.Error()})
+		apperror.AbortHTTP(c, err)
 		return
 	}
 
 	data, err := h.userUsecase.Register(ctx, &req)
 	if err != nil {
-		c.JSON(400, gin.H{"error": err.Error()})
+		apperror.AbortHTTP(c, err)
 		return
 	}
 
-	c.JSON(200, data)
+	render.Data(c, http.StatusOK, data)
 }
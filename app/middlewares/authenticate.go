@@ -0,0 +1,40 @@
+package middlewares
+
+import (
+	"github.com/gin-gonic/gin"
+	"service/pkg/apperror"
+	"service/pkg/auth"
+	"service/pkg/openapi"
+	"strings"
+)
+
+// Authenticate requires a valid bearer token and stores the principal in
+// the request context, see auth.PrincipalFrom. The request of a secured
+// operation is checked against the API document only then, see
+// openapi.ValidateAuthenticated. Put it on a route group to protect every
+// route below it:
+//
+//	authed := api.Group("", mid.Authenticate())
+func (m *Middlewares) Authenticate() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		scheme, token, _ := strings.Cut(c.GetHeader("Authorization"), " ")
+		if !strings.EqualFold(scheme, "Bearer") || token == "" {
+			c.Header("WWW-Authenticate", `Bearer`)
+			apperror.AbortHTTP(c, auth.ErrMissingToken)
+			return
+		}
+
+		principal, err := m.verifier.Verify(c.Request.Context(), strings.TrimSpace(token))
+		if err != nil {
+			c.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+			apperror.AbortHTTP(c, err)
+			return
+		}
+
+		c.Request = c.Request.WithContext(auth.WithPrincipal(c.Request.Context(), principal))
+		if openapi.ValidateAuthenticated(c); c.IsAborted() {
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,175 @@
+package middlewares
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"io"
+	"net/http"
+	"service/pkg/apperror"
+	"service/pkg/auth"
+	"service/pkg/cache"
+	"service/pkg/logger"
+	"service/pkg/validation"
+)
+
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+var (
+	ErrIdempotencyKeyInvalid = apperror.Validation("idempotency.invalid_key", "Idempotency-Key must be 1 to 255 characters")
+	ErrIdempotencyKeyReused  = apperror.Validation("idempotency.key_reused", "Idempotency-Key was used with a different request")
+	ErrIdempotencyInProgress = apperror.Conflict("idempotency.in_progress", "a request with this Idempotency-Key is still in progress")
+)
+
+// storedResponse is what a repeated request gets back.
+type storedResponse struct {
+	Fingerprint string      `json:"fingerprint"`
+	Status      int         `json:"status"`
+	Header      http.Header `json:"header"`
+	Body        []byte      `json:"body"`
+}
+
+// Idempotent makes a route safe to retry. The first response to an
+// Idempotency-Key is stored and replayed to later requests with the same
+// key; a duplicate arriving while the first is still running waits briefly
+// for it and then gets 409. Requests without the header are served
+// normally. Server errors are not stored so a retry can still succeed.
+func (m *Middlewares) Idempotent() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > 255 {
+			apperror.AbortHTTP(c, ErrIdempotencyKeyInvalid)
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				apperror.AbortHTTP(c, validation.ErrTooLarge.WithDetail("limit", tooLarge.Limit))
+				return
+			}
+			apperror.AbortHTTP(c, validation.ErrMalformed.Wrap(err))
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		sum := sha256.Sum256(body)
+		fingerprint := hex.EncodeToString(sum[:])
+
+		// scope keys per caller and route so clients cannot collide
+		scope := c.Request.Method + " " + c.FullPath() + " " + key
+		if principal, ok := auth.PrincipalFrom(c.Request.Context()); ok {
+			scope = principal.Subject + " " + scope
+		}
+		scopeSum := sha256.Sum256([]byte(scope))
+		cacheKey := "idempotency:" + hex.EncodeToString(scopeSum[:])
+
+		cfg := m.idempotency
+		ctx := c.Request.Context()
+		err = m.cache.Lock(ctx, cacheKey+":lock", int64(cfg.LockTTL.Seconds()), func(ctx context.Context) error {
+			stored := storedResponse{}
+			err := m.cache.Get(ctx, cacheKey, &stored)
+			if err == nil {
+				if stored.Fingerprint != fingerprint {
+					return ErrIdempotencyKeyReused
+				}
+				replay(c, &stored)
+				return nil
+			}
+			if !errors.Is(err, cache.ErrMiss) {
+				return err
+			}
+
+			w := &captureWriter{ResponseWriter: c.Writer}
+			c.Writer = w
+			c.Next()
+			c.Writer = w.ResponseWriter
+
+			if w.Status() >= http.StatusInternalServerError {
+				return nil
+			}
+			return m.cache.Set(ctx, cacheKey, storedResponse{
+				Fingerprint: fingerprint,
+				Status:      w.Status(),
+				Header:      representationHeaders(w.handlerHeader()),
+				Body:        w.body.Bytes(),
+			}, int(cfg.TTL.Seconds()))
+		})
+
+		switch {
+		case err == nil:
+		case c.Writer.Written():
+			// the handler answered but storing the response failed, the
+			// next retry runs the handler again
+			logger.Logger.Warn("idempotency: store response",
+				logger.F("error", err.Error()),
+				logger.F("path", c.FullPath()))
+		case errors.Is(err, cache.ErrLocked):
+			apperror.AbortHTTP(c, ErrIdempotencyInProgress)
+		case errors.Is(err, ErrIdempotencyKeyReused):
+			apperror.AbortHTTP(c, err)
+		default:
+			apperror.AbortHTTP(c, apperror.Unavailable("idempotency.unavailable", "idempotency store is unavailable").Wrap(err))
+		}
+	}
+}
+
+func replay(c *gin.Context, stored *storedResponse) {
+	for h, values := range stored.Header {
+		for _, v := range values {
+			c.Writer.Header().Add(h, v)
+		}
+	}
+	c.Header("Idempotent-Replayed", "true")
+	c.Status(stored.Status)
+	_, _ = c.Writer.Write(stored.Body)
+	c.Abort()
+}
+
+// captureWriter keeps a copy of the body written by the handler and of the
+// headers it set, before outer writers like the compressor add their own on
+// the first write.
+type captureWriter struct {
+	gin.ResponseWriter
+	body   bytes.Buffer
+	header http.Header
+}
+
+func (w *captureWriter) Write(b []byte) (int, error) {
+	w.snapshot()
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *captureWriter) WriteString(s string) (int, error) {
+	w.snapshot()
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *captureWriter) WriteHeaderNow() {
+	w.snapshot()
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+func (w *captureWriter) snapshot() {
+	if w.header == nil {
+		w.header = w.Header().Clone()
+	}
+}
+
+// handlerHeader returns the headers as the handler left them.
+func (w *captureWriter) handlerHeader() http.Header {
+	if w.header == nil {
+		return w.Header()
+	}
+	return w.header
+}
@@ -0,0 +1,132 @@
+package middlewares
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"service/pkg/apperror"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func post(r http.Handler, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if key != "" {
+		req.Header.Set(IdempotencyKeyHeader, key)
+	}
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	return rec
+}
+
+// errorCode returns the code of an error envelope, "" for other bodies.
+func errorCode(rec *httptest.ResponseRecorder) string {
+	var body apperror.ErrorResponse
+	_ = json.Unmarshal(rec.Body.Bytes(), &body)
+	return body.Error.Code
+}
+
+func TestIdempotentReplay(t *testing.T) {
+	m := newTestMiddlewares(t)
+	var calls atomic.Int32
+	r := gin.New()
+	r.POST("/users", m.Idempotent(), func(c *gin.Context) {
+		n := calls.Add(1)
+		c.Header("Location", "/users/u1")
+		c.JSON(http.StatusCreated, gin.H{"call": n})
+	})
+
+	first := post(r, "key-1", `{"name":"ana"}`)
+	second := post(r, "key-1", `{"name":"ana"}`)
+	if calls.Load() != 1 {
+		t.Fatalf("handler ran %d times", calls.Load())
+	}
+	if second.Code != http.StatusCreated || second.Body.String() != first.Body.String() ||
+		second.Header().Get("Location") != "/users/u1" || second.Header().Get("Idempotent-Replayed") != "true" {
+		t.Errorf("replay = %d %s %v", second.Code, second.Body, second.Header())
+	}
+	if first.Header().Get("Idempotent-Replayed") != "" {
+		t.Error("first response marked as replayed")
+	}
+
+	// another key and requests without one run the handler
+	post(r, "key-2", `{"name":"ana"}`)
+	post(r, "", `{"name":"ana"}`)
+	post(r, "", `{"name":"ana"}`)
+	if calls.Load() != 4 {
+		t.Errorf("handler ran %d times, want 4", calls.Load())
+	}
+}
+
+func TestIdempotentKeyReused(t *testing.T) {
+	m := newTestMiddlewares(t)
+	r := gin.New()
+	r.POST("/users", m.Idempotent(), func(c *gin.Context) { c.Status(http.StatusCreated) })
+
+	post(r, "key-1", `{"name":"ana"}`)
+	rec := post(r, "key-1", `{"name":"budi"}`)
+	if rec.Code != http.StatusBadRequest || errorCode(rec) != "idempotency.key_reused" {
+		t.Errorf("different body: %d %s", rec.Code, rec.Body)
+	}
+
+	rec = post(r, strings.Repeat("k", 256), `{}`)
+	if rec.Code != http.StatusBadRequest || errorCode(rec) != "idempotency.invalid_key" {
+		t.Errorf("long key: %d %s", rec.Code, rec.Body)
+	}
+}
+
+func TestIdempotentServerErrorNotStored(t *testing.T) {
+	m := newTestMiddlewares(t)
+	var calls atomic.Int32
+	r := gin.New()
+	r.POST("/users", m.Idempotent(), func(c *gin.Context) {
+		if calls.Add(1) == 1 {
+			c.Status(http.StatusServiceUnavailable)
+			return
+		}
+		c.Status(http.StatusCreated)
+	})
+
+	if rec := post(r, "key-1", `{}`); rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("first = %d", rec.Code)
+	}
+	if rec := post(r, "key-1", `{}`); rec.Code != http.StatusCreated || calls.Load() != 2 {
+		t.Errorf("retry = %d after %d calls", rec.Code, calls.Load())
+	}
+}
+
+func TestIdempotentInProgress(t *testing.T) {
+	m := newTestMiddlewares(t)
+	started := make(chan struct{})
+	release := make(chan struct{})
+	r := gin.New()
+	r.POST("/users", m.Idempotent(), func(c *gin.Context) {
+		close(started)
+		<-release
+		c.Status(http.StatusCreated)
+	})
+
+	first := make(chan *httptest.ResponseRecorder)
+	go func() { first <- post(r, "key-1", `{}`) }()
+	<-started
+
+	// the duplicate waits for the lock a few seconds, then gives up
+	start := time.Now()
+	rec := post(r, "key-1", `{}`)
+	if rec.Code != http.StatusConflict || errorCode(rec) != "idempotency.in_progress" {
+		t.Errorf("duplicate: %d %s after %s", rec.Code, rec.Body, time.Since(start))
+	}
+
+	close(release)
+	if rec := <-first; rec.Code != http.StatusCreated {
+		t.Errorf("first = %d", rec.Code)
+	}
+	if rec := post(r, "key-1", `{}`); rec.Code != http.StatusCreated || rec.Header().Get("Idempotent-Replayed") != "true" {
+		t.Errorf("after the first finished: %d %v", rec.Code, rec.Header())
+	}
+}
@@ -1,8 +1,72 @@
 package middlewares
 
+import (
+	"net/http"
+	"service/config"
+	"service/pkg/auth"
+	"service/pkg/cache"
+	"service/pkg/httpcache"
+	"service/pkg/ratelimit"
+	"service/pkg/requestid"
+	"slices"
+	"strings"
+)
+
 type Middlewares struct {
+	verifier             *auth.Verifier
+	permissions          IPermissionChecker
+	limiter              *ratelimit.Limiter
+	cache                cache.ICache
+	idempotency          config.Idempotency
+	responses            *httpcache.Store
+	responseCacheEnabled bool
+}
+
+func NewMiddlewares(
+	cfg *config.Rest,
+	verifier *auth.Verifier,
+	permissions IPermissionChecker,
+	limiter *ratelimit.Limiter,
+	cache cache.ICache,
+	responses *httpcache.Store,
+) *Middlewares {
+	return &Middlewares{
+		verifier:             verifier,
+		permissions:          permissions,
+		limiter:              limiter,
+		cache:                cache,
+		idempotency:          cfg.Idempotency,
+		responses:            responses,
+		responseCacheEnabled: cfg.ResponseCache.Enabled,
+	}
+}
+
+// storedHeaders describe a body and are stored with it by the response cache
+// and the idempotency store, along with the service's own X- headers. The
+// rest, like Content-Encoding, Vary or the rate limit headers, are set per
+// request by the middlewares producing them.
+var storedHeaders = []string{"Content-Type", "Content-Language", "Location", "ETag", "Link"}
+
+// perRequestHeaders are X- headers that describe the request, not the body.
+var perRequestHeaders = []string{requestid.Header, "X-Cache"}
+
+func representationHeaders(header http.Header) http.Header {
+	kept := http.Header{}
+	for h, values := range header {
+		h = http.CanonicalHeaderKey(h)
+		if hasHeader(perRequestHeaders, h) {
+			continue
+		}
+		if hasHeader(storedHeaders, h) || strings.HasPrefix(h, "X-") {
+			kept[h] = slices.Clone(values)
+		}
+	}
+	return kept
 }
 
-func NewMiddlewares() *Middlewares {
-	return &Middlewares{}
+// hasHeader reports whether list names the canonical header h.
+func hasHeader(list []string, h string) bool {
+	return slices.ContainsFunc(list, func(name string) bool {
+		return http.CanonicalHeaderKey(name) == h
+	})
 }
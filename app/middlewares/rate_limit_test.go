@@ -0,0 +1,87 @@
+package middlewares
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"service/config"
+	"service/pkg/apperror"
+	"service/pkg/logger"
+	"service/pkg/ratelimit"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestRateLimitHeaders(t *testing.T) {
+	prev := logger.Logger
+	logger.Use(zaptest.NewLogger(t))
+	t.Cleanup(func() { logger.Logger = prev })
+
+	cfg := config.NewConfig()
+	cfg.RateLimit.Policies = map[string]config.RateLimitPolicy{
+		"login": {Algorithm: config.RateLimitSlidingWindow, Limit: 2, Window: time.Minute, Key: config.RateLimitByIP},
+	}
+	limiter := ratelimit.NewLimiter(nil, &cfg.RateLimit)
+	m := NewMiddlewares(&cfg.Rest, nil, nil, limiter, nil, nil)
+
+	r := gin.New()
+	r.POST("/login", m.RateLimit("login"), func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+	r.POST("/other", m.RateLimit("unknown"), func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+
+	send := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, nil)
+		req.RemoteAddr = "192.0.2.1:1234"
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		return rec
+	}
+
+	for i, want := range []string{"1", "0"} {
+		rec := send("/login")
+		if rec.Code != http.StatusNoContent {
+			t.Fatalf("request %d: %d", i, rec.Code)
+		}
+		if got := rec.Header().Get("RateLimit-Remaining"); got != want {
+			t.Errorf("request %d: RateLimit-Remaining = %s, want %s", i, got, want)
+		}
+	}
+
+	rec := send("/login")
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("third request: %d", rec.Code)
+	}
+	for header, want := range map[string]string{
+		"RateLimit-Policy":    "2;w=60",
+		"RateLimit-Limit":     "2",
+		"RateLimit-Remaining": "0",
+	} {
+		if got := rec.Header().Get(header); got != want {
+			t.Errorf("%s = %q, want %q", header, got, want)
+		}
+	}
+	for _, header := range []string{"Retry-After", "RateLimit-Reset"} {
+		if got := rec.Header().Get(header); got == "" || got == "0" {
+			t.Errorf("%s = %q", header, got)
+		}
+	}
+	body := apperror.ErrorResponse{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if body.Error.Code != "rate_limit.exceeded" || body.Error.Details["retry_after"] == nil {
+		t.Errorf("body = %+v", body.Error)
+	}
+
+	// unknown policies let requests through without headers
+	rec = send("/other")
+	if rec.Code != http.StatusNoContent || rec.Header().Get("RateLimit-Limit") != "" {
+		t.Errorf("unknown policy: %d %v", rec.Code, rec.Header())
+	}
+}
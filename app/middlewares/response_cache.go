@@ -0,0 +1,143 @@
+package middlewares
+
+import (
+	"bytes"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"service/pkg/auth"
+	"service/pkg/cache"
+	"service/pkg/httpcache"
+	"service/pkg/logger"
+	"slices"
+	"time"
+)
+
+// CachePolicy describes how the responses of a GET route are cached.
+type CachePolicy struct {
+	// Resource names what the response is built from. Usecases invalidate
+	// it when the resource changes.
+	Resource string
+	// CacheControl is sent as is, e.g. "private, no-cache" to let clients
+	// keep a copy they revalidate with If-None-Match.
+	CacheControl string
+	// TTL keeps rendered responses in the cache; 0 only adds ETags.
+	TTL time.Duration
+}
+
+// CacheResponse tags successful GET responses with a strong ETag, answers
+// 304 when If-None-Match matches and, while router.response_cache is
+// enabled, serves repeated requests from the cache. Responses are keyed by
+// route, query, caller and Accept, so a user never sees another user's
+// response.
+func (m *Middlewares) CacheResponse(policy CachePolicy) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead {
+			c.Next()
+			return
+		}
+		if policy.CacheControl != "" {
+			c.Header("Cache-Control", policy.CacheControl)
+		}
+
+		ctx := c.Request.Context()
+		store := m.responseCacheEnabled && policy.TTL > 0
+		key := responseCacheKey(c)
+		// set here as well since hits skip the handler that negotiates
+		if !slices.Contains(c.Writer.Header().Values("Vary"), "Accept") {
+			c.Writer.Header().Add("Vary", "Accept")
+		}
+		var gen string
+		if store {
+			// read before the handler runs: a change made meanwhile moves
+			// the resource past gen, so the response stored below is stale
+			// from the start and never served
+			var stored *httpcache.Response
+			var err error
+			gen, err = m.responses.Generation(ctx, policy.Resource)
+			if err == nil {
+				stored, err = m.responses.Get(ctx, policy.Resource, gen, key)
+			}
+			switch {
+			case err == nil:
+				c.Header("X-Cache", "HIT")
+				writeCached(c, stored)
+				c.Abort()
+				return
+			case !errors.Is(err, cache.ErrMiss):
+				// serve from the handler while the cache is down
+				store = false
+				logger.Logger.Warn("response cache read failed", logger.F("error", err.Error()))
+			}
+			c.Header("X-Cache", "MISS")
+		}
+
+		w := &holdWriter{ResponseWriter: c.Writer}
+		c.Writer = w
+		c.Next()
+		c.Writer = w.ResponseWriter
+
+		resp := &httpcache.Response{Status: w.Status(), Body: w.body.Bytes()}
+		if resp.Status != http.StatusOK {
+			_, _ = c.Writer.Write(resp.Body)
+			return
+		}
+		c.Header("ETag", httpcache.ETag(resp.Body))
+		if store {
+			resp.Header = representationHeaders(c.Writer.Header())
+			if err := m.responses.Set(ctx, policy.Resource, gen, key, resp, policy.TTL); err != nil {
+				logger.Logger.Warn("response cache write failed", logger.F("error", err.Error()))
+			}
+		}
+		writeCached(c, resp)
+	}
+}
+
+func responseCacheKey(c *gin.Context) string {
+	caller := ""
+	if principal, ok := auth.PrincipalFrom(c.Request.Context()); ok {
+		caller = principal.Subject
+	}
+	// Encode sorts the parameters so their order does not matter; the
+	// format is negotiated from Accept, so it is part of the key too
+	return c.FullPath() + "?" + c.Request.URL.Query().Encode() + " " + caller + " " + c.GetHeader("Accept")
+}
+
+// writeCached writes resp, or 304 when the client already has it.
+func writeCached(c *gin.Context, resp *httpcache.Response) {
+	for h, values := range resp.Header {
+		c.Writer.Header()[http.CanonicalHeaderKey(h)] = values
+	}
+	etag := c.Writer.Header().Get("ETag")
+	if etag == "" {
+		etag = httpcache.ETag(resp.Body)
+		c.Header("ETag", etag)
+	}
+	if httpcache.NotModified(c.GetHeader("If-None-Match"), etag) {
+		c.Writer.WriteHeader(http.StatusNotModified)
+		c.Writer.WriteHeaderNow()
+		return
+	}
+	c.Writer.WriteHeader(resp.Status)
+	if c.Request.Method != http.MethodHead {
+		_, _ = c.Writer.Write(resp.Body)
+	}
+}
+
+// holdWriter holds the body back so the ETag header can be set from it.
+type holdWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *holdWriter) Write(b []byte) (int, error) {
+	return w.body.Write(b)
+}
+
+func (w *holdWriter) WriteString(s string) (int, error) {
+	return w.body.WriteString(s)
+}
+
+func (w *holdWriter) Written() bool {
+	return w.body.Len() > 0 || w.ResponseWriter.Written()
+}
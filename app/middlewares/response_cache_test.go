@@ -0,0 +1,119 @@
+package middlewares
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"service/config"
+	"service/pkg/datastore/redis"
+	"service/pkg/httpcache"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+func newTestMiddlewares(t *testing.T) *Middlewares {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	cfg := config.NewConfig()
+	cfg.Redis.Host = mr.Addr()
+	cfg.Rest.ResponseCache.Enabled = true
+	rdb := redis.NewRedis(context.Background(), &cfg)
+	t.Cleanup(func() { _ = rdb.Close() })
+	return NewMiddlewares(&cfg.Rest, nil, nil, nil, rdb, httpcache.NewStore(rdb))
+}
+
+func get(r http.Handler, header ...string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/users", nil)
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestCacheResponseInvalidatedWhileRendering(t *testing.T) {
+	m := newTestMiddlewares(t)
+	policy := CachePolicy{Resource: "users", TTL: time.Minute}
+
+	version := "v1"
+	r := gin.New()
+	r.GET("/users", m.CacheResponse(policy), func(c *gin.Context) {
+		body := version
+		if version == "v1" {
+			// the resource changes after the handler read it
+			version = "v2"
+			if err := m.responses.Invalidate(c.Request.Context(), policy.Resource); err != nil {
+				t.Fatal(err)
+			}
+		}
+		c.String(http.StatusOK, body)
+	})
+
+	for i, want := range []struct {
+		body  string
+		cache string
+	}{
+		{"v1", "MISS"},
+		// v1 was rendered before the change and must not be served
+		{"v2", "MISS"},
+		{"v2", "HIT"},
+	} {
+		rec := get(r)
+		if rec.Body.String() != want.body || rec.Header().Get("X-Cache") != want.cache {
+			t.Errorf("request %d: %s %s, want %s %s", i, rec.Header().Get("X-Cache"), rec.Body, want.cache, want.body)
+		}
+	}
+}
+
+func TestCacheResponseNotModified(t *testing.T) {
+	m := newTestMiddlewares(t)
+	for _, policy := range []CachePolicy{
+		{Resource: "users", CacheControl: "private, no-cache"},
+		{Resource: "users", CacheControl: "private, no-cache", TTL: time.Minute},
+	} {
+		calls := 0
+		r := gin.New()
+		r.GET("/users", m.CacheResponse(policy), func(c *gin.Context) {
+			calls++
+			c.JSON(http.StatusOK, gin.H{"users": []string{"ann"}})
+		})
+
+		first := get(r)
+		etag := first.Header().Get("ETag")
+		if first.Code != http.StatusOK || etag == "" {
+			t.Fatalf("ttl %v: first %d, ETag %q", policy.TTL, first.Code, etag)
+		}
+		if got := first.Header().Get("Cache-Control"); got != policy.CacheControl {
+			t.Errorf("ttl %v: Cache-Control = %q", policy.TTL, got)
+		}
+
+		for _, inm := range []string{etag, "W/" + etag, `"other", ` + etag, "*"} {
+			rec := get(r, "If-None-Match", inm)
+			if rec.Code != http.StatusNotModified || rec.Body.Len() != 0 {
+				t.Errorf("ttl %v: If-None-Match %s answered %d %q", policy.TTL, inm, rec.Code, rec.Body)
+			}
+			if rec.Header().Get("ETag") != etag {
+				t.Errorf("ttl %v: 304 ETag = %q, want %q", policy.TTL, rec.Header().Get("ETag"), etag)
+			}
+		}
+		if rec := get(r, "If-None-Match", `"other"`); rec.Code != http.StatusOK || rec.Body.String() != first.Body.String() {
+			t.Errorf("ttl %v: stale If-None-Match answered %d %q", policy.TTL, rec.Code, rec.Body)
+		}
+
+		wantCalls := 6
+		if policy.TTL > 0 {
+			wantCalls = 1
+		}
+		if calls != wantCalls {
+			t.Errorf("ttl %v: handler ran %d times, want %d", policy.TTL, calls, wantCalls)
+		}
+	}
+}
@@ -1,5 +1,26 @@
 package middlewares
 
-func NewValidatePermission() {
-	
+import (
+	"context"
+	"github.com/gin-gonic/gin"
+	"service/pkg/apperror"
+	"service/pkg/auth"
+)
+
+type IPermissionChecker interface {
+	HasPermission(ctx context.Context, userID, permission string) (bool, error)
+}
+
+// Require lets the request through only when the authenticated caller has
+// every listed permission. It must run after Authenticate:
+//
+//	api.GET("/users", mid.Authenticate(), mid.Require("user:read"), handler)
+func (m *Middlewares) Require(permissions ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := auth.Require(c.Request.Context(), m.permissions, permissions...); err != nil {
+			apperror.AbortHTTP(c, err)
+			return
+		}
+		c.Next()
+	}
 }
@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// Permission is a single capability such as "user:read".
+type Permission struct {
+	ID          uint   `json:"id" gorm:"primaryKey"`
+	Name        string `json:"name" gorm:"size:100;uniqueIndex"`
+	Description string `json:"description"`
+}
+
+type Role struct {
+	ID          uint         `json:"id" gorm:"primaryKey"`
+	Name        string       `json:"name" gorm:"size:100;uniqueIndex"`
+	Description string       `json:"description"`
+	Permissions []Permission `json:"permissions" gorm:"many2many:role_permissions" extensions:"x-nullable"`
+	CreatedAt   time.Time    `json:"created_at"`
+}
+
+// UserRole assigns a role to a user.
+type UserRole struct {
+	UserID string `json:"user_id" gorm:"primaryKey;size:64"`
+	RoleID uint   `json:"role_id" gorm:"primaryKey"`
+}
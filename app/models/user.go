@@ -1,5 +1,11 @@
 package models
 
+import "time"
+
 type User struct {
-	ID string `json:"id"`
+	ID        string    `json:"id" bson:"id"`
+	Name      string    `json:"name" bson:"name"`
+	Email     string    `json:"email" bson:"email"`
+	Phone     string    `json:"phone" bson:"phone"`
+	CreatedAt time.Time `json:"created_at" bson:"created_at"`
 }
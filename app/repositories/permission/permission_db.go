@@ -0,0 +1,151 @@
+package permission
+
+import (
+	"context"
+	"errors"
+	"gorm.io/gorm"
+	"service/app/models"
+	"service/pkg/apperror"
+	"service/pkg/datastore/orm"
+)
+
+var (
+	ErrRoleNotFound = apperror.NotFound("role.not_found", "role not found")
+	ErrRoleExists   = apperror.Conflict("role.exists", "role already exists")
+)
+
+type PermissionDB struct {
+	db orm.IDatabase
+}
+
+func NewPermissionRepo(db orm.IDatabase) *PermissionDB {
+	return &PermissionDB{
+		db: db,
+	}
+}
+
+// conn joins the transaction started by the transactor, if any.
+func (r *PermissionDB) conn(ctx context.Context) *gorm.DB {
+	if tx := r.db.WithTx(ctx); tx != nil {
+		return tx
+	}
+	return r.db.DB(ctx)
+}
+
+// Migrate creates or updates the role, permission and assignment tables.
+func (r *PermissionDB) Migrate(ctx context.Context) error {
+	return r.conn(ctx).AutoMigrate(&models.Permission{}, &models.Role{}, &models.UserRole{})
+}
+
+func (r *PermissionDB) ListRoles(ctx context.Context) ([]models.Role, error) {
+	var roles []models.Role
+	err := r.conn(ctx).Preload("Permissions").Order("name").Find(&roles).Error
+	return roles, err
+}
+
+func (r *PermissionDB) GetRole(ctx context.Context, id uint) (*models.Role, error) {
+	role := &models.Role{}
+	err := r.conn(ctx).Preload("Permissions").First(role, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrRoleNotFound.WithDetail("id", id)
+	}
+	return role, err
+}
+
+func (r *PermissionDB) CreateRole(ctx context.Context, role *models.Role) error {
+	err := r.conn(ctx).Omit("Permissions").Create(role).Error
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return ErrRoleExists.WithDetail("name", role.Name)
+	}
+	return err
+}
+
+// EnsureRole loads the role named role.Name into role, creating it when
+// there is none.
+func (r *PermissionDB) EnsureRole(ctx context.Context, role *models.Role) error {
+	db := r.conn(ctx)
+	err := db.Where(models.Role{Name: role.Name}).First(role).Error
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	err = db.Omit("Permissions").Create(role).Error
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		// another replica created it meanwhile
+		return db.Where(models.Role{Name: role.Name}).First(role).Error
+	}
+	return err
+}
+
+func (r *PermissionDB) DeleteRole(ctx context.Context, id uint) error {
+	db := r.conn(ctx)
+	role := &models.Role{ID: id}
+	if err := db.Model(role).Association("Permissions").Clear(); err != nil {
+		return err
+	}
+	if err := db.Where("role_id = ?", id).Delete(&models.UserRole{}).Error; err != nil {
+		return err
+	}
+	res := db.Delete(role)
+	if res.Error == nil && res.RowsAffected == 0 {
+		return ErrRoleNotFound.WithDetail("id", id)
+	}
+	return res.Error
+}
+
+// Grant adds a permission to a role, creating the permission on first use.
+func (r *PermissionDB) Grant(ctx context.Context, roleID uint, name string) error {
+	db := r.conn(ctx)
+	perm := &models.Permission{}
+	if err := db.Where(models.Permission{Name: name}).FirstOrCreate(perm).Error; err != nil {
+		return err
+	}
+	return db.Model(&models.Role{ID: roleID}).Association("Permissions").Append(perm)
+}
+
+func (r *PermissionDB) Revoke(ctx context.Context, roleID uint, name string) error {
+	db := r.conn(ctx)
+	perm := &models.Permission{}
+	err := db.Where(models.Permission{Name: name}).First(perm).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return db.Model(&models.Role{ID: roleID}).Association("Permissions").Delete(perm)
+}
+
+func (r *PermissionDB) AssignRole(ctx context.Context, userID string, roleID uint) error {
+	err := r.conn(ctx).Create(&models.UserRole{UserID: userID, RoleID: roleID}).Error
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return nil
+	}
+	return err
+}
+
+func (r *PermissionDB) UnassignRole(ctx context.Context, userID string, roleID uint) error {
+	return r.conn(ctx).Where("user_id = ? AND role_id = ?", userID, roleID).Delete(&models.UserRole{}).Error
+}
+
+// UsersWithRole lists the users holding a role, to invalidate their cached
+// permissions when the role changes.
+func (r *PermissionDB) UsersWithRole(ctx context.Context, roleID uint) ([]string, error) {
+	var users []string
+	err := r.conn(ctx).Model(&models.UserRole{}).Where("role_id = ?", roleID).Pluck("user_id", &users).Error
+	return users, err
+}
+
+// UserPermissions returns the names of every permission granted to the
+// user through any of their roles.
+func (r *PermissionDB) UserPermissions(ctx context.Context, userID string) ([]string, error) {
+	var names []string
+	err := r.conn(ctx).
+		Table("permissions").
+		Distinct("permissions.name").
+		Joins("JOIN role_permissions ON role_permissions.permission_id = permissions.id").
+		Joins("JOIN user_roles ON user_roles.role_id = role_permissions.role_id").
+		Where("user_roles.user_id = ?", userID).
+		Order("permissions.name").
+		Pluck("permissions.name", &names).Error
+	return names, err
+}
@@ -3,6 +3,7 @@ package repositories
 import (
 	"github.com/elastic/go-elasticsearch/v9"
 	"go.mongodb.org/mongo-driver/mongo"
+	"service/app/repositories/permission"
 	"service/app/repositories/user"
 	"service/pkg/cache"
 	pkg_elastic "service/pkg/datastore/elastic"
@@ -13,6 +14,7 @@ type Repositories struct {
 	Transactor  orm.ITransactor
 	Cache       cache.ICache
 	UserDB      *user.UserDB
+	Permission  *permission.PermissionDB
 	UserElastic *user.UserElasticRepo
 	UserMongo   *user.UserMongoRepo
 }
@@ -28,7 +30,8 @@ func NewRepositories(
 		Cache:       Cache,
 		Transactor:  orm.NewTransactor(db),
 		UserDB:      user.NewUserRepo(db),
+		Permission:  permission.NewPermissionRepo(db),
 		UserElastic: user.NewUserElasticRepo(userIdxElastic),
-		UserMongo:   user.NewUserMongoRepo(mongoDB),
+		UserMongo:   user.NewUserMongoRepo(mongoDB, "sekolahmu"),
 	}
 }
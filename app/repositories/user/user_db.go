@@ -2,7 +2,17 @@ package user
 
 import (
 	"context"
+	"errors"
+	"gorm.io/gorm"
+	"service/app/models"
+	"service/pkg/apperror"
 	"service/pkg/datastore/orm"
+	"service/pkg/pagination"
+)
+
+var (
+	ErrUserNotFound = apperror.NotFound("user.not_found", "user not found")
+	ErrEmailTaken   = apperror.Conflict("user.email_taken", "email is already registered")
 )
 
 type UserDB struct {
@@ -15,8 +25,50 @@ func NewUserRepo(db orm.IDatabase) *UserDB {
 	}
 }
 
-func (r *UserDB) List(ctx context.Context) {
+// conn joins the transaction started by the transactor, if any.
+func (r *UserDB) conn(ctx context.Context) *gorm.DB {
+	if tx := r.db.WithTx(ctx); tx != nil {
+		return tx
+	}
+	return r.db.DB(ctx)
+}
+
+func (r *UserDB) Get(ctx context.Context, id string) (*models.User, error) {
+	user := &models.User{}
+	err := r.conn(ctx).Where("id = ?", id).First(user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrUserNotFound.WithDetail("id", id)
+	}
+	return user, err
+}
+
+// Update saves the name, email and phone of user.
+func (r *UserDB) Update(ctx context.Context, user *models.User) error {
+	err := r.conn(ctx).Model(user).Select("name", "email", "phone").Updates(user).Error
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return ErrEmailTaken.WithDetail("email", user.Email)
+	}
+	return err
+}
+
+func (r *UserDB) List(ctx context.Context, req pagination.Request) ([]models.User, int64, error) {
+	var total int64
+	if !req.IsCursor() {
+		if err := r.db.DB(ctx).Model(&models.User{}).Count(&total).Error; err != nil {
+			return nil, 0, err
+		}
+	}
+
+	users := []models.User{}
+	err := orm.Paginate(r.db.DB(ctx), req).Find(&users).Error
+	return users, total, err
 }
 
 func (r *UserDB) Create(ctx context.Context, data interface{}) {
 }
+
+func (r *UserDB) ExistsByEmail(ctx context.Context, email string) (bool, error) {
+	var count int64
+	err := r.db.DB(ctx).Model(&models.User{}).Where("email = ?", email).Limit(1).Count(&count).Error
+	return count > 0, err
+}
@@ -2,7 +2,9 @@ package user
 
 import (
 	"context"
+	"service/app/models"
 	"service/pkg/datastore/elastic"
+	"service/pkg/pagination"
 )
 
 type UserElasticRepo struct {
@@ -15,6 +17,6 @@ func NewUserElasticRepo(elastic *elastic.Elastic) *UserElasticRepo {
 	}
 }
 
-func (r *UserElasticRepo) List(ctx context.Context) {
-
+func (r *UserElasticRepo) List(ctx context.Context, req pagination.Request) ([]models.User, int64, error) {
+	return elastic.Search[models.User](ctx, r.elastic, &elastic.QueryRequest{}, req)
 }
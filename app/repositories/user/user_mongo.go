@@ -2,19 +2,45 @@ package user
 
 import (
 	"context"
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
+	"service/app/models"
+	"service/pkg/datastore/mongodb"
+	"service/pkg/pagination"
 )
 
 type UserMongoRepo struct {
-	mongoDB *mongo.Client
+	mongoDB  *mongo.Client
+	database string
 }
 
-func NewUserMongoRepo(mongoDB *mongo.Client) *UserMongoRepo {
+func NewUserMongoRepo(mongoDB *mongo.Client, database string) *UserMongoRepo {
 	return &UserMongoRepo{
-		mongoDB: mongoDB,
+		mongoDB:  mongoDB,
+		database: database,
 	}
 }
 
-func (r *UserMongoRepo) List(ctx context.Context) {
+func (r *UserMongoRepo) users() *mongo.Collection {
+	return r.mongoDB.Database(r.database).Collection("users")
+}
+
+func (r *UserMongoRepo) List(ctx context.Context, req pagination.Request) ([]models.User, int64, error) {
+	filter := bson.M{}
+	var total int64
+	if !req.IsCursor() {
+		var err error
+		if total, err = r.users().CountDocuments(ctx, filter); err != nil {
+			return nil, 0, err
+		}
+	}
 
+	filter, opts := mongodb.Paginate(filter, req)
+	cursor, err := r.users().Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	users := []models.User{}
+	err = cursor.All(ctx, &users)
+	return users, total, err
 }
@@ -0,0 +1,43 @@
+package permission
+
+import (
+	"context"
+	"service/app/models"
+	"service/pkg/otel"
+)
+
+const (
+	// AdminRole is assigned at startup to the subjects of auth.admins.
+	AdminRole = "admin"
+	// ManagePermission guards the role and permission admin endpoints.
+	ManagePermission = "permission:manage"
+)
+
+// Bootstrap makes sure AdminRole exists, holds ManagePermission and is
+// assigned to admins, so a fresh deployment has someone able to grant
+// access. It runs on every start, on every replica, and changes nothing
+// once done.
+func (u *PermissionUsecase) Bootstrap(ctx context.Context, admins []string) error {
+	ctx, span := otel.AddSpan(ctx, "permission_usecase.bootstrap")
+	defer span.End()
+
+	if len(admins) == 0 {
+		return nil
+	}
+	role := &models.Role{Name: AdminRole, Description: "manages roles and permissions"}
+	if err := u.permissionRepo.EnsureRole(ctx, role); err != nil {
+		return err
+	}
+	if err := u.permissionRepo.Grant(ctx, role.ID, ManagePermission); err != nil {
+		return err
+	}
+	for _, subject := range admins {
+		if err := u.permissionRepo.AssignRole(ctx, subject, role.ID); err != nil {
+			return err
+		}
+	}
+
+	u.invalidate(ctx, admins...)
+	u.invalidateRoles(ctx)
+	return nil
+}
@@ -0,0 +1,62 @@
+package permission
+
+import (
+	"context"
+	"errors"
+	"service/pkg/cache"
+	"service/pkg/logger"
+	"service/pkg/otel"
+	"slices"
+)
+
+func permissionsKey(userID string) string {
+	return "rbac:permissions:" + userID
+}
+
+// UserPermissions returns the user's permissions, from the cache when
+// possible. A cache failure falls back to the database.
+func (u *PermissionUsecase) UserPermissions(ctx context.Context, userID string) ([]string, error) {
+	ctx, span := otel.AddSpan(ctx, "permission_usecase.user_permissions")
+	defer span.End()
+
+	var perms []string
+	err := u.cache.Get(ctx, permissionsKey(userID), &perms)
+	if err == nil {
+		return perms, nil
+	}
+	if !errors.Is(err, cache.ErrMiss) {
+		logger.Logger.Warn("permission cache read failed", logger.F("error", err.Error()))
+	}
+
+	perms, err = u.permissionRepo.UserPermissions(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if perms == nil {
+		perms = []string{}
+	}
+	if err := u.cache.Set(ctx, permissionsKey(userID), perms, int(u.cacheTTL.Seconds())); err != nil {
+		logger.Logger.Warn("permission cache write failed", logger.F("error", err.Error()))
+	}
+	return perms, nil
+}
+
+func (u *PermissionUsecase) HasPermission(ctx context.Context, userID, permission string) (bool, error) {
+	perms, err := u.UserPermissions(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	return slices.Contains(perms, permission), nil
+}
+
+// invalidate drops the cached permissions of users. A failure is only
+// logged: the entries still expire after cacheTTL.
+func (u *PermissionUsecase) invalidate(ctx context.Context, userIDs ...string) {
+	keys := make([]string, len(userIDs))
+	for i, id := range userIDs {
+		keys[i] = permissionsKey(id)
+	}
+	if err := u.cache.Delete(ctx, keys...); err != nil {
+		logger.Logger.Warn("permission cache invalidation failed", logger.F("error", err.Error()))
+	}
+}
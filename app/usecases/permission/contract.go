@@ -0,0 +1,25 @@
+package permission
+
+import (
+	"context"
+	"service/app/models"
+)
+
+// IResponseCache drops cached HTTP responses of a resource.
+type IResponseCache interface {
+	Invalidate(ctx context.Context, resources ...string) error
+}
+
+type IPermissionRepo interface {
+	ListRoles(ctx context.Context) ([]models.Role, error)
+	GetRole(ctx context.Context, id uint) (*models.Role, error)
+	CreateRole(ctx context.Context, role *models.Role) error
+	EnsureRole(ctx context.Context, role *models.Role) error
+	DeleteRole(ctx context.Context, id uint) error
+	Grant(ctx context.Context, roleID uint, name string) error
+	Revoke(ctx context.Context, roleID uint, name string) error
+	AssignRole(ctx context.Context, userID string, roleID uint) error
+	UnassignRole(ctx context.Context, userID string, roleID uint) error
+	UsersWithRole(ctx context.Context, roleID uint) ([]string, error)
+	UserPermissions(ctx context.Context, userID string) ([]string, error)
+}
@@ -0,0 +1,31 @@
+package permission
+
+import (
+	"service/pkg/cache"
+	"service/pkg/datastore/orm"
+	"time"
+)
+
+type PermissionUsecase struct {
+	transactor     orm.ITransactor
+	permissionRepo IPermissionRepo
+	cache          cache.ICache
+	cacheTTL       time.Duration
+	responses      IResponseCache
+}
+
+func NewPermissionUsecase(
+	transactor orm.ITransactor,
+	permissionRepo IPermissionRepo,
+	cache cache.ICache,
+	cacheTTL time.Duration,
+	responses IResponseCache,
+) *PermissionUsecase {
+	return &PermissionUsecase{
+		transactor:     transactor,
+		permissionRepo: permissionRepo,
+		cache:          cache,
+		cacheTTL:       cacheTTL,
+		responses:      responses,
+	}
+}
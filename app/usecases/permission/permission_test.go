@@ -0,0 +1,241 @@
+package permission
+
+import (
+	"context"
+	"service/app/controllers/restapi/permission"
+	"service/app/models"
+	permissionrepo "service/app/repositories/permission"
+	"service/config"
+	"service/pkg/datastore/redis"
+	"slices"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+// memoryRepo keeps roles and assignments in maps.
+type memoryRepo struct {
+	roles     map[uint]*models.Role
+	userRoles map[string][]uint
+	queries   int
+}
+
+func newMemoryRepo() *memoryRepo {
+	return &memoryRepo{roles: map[uint]*models.Role{}, userRoles: map[string][]uint{}}
+}
+
+func (r *memoryRepo) ListRoles(ctx context.Context) ([]models.Role, error) {
+	var roles []models.Role
+	for _, role := range r.roles {
+		roles = append(roles, *role)
+	}
+	return roles, nil
+}
+
+func (r *memoryRepo) GetRole(ctx context.Context, id uint) (*models.Role, error) {
+	role, ok := r.roles[id]
+	if !ok {
+		return nil, permissionrepo.ErrRoleNotFound
+	}
+	return role, nil
+}
+
+func (r *memoryRepo) CreateRole(ctx context.Context, role *models.Role) error {
+	role.ID = uint(len(r.roles) + 1)
+	r.roles[role.ID] = role
+	return nil
+}
+
+func (r *memoryRepo) EnsureRole(ctx context.Context, role *models.Role) error {
+	for _, existing := range r.roles {
+		if existing.Name == role.Name {
+			*role = *existing
+			return nil
+		}
+	}
+	return r.CreateRole(ctx, role)
+}
+
+func (r *memoryRepo) DeleteRole(ctx context.Context, id uint) error {
+	delete(r.roles, id)
+	for user, roles := range r.userRoles {
+		r.userRoles[user] = slices.DeleteFunc(roles, func(roleID uint) bool { return roleID == id })
+	}
+	return nil
+}
+
+func (r *memoryRepo) Grant(ctx context.Context, roleID uint, name string) error {
+	role := r.roles[roleID]
+	if !slices.ContainsFunc(role.Permissions, func(p models.Permission) bool { return p.Name == name }) {
+		role.Permissions = append(role.Permissions, models.Permission{Name: name})
+	}
+	return nil
+}
+
+func (r *memoryRepo) Revoke(ctx context.Context, roleID uint, name string) error {
+	role := r.roles[roleID]
+	role.Permissions = slices.DeleteFunc(role.Permissions, func(p models.Permission) bool { return p.Name == name })
+	return nil
+}
+
+func (r *memoryRepo) AssignRole(ctx context.Context, userID string, roleID uint) error {
+	if !slices.Contains(r.userRoles[userID], roleID) {
+		r.userRoles[userID] = append(r.userRoles[userID], roleID)
+	}
+	return nil
+}
+
+func (r *memoryRepo) UnassignRole(ctx context.Context, userID string, roleID uint) error {
+	r.userRoles[userID] = slices.DeleteFunc(r.userRoles[userID], func(id uint) bool { return id == roleID })
+	return nil
+}
+
+func (r *memoryRepo) UsersWithRole(ctx context.Context, roleID uint) ([]string, error) {
+	var users []string
+	for user, roles := range r.userRoles {
+		if slices.Contains(roles, roleID) {
+			users = append(users, user)
+		}
+	}
+	return users, nil
+}
+
+func (r *memoryRepo) UserPermissions(ctx context.Context, userID string) ([]string, error) {
+	r.queries++
+	var names []string
+	for _, roleID := range r.userRoles[userID] {
+		for _, p := range r.roles[roleID].Permissions {
+			if !slices.Contains(names, p.Name) {
+				names = append(names, p.Name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+type noTx struct{}
+
+func (noTx) WithTx(ctx context.Context, txFunc func(context.Context) error) error {
+	return txFunc(ctx)
+}
+
+type responseCache struct {
+	invalidated []string
+}
+
+func (c *responseCache) Invalidate(ctx context.Context, resources ...string) error {
+	c.invalidated = append(c.invalidated, resources...)
+	return nil
+}
+
+func newTestUsecase(t *testing.T) (*PermissionUsecase, *memoryRepo, *responseCache) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	cfg := config.NewConfig()
+	cfg.Redis.Host = mr.Addr()
+	rdb := redis.NewRedis(context.Background(), &cfg)
+	t.Cleanup(func() { _ = rdb.Close() })
+
+	repo := newMemoryRepo()
+	responses := &responseCache{}
+	return NewPermissionUsecase(noTx{}, repo, rdb, time.Minute, responses), repo, responses
+}
+
+func TestHasPermission(t *testing.T) {
+	ctx := context.Background()
+	u, repo, _ := newTestUsecase(t)
+	reader := &models.Role{Name: "reader", Permissions: []models.Permission{{Name: "user:read"}}}
+	_ = repo.CreateRole(ctx, reader)
+	_ = repo.AssignRole(ctx, "ann", reader.ID)
+
+	for _, tc := range []struct {
+		user, permission string
+		want             bool
+	}{
+		{"ann", "user:read", true},
+		{"ann", "user:write", false},
+		{"bob", "user:read", false},
+	} {
+		got, err := u.HasPermission(ctx, tc.user, tc.permission)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tc.want {
+			t.Errorf("HasPermission(%s, %s) = %v, want %v", tc.user, tc.permission, got, tc.want)
+		}
+	}
+	// the checks above hit the repository once per user
+	if repo.queries != 2 {
+		t.Errorf("repository queried %d times, want 2", repo.queries)
+	}
+}
+
+func TestGrantChangesInvalidateCache(t *testing.T) {
+	ctx := context.Background()
+	u, repo, responses := newTestUsecase(t)
+	role := &models.Role{Name: "editor"}
+	_ = repo.CreateRole(ctx, role)
+	if err := u.AssignRole(ctx, "ann", role.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	check := func(step string, want bool) {
+		t.Helper()
+		got, err := u.HasPermission(ctx, "ann", "user:write")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("%s: HasPermission = %v, want %v", step, got, want)
+		}
+	}
+
+	check("before grant", false)
+	if err := u.Grant(ctx, role.ID, "user:write"); err != nil {
+		t.Fatal(err)
+	}
+	check("after grant", true)
+	if err := u.Revoke(ctx, role.ID, "user:write"); err != nil {
+		t.Fatal(err)
+	}
+	check("after revoke", false)
+	_ = u.Grant(ctx, role.ID, "user:write")
+	check("after second grant", true)
+	if err := u.UnassignRole(ctx, "ann", role.ID); err != nil {
+		t.Fatal(err)
+	}
+	check("after unassign", false)
+
+	// grant, revoke and grant again each drop the cached role listings
+	if n := len(responses.invalidated); n != 3 || responses.invalidated[0] != permission.RolesCacheResource {
+		t.Errorf("invalidated %v", responses.invalidated)
+	}
+}
+
+func TestBootstrapGrantsAdmins(t *testing.T) {
+	ctx := context.Background()
+	u, repo, _ := newTestUsecase(t)
+
+	// cached before the bootstrap, as on a replica that served a 403
+	if ok, _ := u.HasPermission(ctx, "ops", ManagePermission); ok {
+		t.Fatal("ops can manage before bootstrap")
+	}
+	for i := 0; i < 2; i++ {
+		if err := u.Bootstrap(ctx, []string{"ops"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if ok, _ := u.HasPermission(ctx, "ops", ManagePermission); !ok {
+		t.Error("ops cannot manage after bootstrap")
+	}
+	if len(repo.roles) != 1 || len(repo.roles[1].Permissions) != 1 || len(repo.userRoles["ops"]) != 1 {
+		t.Errorf("bootstrap is not idempotent: %+v %v", repo.roles, repo.userRoles)
+	}
+
+	if err := u.Bootstrap(ctx, nil); err != nil || len(repo.roles) != 1 {
+		t.Errorf("bootstrap without admins: %v, %d roles", err, len(repo.roles))
+	}
+}
@@ -0,0 +1,114 @@
+package permission
+
+import (
+	"context"
+	"service/app/controllers/restapi/permission"
+	"service/app/models"
+	"service/pkg/logger"
+	"service/pkg/otel"
+)
+
+func (u *PermissionUsecase) ListRoles(ctx context.Context) ([]models.Role, error) {
+	ctx, span := otel.AddSpan(ctx, "permission_usecase.list_roles")
+	defer span.End()
+
+	return u.permissionRepo.ListRoles(ctx)
+}
+
+func (u *PermissionUsecase) CreateRole(ctx context.Context, request *permission.CreateRoleRequest) (*models.Role, error) {
+	ctx, span := otel.AddSpan(ctx, "permission_usecase.create_role")
+	defer span.End()
+
+	role := &models.Role{Name: request.Name, Description: request.Description}
+	if err := u.permissionRepo.CreateRole(ctx, role); err != nil {
+		return nil, err
+	}
+	u.invalidateRoles(ctx)
+	return role, nil
+}
+
+func (u *PermissionUsecase) DeleteRole(ctx context.Context, roleID uint) error {
+	ctx, span := otel.AddSpan(ctx, "permission_usecase.delete_role")
+	defer span.End()
+
+	return u.changeRole(ctx, roleID, func(ctx context.Context) error {
+		return u.permissionRepo.DeleteRole(ctx, roleID)
+	})
+}
+
+func (u *PermissionUsecase) Grant(ctx context.Context, roleID uint, name string) error {
+	ctx, span := otel.AddSpan(ctx, "permission_usecase.grant")
+	defer span.End()
+
+	return u.changeRole(ctx, roleID, func(ctx context.Context) error {
+		return u.permissionRepo.Grant(ctx, roleID, name)
+	})
+}
+
+func (u *PermissionUsecase) Revoke(ctx context.Context, roleID uint, name string) error {
+	ctx, span := otel.AddSpan(ctx, "permission_usecase.revoke")
+	defer span.End()
+
+	return u.changeRole(ctx, roleID, func(ctx context.Context) error {
+		return u.permissionRepo.Revoke(ctx, roleID, name)
+	})
+}
+
+func (u *PermissionUsecase) AssignRole(ctx context.Context, userID string, roleID uint) error {
+	ctx, span := otel.AddSpan(ctx, "permission_usecase.assign_role")
+	defer span.End()
+
+	err := u.transactor.WithTx(ctx, func(ctx context.Context) error {
+		if _, err := u.permissionRepo.GetRole(ctx, roleID); err != nil {
+			return err
+		}
+		return u.permissionRepo.AssignRole(ctx, userID, roleID)
+	})
+	if err != nil {
+		return err
+	}
+
+	u.invalidate(ctx, userID)
+	return nil
+}
+
+func (u *PermissionUsecase) UnassignRole(ctx context.Context, userID string, roleID uint) error {
+	ctx, span := otel.AddSpan(ctx, "permission_usecase.unassign_role")
+	defer span.End()
+
+	if err := u.permissionRepo.UnassignRole(ctx, userID, roleID); err != nil {
+		return err
+	}
+
+	u.invalidate(ctx, userID)
+	return nil
+}
+
+// changeRole runs change on an existing role and, once committed, drops
+// the cached permissions of every user holding it and the cached roles.
+func (u *PermissionUsecase) changeRole(ctx context.Context, roleID uint, change func(ctx context.Context) error) error {
+	var users []string
+	err := u.transactor.WithTx(ctx, func(ctx context.Context) error {
+		if _, err := u.permissionRepo.GetRole(ctx, roleID); err != nil {
+			return err
+		}
+		var err error
+		if users, err = u.permissionRepo.UsersWithRole(ctx, roleID); err != nil {
+			return err
+		}
+		return change(ctx)
+	})
+	if err != nil {
+		return err
+	}
+
+	u.invalidate(ctx, users...)
+	u.invalidateRoles(ctx)
+	return nil
+}
+
+func (u *PermissionUsecase) invalidateRoles(ctx context.Context) {
+	if err := u.responses.Invalidate(ctx, permission.RolesCacheResource); err != nil {
+		logger.Logger.Warn("response cache invalidation failed", logger.F("error", err.Error()))
+	}
+}
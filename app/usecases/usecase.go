@@ -2,15 +2,26 @@ package usecases
 
 import (
 	"service/app/repositories"
+	"service/app/usecases/permission"
 	"service/app/usecases/user"
+	"service/config"
+	"service/pkg/eventstream"
+	"service/pkg/httpcache"
 )
 
 type Usecase struct {
-	UserUsecase *user.UserUsecase
+	UserUsecase       *user.UserUsecase
+	PermissionUsecase *permission.PermissionUsecase
 }
 
-func NewUsecase(repositories *repositories.Repositories) *Usecase {
+func NewUsecase(cfg *config.Config, repositories *repositories.Repositories, responses *httpcache.Store, events *eventstream.Hub) *Usecase {
 	return &Usecase{
-		UserUsecase: user.NewUserUsecase(repositories.Transactor, repositories.UserDB),
+		UserUsecase: user.NewUserUsecase(repositories.Transactor, repositories.UserDB, responses, events),
+		PermissionUsecase: permission.NewPermissionUsecase(
+			repositories.Transactor,
+			repositories.Permission,
+			repositories.Cache,
+			cfg.Auth.PermissionCacheTTL,
+			responses),
 	}
 }
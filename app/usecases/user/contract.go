@@ -1,8 +1,26 @@
 package user
 
-import "context"
+import (
+	"context"
+	"service/app/models"
+	"service/pkg/eventstream"
+	"service/pkg/pagination"
+)
+
+// IResponseCache drops cached HTTP responses of a resource.
+type IResponseCache interface {
+	Invalidate(ctx context.Context, resources ...string) error
+}
+
+// IEventStream publishes to and subscribes to the live event streams.
+type IEventStream interface {
+	Publish(ctx context.Context, eventType, subject string, data interface{}) error
+	Subscribe(filter eventstream.Filter, lastEventID string) (*eventstream.Subscription, error)
+}
 
 type IUserRepo interface {
-	List(ctx context.Context)
+	Get(ctx context.Context, id string) (*models.User, error)
+	Update(ctx context.Context, user *models.User) error
+	List(ctx context.Context, req pagination.Request) ([]models.User, int64, error)
 	Create(ctx context.Context, data interface{})
 }
@@ -0,0 +1,20 @@
+package user
+
+import (
+	"context"
+	"service/app/controllers/restapi/user"
+	"service/pkg/eventstream"
+	"service/pkg/otel"
+)
+
+// Events subscribes to the user events selected by request.
+func (u *UserUsecase) Events(ctx context.Context, request *user.EventsRequest) (*eventstream.Subscription, error) {
+	_, span := otel.AddSpan(ctx, "user_usecase.events")
+	defer span.End()
+
+	filter := eventstream.Filter{
+		Types:    request.Types,
+		Subjects: request.UserIDs,
+	}
+	return u.events.Subscribe(filter, request.LastEventID)
+}
@@ -0,0 +1,14 @@
+package user
+
+import (
+	"context"
+	"service/app/models"
+	"service/pkg/otel"
+)
+
+func (u *UserUsecase) Get(ctx context.Context, id string) (*models.User, error) {
+	ctx, span := otel.AddSpan(ctx, "user_usecase.get")
+	defer span.End()
+
+	return u.userRepo.Get(ctx, id)
+}
@@ -2,11 +2,38 @@ package user
 
 import (
 	"context"
+	"service/app/models"
 	"service/pkg/otel"
+	"service/pkg/pagination"
 )
 
-func (u *UserUsecase) List(ctx context.Context) map[string]interface{} {
+func (u *UserUsecase) List(ctx context.Context, req pagination.Request) (*pagination.Page[models.User], error) {
 	ctx, span := otel.AddSpan(ctx, "user_usecase.list")
 	defer span.End()
-	return map[string]interface{}{"test": "test"}
+
+	users, total, err := u.userRepo.List(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return pagination.NewPage(req, users, total, sortValues(req.Sort)), nil
+}
+
+// sortValues returns the cursor values of a user for sort.
+func sortValues(sort []pagination.Sort) func(models.User) []any {
+	return func(user models.User) []any {
+		values := make([]any, len(sort))
+		for i, s := range sort {
+			switch s.Field {
+			case "id":
+				values[i] = user.ID
+			case "name":
+				values[i] = user.Name
+			case "email":
+				values[i] = user.Email
+			case "created_at":
+				values[i] = user.CreatedAt
+			}
+		}
+		return values
+	}
 }
@@ -15,6 +15,10 @@ func (u *UserUsecase) Register(ctx context.Context, request *user.RegistrationRe
 
 		return nil
 	})
+	if err != nil {
+		return nil, err
+	}
 
-	return map[string]interface{}{"test": "test"}, err
+	u.invalidateResponses(ctx)
+	return map[string]interface{}{"test": "test"}, nil
 }
@@ -0,0 +1,65 @@
+package user
+
+import (
+	"context"
+	"service/app/controllers/restapi/user"
+	"service/app/models"
+	"service/pkg/logger"
+	"service/pkg/otel"
+)
+
+// Updated handles a user changed by another service and tells the clients
+// streaming user events. A failed publish is returned so the message is
+// consumed again.
+func (u *UserUsecase) Updated(ctx context.Context, userID string) error {
+	ctx, span := otel.AddSpan(ctx, "user_usecase.updated")
+	defer span.End()
+
+	u.invalidateResponses(ctx)
+	return u.events.Publish(ctx, user.EventUpdated, userID, user.Event{ID: userID})
+}
+
+// Update changes the fields set in request. Clients streaming user events
+// are told about the change like about those of other services.
+func (u *UserUsecase) Update(ctx context.Context, request *user.UpdateRequest) (*models.User, error) {
+	ctx, span := otel.AddSpan(ctx, "user_usecase.update")
+	defer span.End()
+
+	var updated *models.User
+	err := u.transactor.WithTx(ctx, func(ctx context.Context) error {
+		current, err := u.userRepo.Get(ctx, request.ID)
+		if err != nil {
+			return err
+		}
+		if request.Name != nil {
+			current.Name = *request.Name
+		}
+		if request.Email != nil {
+			current.Email = *request.Email
+		}
+		if request.Phone != nil {
+			current.Phone = *request.Phone
+		}
+		updated = current
+		return u.userRepo.Update(ctx, current)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	u.invalidateResponses(ctx)
+	// the change is committed, a failed notification must not report it
+	// as failed
+	if err := u.events.Publish(ctx, user.EventUpdated, updated.ID, user.Event{ID: updated.ID}); err != nil {
+		logger.Logger.Warn("user event publish failed", logger.F("error", err.Error()))
+	}
+	return updated, nil
+}
+
+// invalidateResponses drops the cached user responses. A failure is only
+// logged: they still expire after their TTL.
+func (u *UserUsecase) invalidateResponses(ctx context.Context) {
+	if err := u.responses.Invalidate(ctx, user.CacheResource); err != nil {
+		logger.Logger.Warn("response cache invalidation failed", logger.F("error", err.Error()))
+	}
+}
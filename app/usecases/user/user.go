@@ -5,11 +5,15 @@ import "service/pkg/datastore/orm"
 type UserUsecase struct {
 	transactor orm.ITransactor
 	userRepo   IUserRepo
+	responses  IResponseCache
+	events     IEventStream
 }
 
-func NewUserUsecase(transaaction orm.ITransactor, userRepo IUserRepo) *UserUsecase {
+func NewUserUsecase(transaaction orm.ITransactor, userRepo IUserRepo, responses IResponseCache, events IEventStream) *UserUsecase {
 	return &UserUsecase{
 		transactor: transaaction,
 		userRepo:   userRepo,
+		responses:  responses,
+		events:     events,
 	}
 }
@@ -0,0 +1,21 @@
+package user
+
+import (
+	"context"
+	"service/pkg/validation"
+)
+
+type IEmailChecker interface {
+	ExistsByEmail(ctx context.Context, email string) (bool, error)
+}
+
+// RegisterValidation adds the rules that need the user repository.
+func RegisterValidation(users IEmailChecker) error {
+	return validation.RegisterUnique("unique_email", func(ctx context.Context, email string) (bool, error) {
+		exists, err := users.ExistsByEmail(ctx, email)
+		return !exists, err
+	}, map[string]string{
+		validation.LangEnglish:    "{0} is already registered",
+		validation.LangIndonesian: "{0} sudah terdaftar",
+	})
+}
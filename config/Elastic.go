@@ -1,5 +1,7 @@
 package config
 
 type Elastic struct {
-	Host string
+	Enabled bool   `json:"enabled"`
+	Host    string `json:"host"`
+	Retry   Retry  `json:"retry"`
 }
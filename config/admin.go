@@ -0,0 +1,10 @@
+package config
+
+// Admin is the internal listener serving pprof, build info, the effective
+// config and route tables. Keep it off public networks.
+type Admin struct {
+	Enabled bool `json:"enabled"`
+	// Host is the bind address, loopback by default.
+	Host string `json:"host"`
+	Port int    `json:"port"`
+}
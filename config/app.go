@@ -1,6 +1,25 @@
 package config
 
+import "time"
+
+type Environment string
+
+const (
+	EnvDevelopment Environment = "development"
+	EnvStaging     Environment = "staging"
+	EnvProduction  Environment = "production"
+)
+
 type App struct {
-	Name        string `json:"name"`
-	Environment string `json:"enviroment"`
+	Name        string      `json:"name"`
+	Environment Environment `json:"enviroment"` // development,staging,production
+	// ReloadInterval is how often the config file is polled for changes,
+	// 0 disables polling (SIGHUP still triggers a reload).
+	ReloadInterval time.Duration `json:"reload_interval"`
+	// SecretRefreshInterval is how often secret references are resolved
+	// again, 0 resolves them only at load time.
+	SecretRefreshInterval time.Duration `json:"secret_refresh_interval"`
+	// ShutdownTimeout bounds the whole shutdown sequence: draining traffic
+	// and closing datastores and exporters.
+	ShutdownTimeout time.Duration `json:"shutdown_timeout"`
 }
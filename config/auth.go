@@ -0,0 +1,29 @@
+package config
+
+import "time"
+
+type Auth struct {
+	// Algorithms lists the accepted signing algorithms: HS256,RS256,ES256.
+	Algorithms []string `json:"algorithms"`
+	// Key is a static verification key: the shared secret for HS256 or a
+	// PEM public key for RS256/ES256, usually file:///run/secrets/jwt.pem.
+	// A referenced key is re-read every app.secret_refresh_interval, so it
+	// can be rotated without a restart.
+	Key Secret `json:"key"`
+	// JWKS is a path or http(s) URL of a JWKS document. Tokens with a kid
+	// header are verified against it; it is refetched every JWKSRefresh and
+	// when an unknown kid shows up, so keys can be rotated.
+	JWKS        string        `json:"jwks"`
+	JWKSRefresh time.Duration `json:"jwks_refresh"`
+	Issuer      string        `json:"issuer"`
+	Audience    string        `json:"audience"`
+	// Leeway tolerates clock skew when checking exp and nbf.
+	Leeway time.Duration `json:"leeway"`
+	// PermissionCacheTTL bounds how long a user's permissions are cached.
+	// Grant changes invalidate the cache right away.
+	PermissionCacheTTL time.Duration `json:"permission_cache_ttl"`
+	// Admins lists the subjects assigned the admin role at startup. The role
+	// holds permission:manage, so a fresh deployment has someone who can
+	// grant access; remove a subject here and unassign it to revoke that.
+	Admins []string `json:"admins"`
+}
@@ -1,5 +1,11 @@
 package config
 
+type CacheDriver string
+
+const (
+	CacheDriverRedis CacheDriver = "redis"
+)
+
 type Cache struct {
-	Driver string
+	Driver CacheDriver `json:"driver"` // redis
 }
@@ -1,62 +1,165 @@
 package config
 
+import "time"
+
 type Config struct {
-	App      App      `json:"app"`
-	Database Database `json:"database"`
-	Otel     Otel     `json:"otel"`
-	Rest     Rest     `json:"router"`
-	Grpc     Grpc     `json:"grpc"`
-	Kafka    Kafka    `json:"kafka"`
-	Setting  Setting  `json:"setting"`
-	Cache    Cache    `json:"cache"`
-	Redis    Redis    `json:"redis"`
-	Elastic  Elastic  `json:"elastic"`
-	Mongodb  Mongodb  `json:"mongodb"`
+	App       App       `json:"app"`
+	Log       Log       `json:"log"`
+	Database  Database  `json:"database"`
+	Otel      Otel      `json:"otel"`
+	Rest      Rest      `json:"router"`
+	Grpc      Grpc      `json:"grpc"`
+	Kafka     Kafka     `json:"kafka"`
+	Setting   Setting   `json:"setting"`
+	Cache     Cache     `json:"cache"`
+	Redis     Redis     `json:"redis"`
+	Elastic   Elastic   `json:"elastic"`
+	Mongodb   Mongodb   `json:"mongodb"`
+	Health    Health    `json:"health"`
+	Admin     Admin     `json:"admin"`
+	Auth      Auth      `json:"auth"`
+	RateLimit RateLimit `json:"ratelimit"`
+	Stream    Stream    `json:"stream"`
 }
 
+// NewConfig returns the built-in defaults. Use NewLoader to layer a config
+// file, environment variables and flags on top of them.
 func NewConfig() Config {
 	return Config{
 		App: App{
-			Name:        "service",
-			Environment: "development",
+			Name:                  "service",
+			Environment:           EnvDevelopment,
+			ReloadInterval:        30 * time.Second,
+			SecretRefreshInterval: 5 * time.Minute,
+			ShutdownTimeout:       30 * time.Second,
+		},
+		Log: Log{
+			Level: "info",
 		},
 		Database: Database{
-			Driver:   "mariadb",
+			Driver:   DriverMariadb,
 			Host:     "localhost",
-			Port:     "3306",
+			Port:     3306,
 			User:     "root",
-			Password: "root",
+			Password: NewSecret("root"),
 			Name:     "test",
+			Retry:    defaultRetry(),
+			Migrate:  true,
 		},
 		Otel: Otel{
+			Enabled:     true,
 			ServiceName: "user",
 			HostTempo:   "localhost:4317",
+			Probability: 0.05,
 		},
 		Rest: Rest{
-			Port:   "9000",
-			Prefix: "program",
+			Enabled:     true,
+			Port:        9000,
+			Prefix:      "program",
+			Recovery:    true,
+			RequestID:   true,
+			AccessLog:   true,
+			Timeout:     30 * time.Second,
+			MaxBodySize: 4 << 20,
+			Idempotency: Idempotency{
+				TTL:     24 * time.Hour,
+				LockTTL: time.Minute,
+			},
+			OpenAPI: OpenAPI{
+				Enabled:  true,
+				Path:     "/docs",
+				Validate: true,
+			},
+			ResponseCache: ResponseCache{Enabled: true},
+			Compression: Compression{
+				Enabled: true,
+				MinSize: 1024,
+			},
+		},
+		Grpc: Grpc{
+			Enabled:   true,
+			Port:      9090,
+			Recovery:  true,
+			AccessLog: true,
+			Validate:  true,
+			Auth: GrpcAuth{
+				// needs auth.key, auth.jwks or grpc.tls.client_ca_file
+				Enabled: false,
+				// load balancers probe without credentials
+				Public: []string{
+					"/grpc.health.v1.Health/Check",
+					"/grpc.health.v1.Health/Watch",
+				},
+			},
 		},
 		Kafka: Kafka{
-			Host: "localhost:9092",
+			Enabled: true,
+			Host:    "localhost:9092",
+			Retry:   defaultRetry(),
 		},
 		Setting: Setting{
 			QueueProgram: "1",
+			Features:     map[string]bool{},
 		},
 		Cache: Cache{
-			Driver: "redis",
+			Driver: CacheDriverRedis,
 		},
 		Redis: Redis{
-			Name: "0",
-			Host: "127.0.0.1:6379",
+			DB:    0,
+			Host:  "127.0.0.1:6379",
+			Retry: defaultRetry(),
 		},
 		Elastic: Elastic{
-			Host: "localhost:9200",
+			Enabled: true,
+			Host:    "localhost:9200",
+			Retry:   defaultRetry(),
 		},
 		Mongodb: Mongodb{
-			Url:         "localhost:27017",
-			MaxPoolConn: "50",
-			MaxIdleConn: "10",
-			Compression: "snappy",
+			Url:             "mongodb://localhost:27017",
+			MaxPoolConn:     50,
+			MaxConnIdleTime: 10 * time.Hour,
+			Compression:     []string{"snappy"},
+			Retry:           defaultRetry(),
+		},
+		Auth: Auth{
+			Algorithms:         []string{"RS256"},
+			JWKSRefresh:        5 * time.Minute,
+			Leeway:             30 * time.Second,
+			PermissionCacheTTL: 5 * time.Minute,
+		},
+		RateLimit: RateLimit{
+			Enabled: true,
+			Policies: map[string]RateLimitPolicy{
+				"default": {
+					Algorithm: RateLimitSlidingWindow,
+					Limit:     100,
+					Window:    time.Minute,
+					Key:       RateLimitByIP,
+				},
+				"register": {
+					Algorithm: RateLimitTokenBucket,
+					Limit:     5,
+					Window:    time.Minute,
+					Key:       RateLimitByIP,
+				},
+			},
+		},
+		Stream: Stream{
+			Enabled:      true,
+			Channel:      "service:events:user",
+			History:      1000,
+			Buffer:       64,
+			Heartbeat:    15 * time.Second,
+			WriteTimeout: 10 * time.Second,
+		},
+		Admin: Admin{
+			Host: "127.0.0.1",
+			Port: 9100,
+		},
+		Health: Health{
+			Timeout:      2 * time.Second,
+			Critical:     []string{"database", "cache", "kafka"},
+			GrpcInterval: 10 * time.Second,
 		},
 	}
 }
@@ -1,13 +1,27 @@
 package config
 
+import "time"
+
+type DatabaseDriver string
+
+const (
+	DriverMariadb  DatabaseDriver = "mariadb"
+	DriverMysql    DatabaseDriver = "mysql"
+	DriverPostgres DatabaseDriver = "postgres"
+)
+
 type Database struct {
-	Driver          string `json:"driver"` // mariadb,postgres
-	Host            string `json:"host"`
-	Port            string `json:"port"`
-	User            string `json:"user"`
-	Password        string `json:"password"`
-	Name            string `json:"database"`
-	MaxIdleConn     string `json:"max_idle"`
-	MaxOpenConn     string `json:"max_open"`
-	MaxConnLifetime string `json:"max_conn_lifetime"`
+	Driver          DatabaseDriver `json:"driver"` // mariadb,mysql,postgres
+	Host            string         `json:"host"`
+	Port            int            `json:"port"`
+	User            string         `json:"user"`
+	Password        Secret         `json:"password"` // literal or file://, env:// reference
+	Name            string         `json:"database"`
+	MaxIdleConn     int            `json:"max_idle"`
+	MaxOpenConn     int            `json:"max_open"`
+	MaxConnLifetime time.Duration  `json:"max_conn_lifetime"` // 0 means no limit
+	Retry           Retry          `json:"retry"`
+	// Migrate creates or updates the role and permission tables at startup.
+	// Turn it off when the schema is managed elsewhere.
+	Migrate bool `json:"migrate"`
 }
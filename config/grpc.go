@@ -1,5 +1,43 @@
 package config
 
 type Grpc struct {
-	Port string
+	Enabled bool `json:"enabled"`
+	Port    int  `json:"port"`
+	// Recovery answers codes.Internal to a panicking handler and logs the
+	// stack.
+	Recovery  bool `json:"recovery"`
+	AccessLog bool `json:"access_log"`
+	// Validate rejects requests whose Validate method fails, like messages
+	// generated by protoc-gen-validate, before the handler runs.
+	Validate bool     `json:"validate"`
+	Auth     GrpcAuth `json:"auth"`
+	TLS      GrpcTLS  `json:"tls"`
+}
+
+type GrpcAuth struct {
+	// Enabled requires a bearer token, verified with the auth settings, or
+	// a client certificate when tls.client_ca_file is set.
+	Enabled bool `json:"enabled"`
+	// Public lists the full method names callable without credentials,
+	// e.g. "/grpc.health.v1.Health/Check".
+	Public []string `json:"public"`
+}
+
+// IsPublic reports whether method may be called without credentials.
+func (a GrpcAuth) IsPublic(method string) bool {
+	for _, m := range a.Public {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+type GrpcTLS struct {
+	// CertFile and KeyFile serve TLS; plaintext when unset.
+	CertFile string `json:"cert_file"`
+	KeyFile  string `json:"key_file"`
+	// ClientCAFile verifies client certificates. A verified certificate
+	// authenticates its common name when auth is enabled.
+	ClientCAFile string `json:"client_ca_file"`
 }
@@ -0,0 +1,26 @@
+package config
+
+import "time"
+
+type Health struct {
+	// Timeout bounds every single dependency check.
+	Timeout time.Duration `json:"timeout"`
+	// Critical lists the dependencies that fail readiness when down, the
+	// others only report the service as degraded. Non-critical dependencies
+	// that are still down after their startup retries start degraded and
+	// reconnect in the background. The database is always critical.
+	// database,cache,elastic,mongodb,kafka
+	Critical []string `json:"critical"`
+	// GrpcInterval is how often the gRPC health service status is refreshed.
+	GrpcInterval time.Duration `json:"grpc_interval"`
+}
+
+// IsCritical reports whether the named dependency is critical.
+func (h Health) IsCritical(name string) bool {
+	for _, c := range h.Critical {
+		if c == name {
+			return true
+		}
+	}
+	return false
+}
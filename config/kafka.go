@@ -1,5 +1,12 @@
 package config
 
 type Kafka struct {
-	Host string
+	Enabled bool   `json:"enabled"`
+	Host    string `json:"host"`
+	// Retry applies to connecting the publisher and subscriber. Kafka never
+	// starts degraded: the consumer cannot run without its brokers.
+	Retry Retry `json:"retry"`
+	// PoisonTopic receives messages whose handler failed with a
+	// non-retryable error. When empty they are logged and acked.
+	PoisonTopic string `json:"poison_topic"`
 }
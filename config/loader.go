@@ -0,0 +1,472 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EnvPrefix is prepended to every environment variable read by the loader,
+// e.g. SERVICE_DATABASE_HOST overrides database.host.
+const EnvPrefix = "SERVICE"
+
+// Source identifies which layer supplied a configuration value.
+type Source string
+
+const (
+	SourceDefault Source = "default"
+	SourceFile    Source = "file"
+	SourceEnv     Source = "env"
+	SourceFlag    Source = "flag"
+)
+
+// Sources maps a dotted config key (database.host) to the layer that set it.
+type Sources map[string]Source
+
+// Get returns the source of key, falling back to SourceDefault.
+func (s Sources) Get(key string) Source {
+	if src, ok := s[key]; ok {
+		return src
+	}
+	return SourceDefault
+}
+
+// Keys returns every known key in sorted order.
+func (s Sources) Keys() []string {
+	keys := make([]string, 0, len(s))
+	for k := range s {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Loader builds a Config by layering, from lowest to highest precedence:
+// built-in defaults, a YAML/JSON file, environment variables and
+// command-line flags.
+type Loader struct {
+	file      string
+	resolved  string
+	envPrefix string
+	args      []string
+	lookupEnv func(string) (string, bool)
+	secrets   map[string]SecretProvider
+}
+
+type LoaderOption func(*Loader)
+
+// WithFile sets the config file path. It can still be overridden by the
+// -config flag or the SERVICE_CONFIG_FILE environment variable.
+func WithFile(path string) LoaderOption {
+	return func(l *Loader) {
+		l.file = path
+	}
+}
+
+// WithEnvPrefix replaces the default SERVICE prefix.
+func WithEnvPrefix(prefix string) LoaderOption {
+	return func(l *Loader) {
+		l.envPrefix = prefix
+	}
+}
+
+// WithArgs sets the command-line arguments parsed as overrides.
+func WithArgs(args []string) LoaderOption {
+	return func(l *Loader) {
+		l.args = args
+	}
+}
+
+// WithSecretProvider registers p for references using scheme, e.g.
+// WithSecretProvider("vault", vaultProvider) resolves vault://... values.
+func WithSecretProvider(scheme string, p SecretProvider) LoaderOption {
+	return func(l *Loader) {
+		l.secrets[scheme] = p
+	}
+}
+
+func NewLoader(opts ...LoaderOption) *Loader {
+	l := &Loader{
+		envPrefix: EnvPrefix,
+		lookupEnv: os.LookupEnv,
+		secrets:   map[string]SecretProvider{},
+	}
+	l.secrets["file"] = SecretProviderFunc(fileSecretProvider)
+	l.secrets["env"] = envSecretProvider(l.lookupEnv)
+	for _, opt := range opts {
+		opt(l)
+	}
+	return l
+}
+
+// Load merges every layer on top of NewConfig, resolves secret references,
+// validates the result and reports where each value came from. Parse,
+// secret and validation errors are returned together.
+func (l *Loader) Load() (Config, Sources, error) {
+	cfg := NewConfig()
+	fields := configFields(&cfg)
+	sources := make(Sources, len(fields))
+	for key := range fields {
+		sources[key] = SourceDefault
+	}
+
+	overrides, file, err := l.parseFlags(fields)
+	if err != nil {
+		return cfg, sources, err
+	}
+
+	if file == "" {
+		file, _ = l.lookupEnv(l.envName("config_file"))
+	}
+	if file == "" {
+		file = l.file
+	}
+	l.resolved = file
+
+	if file != "" {
+		if err := applyFile(file, fields, sources); err != nil {
+			return cfg, sources, err
+		}
+	}
+
+	var errs []error
+	for key, fv := range fields {
+		val, ok := l.lookupEnv(l.envName(key))
+		if !ok {
+			continue
+		}
+		if err := setField(fv, val); err != nil {
+			errs = append(errs, fmt.Errorf("env %s: %w", l.envName(key), err))
+			continue
+		}
+		sources[key] = SourceEnv
+	}
+
+	for key, val := range overrides {
+		if err := setField(fields[key], val); err != nil {
+			errs = append(errs, fmt.Errorf("flag -%s: %w", key, err))
+			continue
+		}
+		sources[key] = SourceFlag
+	}
+
+	if err := l.resolveSecrets(context.Background(), &cfg); err != nil {
+		errs = append(errs, err)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+
+	return cfg, sources, errors.Join(errs...)
+}
+
+// File returns the config file used by the last Load, if any.
+func (l *Loader) File() string {
+	return l.resolved
+}
+
+func (l *Loader) envName(key string) string {
+	name := strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+	if l.envPrefix == "" {
+		return name
+	}
+	return l.envPrefix + "_" + name
+}
+
+// parseFlags accepts -config and one flag per key, e.g. -database.host=db.
+// Only flags that were actually passed are returned as overrides.
+func (l *Loader) parseFlags(fields map[string]reflect.Value) (map[string]string, string, error) {
+	overrides := map[string]string{}
+	if len(l.args) == 0 {
+		return overrides, "", nil
+	}
+
+	fs := flag.NewFlagSet("config", flag.ContinueOnError)
+	file := fs.String("config", "", "path to a YAML or JSON config file")
+	for key, fv := range fields {
+		fs.String(key, fmt.Sprint(fv.Interface()), "overrides "+key)
+	}
+
+	if err := fs.Parse(l.args); err != nil {
+		return nil, "", fmt.Errorf("parse flags: %w", err)
+	}
+
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name != "config" {
+			overrides[f.Name] = f.Value.String()
+		}
+	})
+
+	return overrides, *file, nil
+}
+
+func applyFile(path string, fields map[string]reflect.Value, sources Sources) error {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read config file: %w", err)
+	}
+
+	// JSON is a subset of YAML, so a single decoder covers both formats.
+	doc := map[string]interface{}{}
+	if err := yaml.Unmarshal(raw, &doc); err != nil {
+		return fmt.Errorf("parse config file %s: %w", path, err)
+	}
+
+	var errs []error
+	for key, fv := range fields {
+		val, ok := lookupPath(doc, key)
+		if !ok {
+			continue
+		}
+		if err := setFieldFromFile(fv, val); err != nil {
+			errs = append(errs, fmt.Errorf("file %s: %w", key, err))
+			continue
+		}
+		sources[key] = SourceFile
+	}
+
+	return errors.Join(errs...)
+}
+
+func lookupPath(doc map[string]interface{}, key string) (interface{}, bool) {
+	parts := strings.Split(key, ".")
+	var cur interface{} = doc
+	for _, part := range parts {
+		m, ok := cur.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if cur, ok = m[part]; !ok {
+			return nil, false
+		}
+	}
+	return cur, true
+}
+
+// configFields flattens cfg into addressable leaf fields keyed by their
+// dotted json tag path.
+func configFields(cfg *Config) map[string]reflect.Value {
+	fields := map[string]reflect.Value{}
+	collectFields(reflect.ValueOf(cfg).Elem(), "", fields)
+	return fields
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+func collectFields(v reflect.Value, prefix string, fields map[string]reflect.Value) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+
+		name := jsonName(sf)
+		if name == "-" {
+			continue
+		}
+		if prefix != "" {
+			name = prefix + "." + name
+		}
+
+		fv := v.Field(i)
+		if fv.Kind() == reflect.Struct && fv.Type() != secretType {
+			collectFields(fv, name, fields)
+			continue
+		}
+		fields[name] = fv
+	}
+}
+
+// jsonName returns the key a struct field is addressed by in files, env
+// variables and flags.
+func jsonName(sf reflect.StructField) string {
+	name := strings.Split(sf.Tag.Get("json"), ",")[0]
+	if name == "" {
+		name = strings.ToLower(sf.Name)
+	}
+	return name
+}
+
+// setFieldFromFile assigns a decoded YAML value. Scalars, lists and maps of
+// scalars, and maps of structs go through the same string parsing as env and
+// flags so values like durations read the same everywhere; other lists and
+// maps are round-tripped through JSON so the json tags stay authoritative.
+func setFieldFromFile(fv reflect.Value, val interface{}) error {
+	if items, ok := val.([]interface{}); ok && fv.Kind() == reflect.Slice && isScalar(fv.Type().Elem()) {
+		strs := make([]string, len(items))
+		for i := range items {
+			strs[i] = fmt.Sprint(items[i])
+		}
+		return setField(fv, strings.Join(strs, ","))
+	}
+
+	if items, ok := val.(map[string]interface{}); ok && fv.Kind() == reflect.Map && isScalar(fv.Type().Elem()) {
+		pairs := make([]string, 0, len(items))
+		for k, item := range items {
+			pairs = append(pairs, k+"="+fmt.Sprint(item))
+		}
+		return setField(fv, strings.Join(pairs, ","))
+	}
+
+	if items, ok := val.(map[string]interface{}); ok && fv.Kind() == reflect.Map && fv.Type().Elem().Kind() == reflect.Struct {
+		out := reflect.MakeMapWithSize(fv.Type(), len(items))
+		for k, item := range items {
+			doc, ok := item.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("%s: expected a mapping", k)
+			}
+			elem := reflect.New(fv.Type().Elem()).Elem()
+			if err := setStructFromFile(elem, doc); err != nil {
+				return fmt.Errorf("%s: %w", k, err)
+			}
+			out.SetMapIndex(reflect.ValueOf(k).Convert(fv.Type().Key()), elem)
+		}
+		fv.Set(out)
+		return nil
+	}
+
+	switch val.(type) {
+	case map[string]interface{}, []interface{}:
+		raw, err := json.Marshal(val)
+		if err != nil {
+			return err
+		}
+		ptr := reflect.New(fv.Type())
+		if err := json.Unmarshal(raw, ptr.Interface()); err != nil {
+			return err
+		}
+		fv.Set(ptr.Elem())
+		return nil
+	case nil:
+		fv.Set(reflect.Zero(fv.Type()))
+		return nil
+	default:
+		return setField(fv, fmt.Sprint(val))
+	}
+}
+
+// setStructFromFile fills the fields of v found in doc, by json name.
+func setStructFromFile(v reflect.Value, doc map[string]interface{}) error {
+	var errs []error
+	for i := 0; i < v.NumField(); i++ {
+		sf := v.Type().Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		val, ok := doc[jsonName(sf)]
+		if !ok {
+			continue
+		}
+		if err := setFieldFromFile(v.Field(i), val); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", jsonName(sf), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func isScalar(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// setField parses raw into fv according to its kind. Slices of scalars are
+// comma separated, maps use key=value pairs: "a=true,b=false".
+func setField(fv reflect.Value, raw string) error {
+	if fv.Type() == secretType {
+		fv.Set(reflect.ValueOf(NewSecret(raw)))
+		return nil
+	}
+
+	if fv.Type() == durationType {
+		d, err := time.ParseDuration(strings.TrimSpace(raw))
+		if err != nil {
+			return err
+		}
+		fv.SetInt(int64(d))
+		return nil
+	}
+
+	switch fv.Kind() {
+	case reflect.String:
+		fv.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(strings.TrimSpace(raw))
+		if err != nil {
+			return err
+		}
+		fv.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(strings.TrimSpace(raw), 10, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(strings.TrimSpace(raw), 10, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(strings.TrimSpace(raw), fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetFloat(f)
+	case reflect.Slice:
+		if !isScalar(fv.Type().Elem()) {
+			return fmt.Errorf("unsupported list type %s", fv.Type())
+		}
+		parts := []string{}
+		if strings.TrimSpace(raw) != "" {
+			parts = strings.Split(raw, ",")
+		}
+		out := reflect.MakeSlice(fv.Type(), len(parts), len(parts))
+		for i, part := range parts {
+			if err := setField(out.Index(i), strings.TrimSpace(part)); err != nil {
+				return err
+			}
+		}
+		fv.Set(out)
+	case reflect.Map:
+		if fv.Type().Key().Kind() != reflect.String || !isScalar(fv.Type().Elem()) {
+			return fmt.Errorf("unsupported map type %s", fv.Type())
+		}
+		out := reflect.MakeMap(fv.Type())
+		for _, pair := range strings.Split(raw, ",") {
+			if strings.TrimSpace(pair) == "" {
+				continue
+			}
+			k, val, ok := strings.Cut(pair, "=")
+			if !ok {
+				return fmt.Errorf("expected key=value, got %q", pair)
+			}
+			elem := reflect.New(fv.Type().Elem()).Elem()
+			if err := setField(elem, strings.TrimSpace(val)); err != nil {
+				return err
+			}
+			out.SetMapIndex(reflect.ValueOf(strings.TrimSpace(k)).Convert(fv.Type().Key()), elem)
+		}
+		fv.Set(out)
+	default:
+		return fmt.Errorf("unsupported type %s", fv.Type())
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	yamlFile := writeFile(t, "config.yaml", "database:\n  host: file-host\n  port: 3307\n")
+	jsonFile := writeFile(t, "config.json", `{"database": {"host": "json-host"}}`)
+
+	for _, tc := range []struct {
+		name       string
+		file       string
+		env        map[string]string
+		args       []string
+		wantHost   string
+		wantSource Source
+		// layers only override the keys they set
+		wantPort int
+	}{
+		{name: "default", wantHost: "localhost", wantSource: SourceDefault, wantPort: 3306},
+		{name: "yaml file", file: yamlFile, wantHost: "file-host", wantSource: SourceFile, wantPort: 3307},
+		{name: "json file", file: jsonFile, wantHost: "json-host", wantSource: SourceFile, wantPort: 3306},
+		{
+			name:       "env over file",
+			file:       yamlFile,
+			env:        map[string]string{"SERVICE_DATABASE_HOST": "env-host"},
+			wantHost:   "env-host",
+			wantSource: SourceEnv,
+			wantPort:   3307,
+		},
+		{
+			name:       "flag over env",
+			file:       yamlFile,
+			env:        map[string]string{"SERVICE_DATABASE_HOST": "env-host"},
+			args:       []string{"-database.host=flag-host"},
+			wantHost:   "flag-host",
+			wantSource: SourceFlag,
+			wantPort:   3307,
+		},
+		{
+			name:       "file from env",
+			env:        map[string]string{"SERVICE_CONFIG_FILE": jsonFile},
+			wantHost:   "json-host",
+			wantSource: SourceFile,
+			wantPort:   3306,
+		},
+		{
+			name:       "file from flag",
+			file:       yamlFile,
+			env:        map[string]string{"SERVICE_CONFIG_FILE": yamlFile},
+			args:       []string{"-config", jsonFile},
+			wantHost:   "json-host",
+			wantSource: SourceFile,
+			wantPort:   3306,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			for k, v := range tc.env {
+				t.Setenv(k, v)
+			}
+			cfg, sources, err := NewLoader(WithFile(tc.file), WithArgs(tc.args)).Load()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cfg.Database.Host != tc.wantHost {
+				t.Errorf("database.host = %q, want %q", cfg.Database.Host, tc.wantHost)
+			}
+			if got := sources.Get("database.host"); got != tc.wantSource {
+				t.Errorf("database.host source = %s, want %s", got, tc.wantSource)
+			}
+			if cfg.Database.Port != tc.wantPort {
+				t.Errorf("database.port = %d, want %d", cfg.Database.Port, tc.wantPort)
+			}
+		})
+	}
+}
+
+func TestLoadValueTypes(t *testing.T) {
+	file := writeFile(t, "config.yaml", `
+health:
+  critical: [database, kafka]
+ratelimit:
+  policies:
+    login:
+      algorithm: token_bucket
+      key: ip
+      limit: 5
+      window: 1m
+`)
+	t.Setenv("SERVICE_APP_SHUTDOWN_TIMEOUT", "45s")
+	t.Setenv("SERVICE_GRPC_ENABLED", "false")
+	t.Setenv("SERVICE_ROUTER_ROUTE_TIMEOUTS", "POST /program/user/register=5s")
+
+	cfg, _, err := NewLoader(WithFile(file), WithArgs([]string{"-router.max_body_size=2048"})).Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.App.ShutdownTimeout != 45*time.Second {
+		t.Errorf("app.shutdown_timeout = %s", cfg.App.ShutdownTimeout)
+	}
+	if cfg.Grpc.Enabled {
+		t.Error("grpc.enabled not overridden")
+	}
+	if got := cfg.Rest.RouteTimeouts["POST /program/user/register"]; got != 5*time.Second {
+		t.Errorf("router.route_timeouts = %v", cfg.Rest.RouteTimeouts)
+	}
+	if cfg.Rest.MaxBodySize != 2048 {
+		t.Errorf("router.max_body_size = %d", cfg.Rest.MaxBodySize)
+	}
+	if got := cfg.Health.Critical; len(got) != 2 || got[0] != "database" || got[1] != "kafka" {
+		t.Errorf("health.critical = %v", got)
+	}
+	if p := cfg.RateLimit.Policies["login"]; p.Limit != 5 || p.Window != time.Minute {
+		t.Errorf("ratelimit.policies.login = %+v", p)
+	}
+}
+
+func TestLoadReportsEveryParseError(t *testing.T) {
+	t.Setenv("SERVICE_DATABASE_PORT", "not-a-port")
+	t.Setenv("SERVICE_APP_SHUTDOWN_TIMEOUT", "soon")
+
+	_, _, err := NewLoader(WithArgs([]string{"-grpc.enabled=maybe"})).Load()
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	for _, want := range []string{"SERVICE_DATABASE_PORT", "SERVICE_APP_SHUTDOWN_TIMEOUT", "-grpc.enabled"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error does not mention %s: %v", want, err)
+		}
+	}
+}
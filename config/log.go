@@ -0,0 +1,5 @@
+package config
+
+type Log struct {
+	Level string `json:"level"` // debug,info,warn,error,panic,fatal
+}
@@ -1,8 +1,14 @@
 package config
 
+import "time"
+
 type Mongodb struct {
-	Url         string
-	MaxPoolConn string
-	MaxIdleConn string
-	Compression string
+	Enabled         bool          `json:"enabled"`
+	Url             string        `json:"url"`
+	Username        string        `json:"username"`
+	Password        Secret        `json:"password"` // literal or file://, env:// reference
+	MaxPoolConn     uint64        `json:"max_pool_conn"`
+	MaxConnIdleTime time.Duration `json:"max_conn_idle_time"`
+	Compression     []string      `json:"compression"` // snappy,zlib,zstd
+	Retry           Retry         `json:"retry"`
 }
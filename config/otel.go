@@ -1,8 +1,9 @@
 package config
 
 type Otel struct {
-	Host        string
-	ServiceName string
-	Probability string
-	HostTempo   string
+	Enabled     bool    `json:"enabled"`
+	Host        string  `json:"host"`
+	ServiceName string  `json:"service_name"`
+	Probability float64 `json:"probability"` // 0..1
+	HostTempo   string  `json:"host_tempo"`
 }
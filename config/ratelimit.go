@@ -0,0 +1,39 @@
+package config
+
+import "time"
+
+type RateLimitAlgorithm string
+
+const (
+	RateLimitSlidingWindow RateLimitAlgorithm = "sliding_window"
+	RateLimitTokenBucket   RateLimitAlgorithm = "token_bucket"
+)
+
+type RateLimitKey string
+
+const (
+	RateLimitByIP     RateLimitKey = "ip"
+	RateLimitByUser   RateLimitKey = "user"
+	RateLimitByAPIKey RateLimitKey = "api_key"
+	RateLimitByRoute  RateLimitKey = "route"
+)
+
+// RateLimit holds the named policies routes refer to with
+// mid.RateLimit("name"). The section reloads without a restart.
+type RateLimit struct {
+	Enabled bool `json:"enabled"`
+	// Policies can only be set from the config file, where they replace
+	// the defaults as a whole.
+	Policies map[string]RateLimitPolicy `json:"policies"`
+}
+
+type RateLimitPolicy struct {
+	Algorithm RateLimitAlgorithm `json:"algorithm"`
+	// Limit is the number of requests allowed per Window. For a token
+	// bucket it is the burst size, refilled evenly over Window.
+	Limit  int           `json:"limit"`
+	Window time.Duration `json:"window"`
+	// Key groups requests sharing a limit. Anonymous callers of a user
+	// keyed policy, and callers without an API key, are keyed by IP.
+	Key RateLimitKey `json:"key"`
+}
@@ -1,8 +1,9 @@
 package config
 
 type Redis struct {
-	Name     string
-	Host     string
-	Port     string
-	Password string
+	DB       int    `json:"db"`
+	Host     string `json:"host"`
+	Port     int    `json:"port"`     // optional when host already carries the port
+	Password Secret `json:"password"` // literal or file://, env:// reference
+	Retry    Retry  `json:"retry"`
 }
@@ -1,6 +1,91 @@
 package config
 
+import "time"
+
 type Rest struct {
-	Prefix string
-	Port   string
+	Enabled bool   `json:"enabled"`
+	Prefix  string `json:"prefix"`
+	Port    int    `json:"port"`
+	// Recovery answers 500 to a panicking handler and logs the stack.
+	Recovery bool `json:"recovery"`
+	// RequestID reuses the caller's X-Request-ID or generates one.
+	RequestID bool `json:"request_id"`
+	AccessLog bool `json:"access_log"`
+	// Timeout bounds the request context of every route, 0 disables it.
+	Timeout time.Duration `json:"timeout"`
+	// RouteTimeouts overrides Timeout per route, keyed by method and full
+	// path: "POST /program/user/register=5s".
+	RouteTimeouts map[string]time.Duration `json:"route_timeouts"`
+	// MaxBodySize limits request bodies in bytes, 0 disables it.
+	MaxBodySize int64 `json:"max_body_size"`
+	// TrustedProxies lists the IPs or CIDRs allowed to set X-Forwarded-For.
+	// When empty the client IP is always the peer address.
+	TrustedProxies []string `json:"trusted_proxies"`
+	// Idempotency applies to routes using mid.Idempotent.
+	Idempotency Idempotency `json:"idempotency"`
+	// Versions holds the lifecycle of the API versions, keyed by version
+	// name ("v1"). Versions not listed are current.
+	Versions map[string]ApiVersion `json:"versions"`
+	OpenAPI  OpenAPI               `json:"openapi"`
+	// ResponseCache stores the responses of routes using mid.CacheResponse.
+	// When disabled they still get ETags and 304s.
+	ResponseCache ResponseCache `json:"response_cache"`
+	Compression   Compression   `json:"compression"`
+}
+
+type Compression struct {
+	// Enabled compresses responses with zstd or gzip, as Accept-Encoding
+	// allows.
+	Enabled bool `json:"enabled"`
+	// MinSize leaves smaller responses uncompressed, in bytes.
+	MinSize int `json:"min_size"`
+}
+
+type ResponseCache struct {
+	Enabled bool `json:"enabled"`
+}
+
+type OpenAPI struct {
+	// Enabled serves the API document and its UI under Path.
+	Enabled bool   `json:"enabled"`
+	Path    string `json:"path"`
+	// Validate checks requests and responses of the documented version
+	// against the document. It is never applied in production.
+	Validate bool `json:"validate"`
+}
+
+// ApiVersion announces the retirement of an API version. Dates are
+// "2006-01-02" in UTC.
+type ApiVersion struct {
+	// Deprecated sets the Deprecation header on every response of the
+	// version; it may be a future date to announce an upcoming deprecation.
+	Deprecated string `json:"deprecated"`
+	// Sunset sets the Sunset header, the date the version stops being served.
+	Sunset string `json:"sunset"`
+	// Link is sent with rel="deprecation", e.g. the migration guide.
+	Link string `json:"link"`
+}
+
+// DeprecatedAt and SunsetAt parse the dates; zero when unset.
+func (v ApiVersion) DeprecatedAt() (time.Time, error) {
+	return parseDate(v.Deprecated)
+}
+
+func (v ApiVersion) SunsetAt() (time.Time, error) {
+	return parseDate(v.Sunset)
+}
+
+func parseDate(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.DateOnly, s)
+}
+
+type Idempotency struct {
+	// TTL is how long a response is kept for replay.
+	TTL time.Duration `json:"ttl"`
+	// LockTTL must outlast the slowest request, or a duplicate may run
+	// while the first is still in progress.
+	LockTTL time.Duration `json:"lock_ttl"`
 }
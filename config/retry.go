@@ -0,0 +1,27 @@
+package config
+
+import "time"
+
+// Retry controls how a dependency is retried at startup, with the interval
+// growing by Multiplier after each failed attempt up to MaxInterval.
+type Retry struct {
+	InitialInterval time.Duration `json:"initial_interval"`
+	MaxInterval     time.Duration `json:"max_interval"`
+	Multiplier      float64       `json:"multiplier"`
+	// Timeout is the total time spent retrying before startup fails, or
+	// before a non-critical dependency starts degraded. 0 tries once.
+	Timeout time.Duration `json:"timeout"`
+	// AttemptTimeout bounds each attempt so a dependency that hangs instead
+	// of refusing is retried too. 0 leaves attempts bounded by Timeout only.
+	AttemptTimeout time.Duration `json:"attempt_timeout"`
+}
+
+func defaultRetry() Retry {
+	return Retry{
+		InitialInterval: 500 * time.Millisecond,
+		MaxInterval:     10 * time.Second,
+		Multiplier:      2,
+		Timeout:         time.Minute,
+		AttemptTimeout:  5 * time.Second,
+	}
+}
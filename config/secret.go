@@ -0,0 +1,151 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+const redacted = "[REDACTED]"
+
+// SecretProvider resolves a secret reference such as file:///run/secrets/db
+// or vault://kv/service#password. Implementations are registered per URL
+// scheme with WithSecretProvider.
+type SecretProvider interface {
+	Resolve(ctx context.Context, ref string) (string, error)
+}
+
+// SecretProviderFunc adapts a function to SecretProvider.
+type SecretProviderFunc func(ctx context.Context, ref string) (string, error)
+
+func (f SecretProviderFunc) Resolve(ctx context.Context, ref string) (string, error) {
+	return f(ctx, ref)
+}
+
+// Secret is a config value that may hold a credential. It accepts either a
+// literal value or a reference resolved through a SecretProvider: file://,
+// env:// or a scheme registered with WithSecretProvider. Anything else is
+// literal, even when it looks like a URL. Its
+// String and MarshalJSON output is always redacted, so it is safe to log or
+// dump a Config that contains it. Copies share the resolved value, which lets
+// a refresh reach every snapshot.
+type Secret struct {
+	ref string
+	val *secretValue
+}
+
+type secretValue struct {
+	mu sync.RWMutex
+	v  string
+}
+
+func NewSecret(raw string) Secret {
+	s := Secret{ref: raw, val: &secretValue{}}
+	if !s.IsReference() {
+		s.val.v = raw
+	}
+	return s
+}
+
+// Value returns the resolved secret. Never log it.
+func (s Secret) Value() string {
+	if s.val == nil {
+		return ""
+	}
+	s.val.mu.RLock()
+	defer s.val.mu.RUnlock()
+	return s.val.v
+}
+
+// IsReference reports whether the secret was configured as a file:// or
+// env:// reference rather than a literal value. Values of a scheme
+// registered with WithSecretProvider are resolved by the Loader as well.
+func (s Secret) IsReference() bool {
+	return strings.HasPrefix(s.ref, "file://") || strings.HasPrefix(s.ref, "env://")
+}
+
+func (s Secret) String() string {
+	if s.ref == "" {
+		return ""
+	}
+	return redacted
+}
+
+func (s Secret) GoString() string {
+	return s.String()
+}
+
+func (s Secret) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + s.String() + `"`), nil
+}
+
+func (s Secret) equal(other Secret) bool {
+	return s.ref == other.ref && s.Value() == other.Value()
+}
+
+func (s Secret) set(v string) {
+	s.val.mu.Lock()
+	defer s.val.mu.Unlock()
+	s.val.v = v
+}
+
+// secretScheme returns the scheme a value would be resolved with, or "" when
+// it has none. Only schemes with a provider make it a reference.
+func secretScheme(raw string) string {
+	scheme, rest, ok := strings.Cut(raw, "://")
+	if !ok || rest == "" {
+		return ""
+	}
+	return scheme
+}
+
+var secretType = reflect.TypeOf(Secret{})
+
+// fileSecretProvider reads file:///path references, trimming the trailing
+// newline most secret mounts add.
+func fileSecretProvider(_ context.Context, ref string) (string, error) {
+	raw, err := os.ReadFile(strings.TrimPrefix(ref, "file://"))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(raw), "\r\n"), nil
+}
+
+// envSecretProvider reads env://NAME references.
+func envSecretProvider(lookupEnv func(string) (string, bool)) SecretProviderFunc {
+	return func(_ context.Context, ref string) (string, error) {
+		name := strings.TrimPrefix(ref, "env://")
+		v, ok := lookupEnv(name)
+		if !ok {
+			return "", fmt.Errorf("environment variable %s is not set", name)
+		}
+		return v, nil
+	}
+}
+
+// resolveSecrets resolves every Secret reference in cfg in place.
+func (l *Loader) resolveSecrets(ctx context.Context, cfg *Config) error {
+	var errs []error
+	for key, fv := range configFields(cfg) {
+		if fv.Type() != secretType {
+			continue
+		}
+		secret := fv.Interface().(Secret)
+		provider, ok := l.secrets[secretScheme(secret.ref)]
+		if !ok {
+			// a literal, e.g. p@ss://word
+			continue
+		}
+		v, err := provider.Resolve(ctx, secret.ref)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: resolve %s: %w", key, secret.ref, err))
+			continue
+		}
+		secret.set(v)
+	}
+	return errors.Join(errs...)
+}
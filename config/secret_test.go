@@ -0,0 +1,129 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestSecretRedaction(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		raw  string
+		want string
+	}{
+		{"literal", "hunter2", redacted},
+		{"reference", "env://DB_PASS", redacted},
+		{"unset", "", ""},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			s := NewSecret(tc.raw)
+			if got := s.String(); got != tc.want {
+				t.Errorf("String() = %q, want %q", got, tc.want)
+			}
+			for _, format := range []string{"%v", "%s", "%+v", "%#v"} {
+				if got := fmt.Sprintf(format, s); got != tc.want {
+					t.Errorf("Sprintf(%s) = %q, want %q", format, got, tc.want)
+				}
+			}
+			raw, err := json.Marshal(s)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(raw) != `"`+tc.want+`"` {
+				t.Errorf("MarshalJSON() = %s", raw)
+			}
+		})
+	}
+}
+
+func TestConfigDumpHidesSecrets(t *testing.T) {
+	cfg := NewConfig()
+	cfg.Database.Password = NewSecret("hunter2")
+	cfg.Redis.Password = NewSecret("hunter3")
+
+	raw, err := json.Marshal(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, dump := range []string{string(raw), fmt.Sprintf("%+v", cfg)} {
+		if strings.Contains(dump, "hunter") {
+			t.Errorf("dump leaks a secret: %s", dump)
+		}
+	}
+	if cfg.Database.Password.Value() != "hunter2" {
+		t.Errorf("Value() = %q", cfg.Database.Password.Value())
+	}
+}
+
+func TestSecretProviders(t *testing.T) {
+	file := writeFile(t, "db_password", "from-file\n")
+	t.Setenv("SERVICE_DATABASE_PASSWORD", "file://"+file)
+	t.Setenv("SERVICE_REDIS_PASSWORD", "env://REDIS_PASS")
+	t.Setenv("REDIS_PASS", "from-env")
+	t.Setenv("SERVICE_MONGODB_PASSWORD", "vault://kv/mongo#password")
+
+	vault := SecretProviderFunc(func(ctx context.Context, ref string) (string, error) {
+		return "from-vault:" + strings.TrimPrefix(ref, "vault://"), nil
+	})
+	cfg, _, err := NewLoader(WithSecretProvider("vault", vault)).Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for key, tc := range map[string]struct{ got, want string }{
+		"database.password": {cfg.Database.Password.Value(), "from-file"},
+		"redis.password":    {cfg.Redis.Password.Value(), "from-env"},
+		"mongodb.password":  {cfg.Mongodb.Password.Value(), "from-vault:kv/mongo#password"},
+	} {
+		if tc.got != tc.want {
+			t.Errorf("%s = %q, want %q", key, tc.got, tc.want)
+		}
+	}
+}
+
+func TestSecretProviderErrors(t *testing.T) {
+	t.Setenv("SERVICE_DATABASE_PASSWORD", "vault://kv/db")
+	t.Setenv("SERVICE_REDIS_PASSWORD", "env://MISSING_REDIS_PASS")
+	failing := SecretProviderFunc(func(ctx context.Context, ref string) (string, error) {
+		return "", errors.New("sealed")
+	})
+	_, _, err := NewLoader(WithSecretProvider("vault", failing)).Load()
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	for _, want := range []string{
+		"database.password: resolve vault://kv/db: sealed",
+		"redis.password: resolve env://MISSING_REDIS_PASS",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error does not contain %q: %v", want, err)
+		}
+	}
+}
+
+func TestSecretLiteralLookingLikeURL(t *testing.T) {
+	for _, raw := range []string{"p@ss://word", "aws://secret", "ftp://x", "://", "hunter2"} {
+		t.Run(raw, func(t *testing.T) {
+			t.Setenv("SERVICE_DATABASE_PASSWORD", raw)
+			cfg, _, err := NewLoader().Load()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := cfg.Database.Password.Value(); got != raw {
+				t.Errorf("Value() = %q, want the literal", got)
+			}
+			if cfg.Database.Password.IsReference() {
+				t.Error("IsReference() = true")
+			}
+		})
+	}
+
+	for _, raw := range []string{"file:///run/secrets/db", "env://DB_PASS"} {
+		if !NewSecret(raw).IsReference() {
+			t.Errorf("%s: IsReference() = false", raw)
+		}
+	}
+}
@@ -1,5 +1,6 @@
 package config
 
 type Setting struct {
-	QueueProgram string
+	QueueProgram string          `json:"queue_program"`
+	Features     map[string]bool `json:"features"` // feature toggles, e.g. SERVICE_SETTING_FEATURES=new_flow=true
 }
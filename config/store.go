@@ -0,0 +1,198 @@
+package config
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// reloadableSections lists the top-level sections that may change while the
+// service is running. Changes to any other section are ignored until the
+// next restart.
+var reloadableSections = map[string]bool{
+	"log":       true,
+	"otel":      true,
+	"setting":   true,
+	"ratelimit": true,
+}
+
+// Store holds the running config snapshot. Snapshots are immutable: a reload
+// builds a new Config and swaps the pointer, so readers never see a partially
+// applied change.
+type Store struct {
+	loader  *Loader
+	current atomic.Pointer[Config]
+	sources atomic.Pointer[Sources]
+
+	mu   sync.Mutex
+	subs map[string][]func(cfg *Config)
+}
+
+func NewStore(loader *Loader, cfg Config, sources Sources) *Store {
+	s := &Store{
+		loader: loader,
+		subs:   map[string][]func(cfg *Config){},
+	}
+	s.current.Store(&cfg)
+	s.sources.Store(&sources)
+	return s
+}
+
+// Get returns the current snapshot. Callers must not modify it.
+func (s *Store) Get() *Config {
+	return s.current.Load()
+}
+
+// Sources returns where each value of the current snapshot came from.
+func (s *Store) Sources() Sources {
+	return *s.sources.Load()
+}
+
+// Subscribe registers fn to be called with the new snapshot whenever the
+// given section (its json key, e.g. "log") changes.
+func (s *Store) Subscribe(section string, fn func(cfg *Config)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.subs[section] = append(s.subs[section], fn)
+}
+
+// Reload re-reads every source. An invalid result is rejected and the running
+// snapshot stays untouched; otherwise reloadable sections are swapped in and
+// their subscribers notified.
+func (s *Store) Reload() error {
+	next, sources, err := s.loader.Load()
+	if err != nil {
+		log.Printf("config reload rejected: %v", err)
+		return err
+	}
+
+	s.mu.Lock()
+	prev := s.current.Load()
+	merged := *prev
+	mergedVal := reflect.ValueOf(&merged).Elem()
+	nextVal := reflect.ValueOf(next)
+
+	var changed []string
+	for i := 0; i < mergedVal.NumField(); i++ {
+		section := jsonName(mergedVal.Type().Field(i))
+		if sectionEqual(mergedVal.Field(i), nextVal.Field(i)) {
+			continue
+		}
+		if !reloadableSections[section] {
+			log.Printf("config section %s changed, restart required to apply it", section)
+			continue
+		}
+		mergedVal.Field(i).Set(nextVal.Field(i))
+		changed = append(changed, section)
+	}
+
+	if err := merged.Validate(); err != nil {
+		s.mu.Unlock()
+		log.Printf("config reload rejected: %v", err)
+		return err
+	}
+
+	s.current.Store(&merged)
+	s.sources.Store(&sources)
+
+	var notify []func(cfg *Config)
+	for _, section := range changed {
+		notify = append(notify, s.subs[section]...)
+	}
+	s.mu.Unlock()
+
+	if len(changed) > 0 {
+		log.Printf("config reloaded: %s", strings.Join(changed, ","))
+	}
+	for _, fn := range notify {
+		fn(&merged)
+	}
+
+	return nil
+}
+
+// RefreshSecrets resolves the secret references of the running snapshot
+// every interval. Resolved values are shared by every copy of a Secret, so
+// consumers reading Secret.Value pick up rotated credentials: the SQL
+// database and Redis on their next connection, the auth key on the next
+// token. The MongoDB client reads its password once and needs a restart.
+// A failed refresh keeps the previous values.
+func (s *Store) RefreshSecrets(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.loader.resolveSecrets(ctx, s.Get()); err != nil {
+				log.Printf("config secret refresh: %v", err)
+			}
+		}
+	}
+}
+
+// Watch reloads on SIGHUP and, when interval is positive, whenever the config
+// file's modification time or size changes. It blocks until ctx is done.
+func (s *Store) Watch(ctx context.Context, interval time.Duration) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	var tick <-chan time.Time
+	if interval > 0 && s.loader.File() != "" {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	last, _ := os.Stat(s.loader.File())
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			_ = s.Reload()
+		case <-tick:
+			info, err := os.Stat(s.loader.File())
+			if err != nil {
+				log.Printf("config watch: %v", err)
+				continue
+			}
+			if last != nil && info.ModTime().Equal(last.ModTime()) && info.Size() == last.Size() {
+				continue
+			}
+			last = info
+			_ = s.Reload()
+		}
+	}
+}
+
+// sectionEqual compares two config values, treating secrets by value rather
+// than by the identity of their shared storage.
+func sectionEqual(a, b reflect.Value) bool {
+	if a.Type() == secretType {
+		return a.Interface().(Secret).equal(b.Interface().(Secret))
+	}
+	if a.Kind() == reflect.Struct {
+		for i := 0; i < a.NumField(); i++ {
+			if !sectionEqual(a.Field(i), b.Field(i)) {
+				return false
+			}
+		}
+		return true
+	}
+	return reflect.DeepEqual(a.Interface(), b.Interface())
+}
@@ -0,0 +1,100 @@
+package config
+
+import (
+	"os"
+	"testing"
+)
+
+func TestStoreReload(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		file     string
+		wantErr  bool
+		wantLog  string
+		wantHost string
+		notified []string
+	}{
+		{
+			name:     "reloadable section",
+			file:     "log:\n  level: debug\n",
+			wantLog:  "debug",
+			wantHost: "localhost",
+			notified: []string{"log"},
+		},
+		{
+			name:     "non-reloadable section is kept",
+			file:     "database:\n  host: other-db\n",
+			wantLog:  "info",
+			wantHost: "localhost",
+		},
+		{
+			name:     "only the reloadable part applies",
+			file:     "log:\n  level: warn\ndatabase:\n  host: other-db\n",
+			wantLog:  "warn",
+			wantHost: "localhost",
+			notified: []string{"log"},
+		},
+		{
+			name:     "invalid config is rejected",
+			file:     "log:\n  level: loud\n",
+			wantErr:  true,
+			wantLog:  "info",
+			wantHost: "localhost",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			path := writeFile(t, "config.yaml", "log:\n  level: info\n")
+			loader := NewLoader(WithFile(path))
+			cfg, sources, err := loader.Load()
+			if err != nil {
+				t.Fatal(err)
+			}
+			store := NewStore(loader, cfg, sources)
+			var notified []string
+			for _, section := range []string{"log", "database", "otel"} {
+				store.Subscribe(section, func(cfg *Config) {
+					notified = append(notified, section)
+				})
+			}
+
+			if err := os.WriteFile(path, []byte(tc.file), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			if err := store.Reload(); (err != nil) != tc.wantErr {
+				t.Fatalf("Reload() = %v, want error %v", err, tc.wantErr)
+			}
+
+			got := store.Get()
+			if got.Log.Level != tc.wantLog {
+				t.Errorf("log.level = %q, want %q", got.Log.Level, tc.wantLog)
+			}
+			if got.Database.Host != tc.wantHost {
+				t.Errorf("database.host = %q, want %q", got.Database.Host, tc.wantHost)
+			}
+			if len(notified) != len(tc.notified) || len(notified) > 0 && notified[0] != tc.notified[0] {
+				t.Errorf("notified %v, want %v", notified, tc.notified)
+			}
+		})
+	}
+}
+
+func TestStoreSnapshotsAreImmutable(t *testing.T) {
+	path := writeFile(t, "config.yaml", "log:\n  level: info\n")
+	loader := NewLoader(WithFile(path))
+	cfg, sources, err := loader.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := NewStore(loader, cfg, sources)
+	before := store.Get()
+
+	if err := os.WriteFile(path, []byte("log:\n  level: error\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if before.Log.Level != "info" || store.Get().Log.Level != "error" {
+		t.Errorf("before %q, after %q", before.Log.Level, store.Get().Log.Level)
+	}
+}
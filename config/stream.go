@@ -0,0 +1,23 @@
+package config
+
+import "time"
+
+// Stream configures the live user event stream served over SSE and
+// WebSocket.
+type Stream struct {
+	Enabled bool `json:"enabled"`
+	// Channel is the Redis pub/sub channel events travel on, so every
+	// replica delivers the events consumed by any of them.
+	Channel string `json:"channel"`
+	// History is how many recent events are kept to resume a stream from
+	// Last-Event-ID.
+	History int `json:"history"`
+	// Buffer is how many events may wait for one subscriber. A subscriber
+	// that falls further behind is disconnected and resumes on reconnect.
+	Buffer int `json:"buffer"`
+	// Heartbeat is the interval of SSE comments and WebSocket pings that
+	// keep idle connections open through proxies.
+	Heartbeat time.Duration `json:"heartbeat"`
+	// WriteTimeout bounds every write to a subscriber.
+	WriteTimeout time.Duration `json:"write_timeout"`
+}
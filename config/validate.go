@@ -0,0 +1,254 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+	"time"
+)
+
+// validator collects every problem instead of stopping at the first one so a
+// broken deployment can be fixed in a single pass.
+type validator struct {
+	errs []error
+}
+
+func (v *validator) add(key string, format string, args ...interface{}) {
+	v.errs = append(v.errs, fmt.Errorf("%s: %s", key, fmt.Sprintf(format, args...)))
+}
+
+func (v *validator) required(key, value string) {
+	if strings.TrimSpace(value) == "" {
+		v.add(key, "is required")
+	}
+}
+
+func (v *validator) port(key string, port int) {
+	if port <= 0 || port > 65535 {
+		v.add(key, "must be a port between 1 and 65535, got %d", port)
+	}
+}
+
+func (v *validator) nonNegative(key string, n int64) {
+	if n < 0 {
+		v.add(key, "must not be negative, got %d", n)
+	}
+}
+
+func (v *validator) retry(key string, r Retry) {
+	if r.InitialInterval <= 0 {
+		v.add(key+".initial_interval", "must be positive, got %s", r.InitialInterval)
+	}
+	if r.MaxInterval < r.InitialInterval {
+		v.add(key+".max_interval", "must not be below initial_interval, got %s", r.MaxInterval)
+	}
+	if r.Multiplier < 1 {
+		v.add(key+".multiplier", "must be at least 1, got %v", r.Multiplier)
+	}
+	v.nonNegative(key+".timeout", int64(r.Timeout))
+	v.nonNegative(key+".attempt_timeout", int64(r.AttemptTimeout))
+}
+
+func (v *validator) oneOf(key, value string, allowed ...string) {
+	for _, a := range allowed {
+		if value == a {
+			return
+		}
+	}
+	v.add(key, "unknown value %q, expected one of %s", value, strings.Join(allowed, ","))
+}
+
+// Validate checks the whole config and returns every problem joined into a
+// single error, or nil when the config is usable.
+func (c *Config) Validate() error {
+	v := &validator{}
+
+	v.required("app.name", c.App.Name)
+	v.oneOf("app.enviroment", string(c.App.Environment),
+		string(EnvDevelopment), string(EnvStaging), string(EnvProduction))
+	v.nonNegative("app.reload_interval", int64(c.App.ReloadInterval))
+	v.nonNegative("app.secret_refresh_interval", int64(c.App.SecretRefreshInterval))
+	if c.App.ShutdownTimeout <= 0 {
+		v.add("app.shutdown_timeout", "must be positive, got %s", c.App.ShutdownTimeout)
+	}
+
+	v.oneOf("log.level", c.Log.Level, "debug", "info", "warn", "error", "panic", "fatal")
+
+	v.oneOf("database.driver", string(c.Database.Driver),
+		string(DriverMariadb), string(DriverMysql), string(DriverPostgres))
+	v.required("database.host", c.Database.Host)
+	v.port("database.port", c.Database.Port)
+	v.required("database.database", c.Database.Name)
+	v.nonNegative("database.max_idle", int64(c.Database.MaxIdleConn))
+	v.nonNegative("database.max_open", int64(c.Database.MaxOpenConn))
+	v.nonNegative("database.max_conn_lifetime", int64(c.Database.MaxConnLifetime))
+	v.retry("database.retry", c.Database.Retry)
+
+	v.required("otel.service_name", c.Otel.ServiceName)
+	if c.Otel.Probability < 0 || c.Otel.Probability > 1 {
+		v.add("otel.probability", "must be between 0 and 1, got %v", c.Otel.Probability)
+	}
+
+	if c.Rest.Enabled {
+		v.port("router.port", c.Rest.Port)
+		v.nonNegative("router.timeout", int64(c.Rest.Timeout))
+		for route, d := range c.Rest.RouteTimeouts {
+			if len(strings.Fields(route)) != 2 {
+				v.add("router.route_timeouts", "expected \"METHOD /path\", got %q", route)
+			}
+			v.nonNegative("router.route_timeouts."+route, int64(d))
+		}
+		v.nonNegative("router.max_body_size", c.Rest.MaxBodySize)
+		v.nonNegative("router.compression.min_size", int64(c.Rest.Compression.MinSize))
+		if c.Rest.Idempotency.TTL < time.Second {
+			v.add("router.idempotency.ttl", "must be at least 1s, got %s", c.Rest.Idempotency.TTL)
+		}
+		if c.Rest.Idempotency.LockTTL < time.Second {
+			v.add("router.idempotency.lock_ttl", "must be at least 1s, got %s", c.Rest.Idempotency.LockTTL)
+		}
+		if c.Rest.OpenAPI.Enabled && !strings.HasPrefix(c.Rest.OpenAPI.Path, "/") {
+			v.add("router.openapi.path", "must start with /, got %q", c.Rest.OpenAPI.Path)
+		}
+		for name, version := range c.Rest.Versions {
+			deprecated, err := version.DeprecatedAt()
+			if err != nil {
+				v.add("router.versions."+name+".deprecated", "expected a 2006-01-02 date, got %q", version.Deprecated)
+			}
+			sunset, err := version.SunsetAt()
+			if err != nil {
+				v.add("router.versions."+name+".sunset", "expected a 2006-01-02 date, got %q", version.Sunset)
+			}
+			if !sunset.IsZero() && sunset.Before(deprecated) {
+				v.add("router.versions."+name+".sunset", "must not be before deprecated")
+			}
+		}
+		for _, p := range c.Rest.TrustedProxies {
+			if net.ParseIP(p) == nil {
+				if _, _, err := net.ParseCIDR(p); err != nil {
+					v.add("router.trusted_proxies", "%q is neither an IP nor a CIDR", p)
+				}
+			}
+		}
+	}
+	if c.Stream.Enabled {
+		v.required("stream.channel", c.Stream.Channel)
+		v.nonNegative("stream.history", int64(c.Stream.History))
+		if c.Stream.Buffer < 1 {
+			v.add("stream.buffer", "must be at least 1, got %d", c.Stream.Buffer)
+		}
+		if c.Stream.Heartbeat < time.Second {
+			v.add("stream.heartbeat", "must be at least 1s, got %s", c.Stream.Heartbeat)
+		}
+		if c.Stream.WriteTimeout < time.Second {
+			v.add("stream.write_timeout", "must be at least 1s, got %s", c.Stream.WriteTimeout)
+		}
+	}
+	if c.Grpc.Enabled {
+		v.port("grpc.port", c.Grpc.Port)
+		for _, method := range c.Grpc.Auth.Public {
+			if strings.Count(method, "/") != 2 || !strings.HasPrefix(method, "/") {
+				v.add("grpc.auth.public", "expected \"/package.Service/Method\", got %q", method)
+			}
+		}
+		if (c.Grpc.TLS.CertFile == "") != (c.Grpc.TLS.KeyFile == "") {
+			v.add("grpc.tls", "cert_file and key_file must be set together")
+		}
+		if c.Grpc.TLS.ClientCAFile != "" && c.Grpc.TLS.CertFile == "" {
+			v.add("grpc.tls.client_ca_file", "requires cert_file and key_file")
+		}
+		// without any of them every authenticated call would be rejected
+		if c.Grpc.Auth.Enabled && c.Auth.Key.Value() == "" && c.Auth.JWKS == "" && c.Grpc.TLS.ClientCAFile == "" {
+			v.add("grpc.auth.enabled", "requires auth.key, auth.jwks or grpc.tls.client_ca_file")
+		}
+	}
+	if c.Rest.Enabled && c.Grpc.Enabled && c.Rest.Port == c.Grpc.Port {
+		v.add("grpc.port", "must differ from router.port")
+	}
+	for _, alg := range c.Auth.Algorithms {
+		v.oneOf("auth.algorithms", alg, "HS256", "RS256", "ES256")
+	}
+	v.nonNegative("auth.jwks_refresh", int64(c.Auth.JWKSRefresh))
+	v.nonNegative("auth.leeway", int64(c.Auth.Leeway))
+	v.nonNegative("auth.permission_cache_ttl", int64(c.Auth.PermissionCacheTTL))
+	if slices.ContainsFunc(c.Auth.Admins, func(s string) bool { return strings.TrimSpace(s) == "" }) {
+		v.add("auth.admins", "must not contain empty subjects")
+	}
+
+	for name, p := range c.RateLimit.Policies {
+		key := "ratelimit.policies." + name
+		v.oneOf(key+".algorithm", string(p.Algorithm),
+			string(RateLimitSlidingWindow), string(RateLimitTokenBucket))
+		if p.Limit <= 0 {
+			v.add(key+".limit", "must be positive, got %d", p.Limit)
+		}
+		// the limiters count in whole milliseconds
+		if p.Window < time.Millisecond {
+			v.add(key+".window", "must be at least 1ms, got %s", p.Window)
+		}
+		v.oneOf(key+".key", string(p.Key),
+			string(RateLimitByIP), string(RateLimitByUser), string(RateLimitByAPIKey), string(RateLimitByRoute))
+	}
+
+	if c.Admin.Enabled {
+		v.port("admin.port", c.Admin.Port)
+		if c.Rest.Enabled && c.Admin.Port == c.Rest.Port {
+			v.add("admin.port", "must differ from router.port")
+		}
+		if c.Grpc.Enabled && c.Admin.Port == c.Grpc.Port {
+			v.add("admin.port", "must differ from grpc.port")
+		}
+	}
+
+	if c.Kafka.Enabled {
+		v.required("kafka.host", c.Kafka.Host)
+		v.retry("kafka.retry", c.Kafka.Retry)
+	}
+
+	v.oneOf("cache.driver", string(c.Cache.Driver), string(CacheDriverRedis))
+	if c.Cache.Driver == CacheDriverRedis {
+		v.required("redis.host", c.Redis.Host)
+		v.retry("redis.retry", c.Redis.Retry)
+	}
+	v.nonNegative("redis.db", int64(c.Redis.DB))
+	if c.Redis.Port != 0 {
+		v.port("redis.port", c.Redis.Port)
+	}
+
+	if c.Elastic.Enabled {
+		v.required("elastic.host", c.Elastic.Host)
+		v.retry("elastic.retry", c.Elastic.Retry)
+	}
+
+	if c.Mongodb.Enabled {
+		v.required("mongodb.url", c.Mongodb.Url)
+		v.retry("mongodb.retry", c.Mongodb.Retry)
+	}
+	if c.Mongodb.Url != "" &&
+		!strings.HasPrefix(c.Mongodb.Url, "mongodb://") &&
+		!strings.HasPrefix(c.Mongodb.Url, "mongodb+srv://") {
+		v.add("mongodb.url", "must start with mongodb:// or mongodb+srv://")
+	}
+	v.nonNegative("mongodb.max_conn_idle_time", int64(c.Mongodb.MaxConnIdleTime))
+	for _, compressor := range c.Mongodb.Compression {
+		v.oneOf("mongodb.compression", compressor, "snappy", "zlib", "zstd")
+	}
+
+	if c.Health.Timeout <= 0 {
+		v.add("health.timeout", "must be positive, got %s", c.Health.Timeout)
+	}
+	if c.Health.GrpcInterval <= 0 {
+		v.add("health.grpc_interval", "must be positive, got %s", c.Health.GrpcInterval)
+	}
+	for _, name := range c.Health.Critical {
+		v.oneOf("health.critical", name, "database", "cache", "elastic", "mongodb", "kafka")
+	}
+	// the app module migrates, bootstraps admins and registers lookups
+	// against the database at startup, it cannot start degraded without it
+	if !c.Health.IsCritical("database") {
+		v.add("health.critical", "must include database")
+	}
+
+	return errors.Join(v.errs...)
+}
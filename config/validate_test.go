@@ -0,0 +1,112 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidateDefaults(t *testing.T) {
+	cfg := NewConfig()
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("defaults are invalid: %v", err)
+	}
+}
+
+func TestValidateReportsEveryProblem(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		change func(c *Config)
+		keys   []string
+	}{
+		{
+			name: "several sections",
+			change: func(c *Config) {
+				c.App.Name = " "
+				c.Database.Port = 70000
+				c.Log.Level = "loud"
+				c.Database.Retry.Multiplier = 0.5
+			},
+			keys: []string{"app.name", "database.port", "log.level", "database.retry.multiplier"},
+		},
+		{
+			name: "database not critical",
+			change: func(c *Config) {
+				c.Health.Critical = []string{"cache", "kafka"}
+			},
+			keys: []string{"health.critical"},
+		},
+		{
+			name: "port clash",
+			change: func(c *Config) {
+				c.Grpc.Port = c.Rest.Port
+			},
+			keys: []string{"grpc.port"},
+		},
+		{
+			name: "negative durations",
+			change: func(c *Config) {
+				c.Auth.Leeway = -time.Second
+				c.App.ShutdownTimeout = 0
+			},
+			keys: []string{"auth.leeway", "app.shutdown_timeout"},
+		},
+		{
+			name: "rate limit window below a millisecond",
+			change: func(c *Config) {
+				c.RateLimit.Policies["default"] = RateLimitPolicy{
+					Algorithm: RateLimitSlidingWindow,
+					Limit:     10,
+					Window:    500 * time.Microsecond,
+					Key:       RateLimitByIP,
+				}
+			},
+			keys: []string{"ratelimit.policies.default.window"},
+		},
+		{
+			name: "grpc auth without a key",
+			change: func(c *Config) {
+				c.Grpc.Auth.Enabled = true
+			},
+			keys: []string{"grpc.auth.enabled"},
+		},
+		{
+			name: "grpc auth with a jwks",
+			change: func(c *Config) {
+				c.Grpc.Auth.Enabled = true
+				c.Auth.JWKS = "https://issuer.test/.well-known/jwks.json"
+			},
+		},
+		{
+			name: "disabled sections are skipped",
+			change: func(c *Config) {
+				c.Grpc.Enabled = false
+				c.Grpc.Port = 0
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := NewConfig()
+			tc.change(&cfg)
+			err := cfg.Validate()
+			if len(tc.keys) == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			lines := strings.Split(err.Error(), "\n")
+			if len(lines) != len(tc.keys) {
+				t.Errorf("got %d problems, want %d: %v", len(lines), len(tc.keys), err)
+			}
+			for _, key := range tc.keys {
+				if !strings.Contains(err.Error(), key+": ") {
+					t.Errorf("error does not report %s: %v", key, err)
+				}
+			}
+		})
+	}
+}
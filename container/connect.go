@@ -0,0 +1,34 @@
+package container
+
+import (
+	"context"
+	"log"
+	"service/config"
+	"service/pkg/health"
+	"service/pkg/retry"
+)
+
+// connect waits for a dependency with retries and registers its health
+// check. A critical dependency that never becomes ready fails startup. A
+// non-critical one starts degraded: its check reports it down, which only
+// degrades readiness, while it is retried in the background until c.bg is
+// cancelled.
+func (c *components) connect(ctx context.Context, name string, cfg config.Retry, ping health.CheckFunc) error {
+	critical := c.cfg.Health.IsCritical(name)
+	c.checks.Add(name, critical, ping)
+
+	err := retry.Do(ctx, cfg, name, ping)
+	if err == nil || critical {
+		return err
+	}
+
+	log.Printf("%s is not critical, starting degraded: %v", name, err)
+	go func() {
+		// keep retrying; the client reconnects on its own once reachable
+		cfg.Timeout = -1
+		if err := retry.Do(c.bg, cfg, name, ping); err == nil {
+			log.Printf("%s recovered", name)
+		}
+	}()
+	return nil
+}
@@ -0,0 +1,102 @@
+package container
+
+import (
+	"context"
+	"errors"
+	"service/config"
+	"service/pkg/health"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// flaky is a dependency whose ping fails the first n times.
+type flaky struct {
+	n     int32
+	calls atomic.Int32
+}
+
+func (f *flaky) ping(ctx context.Context) error {
+	if f.calls.Add(1) <= f.n {
+		return errors.New("connection refused")
+	}
+	return nil
+}
+
+func newConnectComponents(t *testing.T) *components {
+	t.Helper()
+	cfg := config.NewConfig()
+	bg, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	return &components{cfg: &cfg, checks: health.NewRegistry(time.Second), bg: bg}
+}
+
+var fastRetry = config.Retry{
+	InitialInterval: time.Millisecond,
+	MaxInterval:     5 * time.Millisecond,
+	Multiplier:      2,
+	Timeout:         30 * time.Millisecond,
+}
+
+func TestConnectRetries(t *testing.T) {
+	c := newConnectComponents(t)
+	dep := &flaky{n: 3}
+	if err := c.connect(context.Background(), "database", fastRetry, dep.ping); err != nil {
+		t.Fatal(err)
+	}
+	if calls := dep.calls.Load(); calls != 4 {
+		t.Errorf("%d pings, want 4", calls)
+	}
+	if report := c.checks.Check(context.Background()); report.Status != health.StatusUp || !report.Checks["database"].Critical {
+		t.Errorf("report = %+v", report)
+	}
+}
+
+func TestConnectCriticalFails(t *testing.T) {
+	c := newConnectComponents(t)
+	dep := &flaky{n: 1 << 30}
+	if err := c.connect(context.Background(), "database", fastRetry, dep.ping); err == nil {
+		t.Fatal("a critical dependency that never comes up must fail startup")
+	}
+	// nothing keeps retrying once startup failed
+	calls := dep.calls.Load()
+	time.Sleep(20 * time.Millisecond)
+	if dep.calls.Load() != calls {
+		t.Error("critical dependency retried in the background")
+	}
+}
+
+func TestConnectDegraded(t *testing.T) {
+	c := newConnectComponents(t)
+	// fails through the startup retries, then recovers in the background
+	dep := &flaky{n: 30}
+	if err := c.connect(context.Background(), "elastic", fastRetry, dep.ping); err != nil {
+		t.Fatalf("non-critical dependency failed startup: %v", err)
+	}
+	if calls := dep.calls.Load(); calls >= 30 {
+		t.Fatalf("recovered during startup after %d pings, raise n", calls)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for dep.calls.Load() <= dep.n {
+		if time.Now().After(deadline) {
+			t.Fatalf("not retried in the background: %d pings", dep.calls.Load())
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if report := c.checks.Check(context.Background()); report.Status != health.StatusUp {
+		t.Errorf("after recovery: %+v", report)
+	}
+}
+
+func TestConnectDegradedReadiness(t *testing.T) {
+	c := newConnectComponents(t)
+	dep := &flaky{n: 1 << 30}
+	if err := c.connect(context.Background(), "elastic", fastRetry, dep.ping); err != nil {
+		t.Fatal(err)
+	}
+	report := c.checks.Check(context.Background())
+	if report.Status != health.StatusDegraded || report.Checks["elastic"].Critical {
+		t.Errorf("report = %+v", report)
+	}
+}
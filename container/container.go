@@ -3,72 +3,62 @@ package container
 import (
 	"context"
 	"fmt"
-	"service/app/controllers/broker"
-	"service/app/controllers/grpc"
-	"service/app/controllers/restapi"
-	"service/app/middlewares"
-	"service/app/repositories"
-	"service/app/usecases"
+	"log"
 	"service/config"
-	"service/pkg/cache"
-	"service/pkg/datastore/elastic"
-	"service/pkg/datastore/orm"
-	"service/pkg/message_broker/kafka"
+	"service/pkg/health"
+	"service/pkg/lifecycle"
+	"service/pkg/logger"
 	"service/pkg/otel"
-	"service/pkg/server"
 	"service/pkg/setting"
-	"service/routes/api"
-	brokerRouter "service/routes/broker"
 )
 
-func StartApp(ctx context.Context) {
-	cfg := config.NewConfig()
-	setting.NewSetting(&cfg)
-
-	db := orm.NewProvider(&cfg.Database)
-	cache := cache.NewCache(ctx, &cfg)
-	esClient := elastic.NewElasticClient(ctx, &cfg)
-	//mongoClient := mongodb.NewMongodb(ctx, &cfg)
-
-	repo := repositories.NewRepositories(db, cache, esClient, nil)
-	//repo := repositories.NewRepositories(db, cache, esClient, mongoClient)
-	uc := usecases.NewUsecase(repo)
-	rest := restapi.NewRestapi(uc)
-	mid := middlewares.NewMiddlewares()
-
-	traceProvider, teardown, err := otel.InitTracing(otel.Config{
-		ServiceName: cfg.Otel.ServiceName,
-		Host:        cfg.Otel.HostTempo,
-		Probability: 0.05,
-	})
-
+// StartApp loads config from args and the environment, starts the given
+// modules (every enabled module when none is given) with their dependencies
+// and serves until ctx is cancelled or a server fails. Modules are then
+// stopped in reverse order within app.shutdown_timeout.
+func StartApp(ctx context.Context, args []string, modules ...string) error {
+	loader := config.NewLoader(config.WithArgs(args))
+	cfg, sources, err := loader.Load()
 	if err != nil {
-		panic(fmt.Errorf("starting tracing: %w", err))
+		return fmt.Errorf("load config: %w", err)
 	}
+	for _, key := range sources.Keys() {
+		if src := sources.Get(key); src != config.SourceDefault {
+			log.Printf("config %s set from %s", key, src)
+		}
+	}
+	store := config.NewStore(loader, cfg, sources)
+	setting.NewSetting(&cfg)
 
-	defer teardown(ctx)
-
-	tracer := traceProvider.Tracer(cfg.Otel.ServiceName)
-	tracer.Start(ctx, "main")
+	var level logger.Level
+	_ = level.FromString(cfg.Log.Level)
+	logger.NewLogger(level)
+	defer func() {
+		_ = logger.Logger.Flush()
+	}()
 
-	// run message broker
-	brokerHandler := broker.NewBroker()
-	pub, sub := setupKafka(ctx, &cfg.Kafka)
-	defer pub.Close()
+	setting.SubscribeConfig(store)
+	logger.SubscribeConfig(store)
+	otel.SubscribeConfig(store)
+	go store.Watch(ctx, cfg.App.ReloadInterval)
+	go store.RefreshSecrets(ctx, cfg.App.SecretRefreshInterval)
 
-	//run message broker
-	go kafka.NewMessageBroker(
-		ctx,
-		&cfg.Kafka,
-		pub,
-		sub,
-		brokerHandler,
-		brokerRouter.NewUserBroker)
+	bg, cancel := context.WithCancel(context.Background())
+	defer cancel()
 
-	grpxController := grpc.NewGrpc(ctx, uc)
+	reg := lifecycle.NewRegistry()
+	registerModules(reg, &components{
+		cfg:     &cfg,
+		store:   store,
+		checks:  health.NewRegistry(cfg.Health.Timeout),
+		bg:      bg,
+		targets: modules,
+	})
 
-	go server.RunGrpcServer(ctx, &cfg, grpxController)
+	// the admin listener runs alongside whichever subcommand was picked
+	if len(modules) > 0 && cfg.Admin.Enabled {
+		modules = append(modules, "admin")
+	}
 
-	// run http server
-	server.RunHTTPServer(ctx, &cfg, tracer, rest, mid, api.NewUserApi, api.NewPermissionApi)
+	return reg.Run(ctx, cfg.App.ShutdownTimeout, modules...)
 }
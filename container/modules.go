@@ -0,0 +1,445 @@
+package container
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	kafkasdk "github.com/ThreeDotsLabs/watermill-kafka/v3/pkg/kafka"
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/elastic/go-elasticsearch/v9"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.opentelemetry.io/otel/trace"
+	"service/app/controllers/broker"
+	"service/app/controllers/grpc"
+	"service/app/controllers/restapi"
+	"service/app/middlewares"
+	"service/app/repositories"
+	"service/app/usecases"
+	"service/app/usecases/user"
+	"service/config"
+	"service/pkg/auth"
+	"service/pkg/cache"
+	"service/pkg/datastore/elastic"
+	"service/pkg/datastore/mongodb"
+	"service/pkg/datastore/orm"
+	"service/pkg/eventstream"
+	"service/pkg/health"
+	"service/pkg/httpcache"
+	"service/pkg/lifecycle"
+	"service/pkg/message_broker/kafka"
+	"service/pkg/otel"
+	"service/pkg/ratelimit"
+	"service/pkg/retry"
+	"service/pkg/server"
+	"service/routes/api"
+	brokerRouter "service/routes/broker"
+	"sort"
+	"sync/atomic"
+)
+
+// components holds what the modules build so later modules can use it.
+type components struct {
+	cfg    *config.Config
+	store  *config.Store
+	checks *health.Registry
+	// bg lives until the app stops, for background reconnects
+	bg context.Context
+	// targets are the modules of the subcommand, none when it runs all
+	targets []string
+
+	tracer   trace.Tracer
+	teardown func(ctx context.Context)
+
+	db       *orm.Orm
+	cache    cache.ICache
+	esClient *elasticsearch.Client
+	mongo    *mongo.Client
+	sub      *kafkasdk.Subscriber
+	pub      *kafkasdk.Publisher
+
+	events  *eventstream.Hub
+	usecase *usecases.Usecase
+	rest    *restapi.Restapi
+	mid     *middlewares.Middlewares
+	// verifier checks the bearer tokens of HTTP and gRPC calls
+	verifier *auth.Verifier
+	grpc     *grpc.Grpc
+
+	grpcServer  *server.GrpcServer
+	adminServer *server.AdminServer
+	// the admin module starts first and reads these while the modules
+	// that set them are still starting
+	httpServer atomic.Pointer[server.HTTPServer]
+	router     atomic.Pointer[message.Router]
+}
+
+// registerModules registers every module enabled in config. Dependencies on
+// optional modules are only declared when they are enabled.
+func registerModules(reg *lifecycle.Registry, c *components) {
+	cfg := c.cfg
+
+	if cfg.Otel.Enabled {
+		reg.Register(otelModule(c))
+	}
+	reg.Register(databaseModule(c))
+	reg.Register(cacheModule(c))
+	if cfg.Elastic.Enabled {
+		reg.Register(elasticModule(c))
+	}
+	if cfg.Mongodb.Enabled {
+		reg.Register(mongodbModule(c))
+	}
+	if cfg.Kafka.Enabled {
+		reg.Register(kafkaModule(c))
+	}
+
+	reg.Register(appModule(c))
+
+	if cfg.Rest.Enabled && cfg.Stream.Enabled {
+		reg.Register(streamModule(c))
+	}
+	if cfg.Rest.Enabled {
+		reg.Register(httpModule(c))
+	}
+	if cfg.Grpc.Enabled {
+		reg.Register(grpcModule(c))
+	}
+	if cfg.Kafka.Enabled {
+		reg.Register(brokerModule(c))
+	}
+	if cfg.Admin.Enabled {
+		reg.Register(adminModule(c))
+	}
+}
+
+func otelModule(c *components) lifecycle.Module {
+	return lifecycle.Module{
+		Name: "otel",
+		Start: func(ctx context.Context) error {
+			traceProvider, teardown, err := otel.InitTracing(otel.Config{
+				ServiceName: c.cfg.Otel.ServiceName,
+				Host:        c.cfg.Otel.HostTempo,
+				Probability: c.cfg.Otel.Probability,
+			})
+			if err != nil {
+				return err
+			}
+			c.tracer = traceProvider.Tracer(c.cfg.Otel.ServiceName)
+			c.teardown = teardown
+			return nil
+		},
+		Stop: func(ctx context.Context) error {
+			c.teardown(ctx)
+			return nil
+		},
+	}
+}
+
+func databaseModule(c *components) lifecycle.Module {
+	return lifecycle.Module{
+		Name: "database",
+		Start: func(ctx context.Context) error {
+			db, err := orm.NewProvider(&c.cfg.Database)
+			if err != nil {
+				return err
+			}
+			c.db = db
+			return c.connect(ctx, "database", c.cfg.Database.Retry, c.db.Ping)
+		},
+		Stop: func(ctx context.Context) error {
+			return c.db.Close()
+		},
+	}
+}
+
+func cacheModule(c *components) lifecycle.Module {
+	return lifecycle.Module{
+		Name: "cache",
+		Start: func(ctx context.Context) error {
+			ch, err := cache.NewCache(ctx, c.cfg)
+			if err != nil {
+				return err
+			}
+			c.cache = ch
+			return c.connect(ctx, "cache", c.cfg.Redis.Retry, c.cache.Ping)
+		},
+		Stop: func(ctx context.Context) error {
+			return c.cache.Close()
+		},
+	}
+}
+
+func elasticModule(c *components) lifecycle.Module {
+	return lifecycle.Module{
+		Name: "elastic",
+		Start: func(ctx context.Context) error {
+			es, err := elastic.NewElasticClient(ctx, c.cfg)
+			if err != nil {
+				return err
+			}
+			c.esClient = es
+			return c.connect(ctx, "elastic", c.cfg.Elastic.Retry, func(ctx context.Context) error {
+				return elastic.Ping(ctx, c.esClient)
+			})
+		},
+	}
+}
+
+func mongodbModule(c *components) lifecycle.Module {
+	return lifecycle.Module{
+		Name: "mongodb",
+		Start: func(ctx context.Context) error {
+			client, err := mongodb.NewMongodb(ctx, c.cfg)
+			if err != nil {
+				return err
+			}
+			c.mongo = client
+			return c.connect(ctx, "mongodb", c.cfg.Mongodb.Retry, func(ctx context.Context) error {
+				return mongodb.Ping(ctx, c.mongo)
+			})
+		},
+		Stop: func(ctx context.Context) error {
+			return c.mongo.Disconnect(ctx)
+		},
+	}
+}
+
+func kafkaModule(c *components) lifecycle.Module {
+	return lifecycle.Module{
+		Name: "kafka",
+		Start: func(ctx context.Context) error {
+			// the clients connect when created and the broker cannot run
+			// without them, so kafka never starts degraded
+			err := retry.Do(ctx, c.cfg.Kafka.Retry, "kafka", func(ctx context.Context) error {
+				sub, pub, err := setupKafka(ctx, &c.cfg.Kafka)
+				if err != nil {
+					return err
+				}
+				c.sub, c.pub = sub, pub
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			c.checks.Add("kafka", c.cfg.Health.IsCritical("kafka"), func(ctx context.Context) error {
+				return kafka.Ping(ctx, &c.cfg.Kafka)
+			})
+			return nil
+		},
+		Stop: func(ctx context.Context) error {
+			return errors.Join(c.sub.Close(), c.pub.Close())
+		},
+	}
+}
+
+// appModule builds repositories, usecases and controllers on top of the
+// enabled datastores. Every entry point reads the database and cache
+// through it. No entry point reads the elastic and mongodb repositories
+// yet, so only a start of every module waits for them; a subcommand leaves
+// them stopped and their repositories without a client.
+func appModule(c *components) lifecycle.Module {
+	deps := []string{"database", "cache"}
+	if c.cfg.Elastic.Enabled && len(c.targets) == 0 {
+		deps = append(deps, "elastic")
+	}
+	if c.cfg.Mongodb.Enabled && len(c.targets) == 0 {
+		deps = append(deps, "mongodb")
+	}
+
+	return lifecycle.Module{
+		Name:      "app",
+		DependsOn: deps,
+		Start: func(ctx context.Context) error {
+			repo := repositories.NewRepositories(c.db, c.cache, c.esClient, c.mongo)
+			if err := user.RegisterValidation(repo.UserDB); err != nil {
+				return err
+			}
+			responses := httpcache.NewStore(c.cache)
+			// events travel through redis to every replica, other cache
+			// drivers only reach the subscribers of this process
+			pubsub, _ := c.cache.(eventstream.PubSub)
+			c.events = eventstream.NewHub(&c.cfg.Stream, pubsub)
+			c.usecase = usecases.NewUsecase(c.cfg, repo, responses, c.events)
+			if c.cfg.Database.Migrate {
+				if err := repo.Permission.Migrate(ctx); err != nil {
+					return fmt.Errorf("migrate permissions: %w", err)
+				}
+			}
+			if err := c.usecase.PermissionUsecase.Bootstrap(ctx, c.cfg.Auth.Admins); err != nil {
+				return fmt.Errorf("bootstrap admins: %w", err)
+			}
+			c.rest = restapi.NewRestapi(c.cfg, c.usecase)
+			verifier, err := auth.NewVerifier(&c.cfg.Auth)
+			if err != nil {
+				return err
+			}
+			c.verifier = verifier
+			// the redis cache runs the limiter scripts, other drivers limit
+			// in process only
+			runner, _ := c.cache.(ratelimit.ScriptRunner)
+			limiter := ratelimit.NewLimiter(runner, &c.cfg.RateLimit)
+			limiter.SubscribeConfig(c.store)
+			c.mid = middlewares.NewMiddlewares(&c.cfg.Rest, verifier, c.usecase.PermissionUsecase, limiter, c.cache, responses)
+			c.grpc = grpc.NewGrpc(ctx, c.usecase)
+			return nil
+		},
+	}
+}
+
+// withOtel adds the otel module to deps when tracing is enabled so entry
+// points are traced whichever subcommand starts them.
+func withOtel(c *components, deps ...string) []string {
+	if c.cfg.Otel.Enabled {
+		deps = append(deps, "otel")
+	}
+	return deps
+}
+
+// streamModule delivers the events published by every replica to the
+// streams served by this one.
+func streamModule(c *components) lifecycle.Module {
+	return lifecycle.Module{
+		Name:      "stream",
+		DependsOn: []string{"app"},
+		Run: func() error {
+			return c.events.Run()
+		},
+		Stop: func(ctx context.Context) error {
+			c.events.Close()
+			return nil
+		},
+	}
+}
+
+func httpModule(c *components) lifecycle.Module {
+	deps := []string{"app"}
+	if c.cfg.Stream.Enabled {
+		deps = append(deps, "stream")
+	}
+
+	return lifecycle.Module{
+		Name:      "http",
+		DependsOn: withOtel(c, deps...),
+		Start: func(ctx context.Context) error {
+			httpServer, err := server.NewHTTPServer(c.cfg, c.tracer, c.rest, c.mid, c.checks, api.Versions()...)
+			if err != nil {
+				return err
+			}
+			// open streams would hold up the graceful shutdown
+			httpServer.OnShutdown(c.events.Close)
+			c.httpServer.Store(httpServer)
+			return nil
+		},
+		Run: func() error {
+			return c.httpServer.Load().Run()
+		},
+		Stop: func(ctx context.Context) error {
+			return c.httpServer.Load().Shutdown(ctx)
+		},
+	}
+}
+
+func grpcModule(c *components) lifecycle.Module {
+	return lifecycle.Module{
+		Name:      "grpc",
+		DependsOn: withOtel(c, "app"),
+		Start: func(ctx context.Context) error {
+			grpcServer, err := server.NewGrpcServer(c.cfg, c.tracer, c.verifier, c.grpc, c.checks)
+			if err != nil {
+				return err
+			}
+			c.grpcServer = grpcServer
+			return nil
+		},
+		Run: func() error {
+			return c.grpcServer.Run()
+		},
+		Stop: func(ctx context.Context) error {
+			return c.grpcServer.Shutdown(ctx)
+		},
+	}
+}
+
+func brokerModule(c *components) lifecycle.Module {
+	return lifecycle.Module{
+		Name:      "broker",
+		DependsOn: withOtel(c, "app", "kafka"),
+		Start: func(ctx context.Context) error {
+			router, err := kafka.NewMessageBroker(
+				ctx,
+				&c.cfg.Kafka,
+				c.cfg.App.ShutdownTimeout,
+				c.sub,
+				c.pub,
+				broker.NewBroker(c.usecase.UserUsecase),
+				brokerRouter.NewUserBroker)
+			if err != nil {
+				return err
+			}
+			c.router.Store(router)
+			return nil
+		},
+		Run: func() error {
+			// the router is stopped through Close, not by a context
+			return c.router.Load().Run(context.Background())
+		},
+		Stop: func(ctx context.Context) error {
+			return c.router.Load().Close()
+		},
+	}
+}
+
+// adminModule serves the internal debug listener. It depends on nothing so
+// it comes up first; routes and handlers of modules that are not running in
+// this process are reported empty.
+func adminModule(c *components) lifecycle.Module {
+	return lifecycle.Module{
+		Name: "admin",
+		Start: func(ctx context.Context) error {
+			c.adminServer = server.NewAdminServer(&c.cfg.Admin, adminSources(c))
+			return nil
+		},
+		Run: func() error {
+			return c.adminServer.Run()
+		},
+		Stop: func(ctx context.Context) error {
+			return c.adminServer.Shutdown(ctx)
+		},
+	}
+}
+
+// adminSources reads the state of the other modules for the admin server.
+// It may run before and while those modules start.
+func adminSources(c *components) server.AdminSources {
+	return server.AdminSources{
+		Config:        c.store.Get,
+		ConfigSources: c.store.Sources,
+		Routes: func() gin.RoutesInfo {
+			httpServer := c.httpServer.Load()
+			if httpServer == nil {
+				return nil
+			}
+			return httpServer.Routes()
+		},
+		Versions: func() []server.VersionRoutes {
+			httpServer := c.httpServer.Load()
+			if httpServer == nil {
+				return nil
+			}
+			return httpServer.Versions()
+		},
+		BrokerHandlers: func() []string {
+			router := c.router.Load()
+			if router == nil {
+				return nil
+			}
+			names := make([]string, 0, len(router.Handlers()))
+			for name := range router.Handlers() {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			return names
+		},
+	}
+}
@@ -0,0 +1,104 @@
+package container
+
+import (
+	"service/config"
+	"service/pkg/health"
+	"service/pkg/lifecycle"
+	"service/pkg/server"
+	"slices"
+	"testing"
+	"time"
+)
+
+// order returns the modules registered for cfg in start order.
+func order(t *testing.T, cfg config.Config, targets ...string) []string {
+	t.Helper()
+	reg := lifecycle.NewRegistry()
+	registerModules(reg, &components{cfg: &cfg, targets: targets})
+	order, err := reg.Order(targets...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return order
+}
+
+// before fails the test unless every module of deps starts before name.
+func before(t *testing.T, order []string, name string, deps ...string) {
+	t.Helper()
+	at := slices.Index(order, name)
+	if at < 0 {
+		t.Fatalf("%s not started: %v", name, order)
+	}
+	for _, dep := range deps {
+		if i := slices.Index(order, dep); i < 0 || i > at {
+			t.Errorf("%s does not start before %s: %v", dep, name, order)
+		}
+	}
+}
+
+func TestModuleOrder(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.Elastic.Enabled = true
+	cfg.Mongodb.Enabled = true
+	cfg.Kafka.Enabled = true
+	cfg.Grpc.Enabled = true
+	cfg.Stream.Enabled = true
+	cfg.Admin.Enabled = true
+	cfg.Otel.Enabled = true
+
+	all := order(t, cfg)
+	before(t, all, "app", "database", "cache", "elastic", "mongodb")
+	before(t, all, "stream", "app")
+	before(t, all, "http", "app", "stream", "otel")
+	before(t, all, "grpc", "app", "otel")
+	before(t, all, "broker", "app", "kafka", "otel")
+	if len(all) != 12 {
+		t.Errorf("modules = %v", all)
+	}
+}
+
+func TestModuleTargets(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.Kafka.Enabled = true
+	cfg.Grpc.Enabled = true
+	// no entry point reads them, so no subcommand starts them
+	cfg.Elastic.Enabled = true
+	cfg.Mongodb.Enabled = true
+	cfg.Otel.Enabled = false
+	cfg.Stream.Enabled = false
+
+	for target, want := range map[string][]string{
+		"http":   {"database", "cache", "app", "http"},
+		"grpc":   {"database", "cache", "app", "grpc"},
+		"broker": {"database", "cache", "app", "kafka", "broker"},
+	} {
+		if got := order(t, cfg, target); !slices.Equal(got, want) {
+			t.Errorf("%s: modules = %v, want %v", target, got, want)
+		}
+	}
+}
+
+// The admin module serves while the http module starts; run with -race.
+func TestAdminSourcesDuringStartup(t *testing.T) {
+	cfg := config.NewConfig()
+	c := &components{cfg: &cfg}
+	src := adminSources(c)
+
+	go func() {
+		httpServer, err := server.NewHTTPServer(&cfg, nil, nil, nil, health.NewRegistry(time.Second))
+		if err != nil {
+			t.Error(err)
+		}
+		c.httpServer.Store(httpServer)
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for c.httpServer.Load() == nil && time.Now().Before(deadline) {
+		src.Routes()
+		src.Versions()
+		src.BrokerHandlers()
+	}
+	if len(src.Routes()) == 0 {
+		t.Error("no routes once the http module started")
+	}
+}
@@ -2,14 +2,21 @@ package container
 
 import (
 	"context"
+	"errors"
 	kafkasdk "github.com/ThreeDotsLabs/watermill-kafka/v3/pkg/kafka"
 	"service/config"
 	"service/pkg/message_broker/kafka"
 )
 
-func setupKafka(ctx context.Context, cfg *config.Kafka) (*kafkasdk.Subscriber, *kafkasdk.Publisher) {
-	sub := kafka.NewSubscriber(ctx, cfg)
-	pub := kafka.NewProducer(ctx, cfg)
+func setupKafka(ctx context.Context, cfg *config.Kafka) (*kafkasdk.Subscriber, *kafkasdk.Publisher, error) {
+	sub, err := kafka.NewSubscriber(ctx, cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	pub, err := kafka.NewProducer(ctx, cfg)
+	if err != nil {
+		return nil, nil, errors.Join(err, sub.Close())
+	}
 
-	return sub, pub
+	return sub, pub, nil
 }
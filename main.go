@@ -1,11 +1,72 @@
+// @title service
+// @version 1
+// @description REST API of the service. Paths are relative to router.prefix and the API version.
+// @BasePath /program/v1
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
+// @description "Bearer " followed by the access token
 package main
 
 import (
 	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
 	"service/container"
+	"strings"
+	"syscall"
 )
 
+type command struct {
+	name    string
+	usage   string
+	modules []string
+}
+
+// commands map each subcommand to the modules it serves. Dependencies such
+// as the database or Kafka are started only when one of them needs it.
+var commands = []command{
+	{name: "all", usage: "serve HTTP, gRPC and consume Kafka (default)"},
+	{name: "serve-http", usage: "serve the REST API only", modules: []string{"http"}},
+	{name: "serve-grpc", usage: "serve the gRPC API only", modules: []string{"grpc"}},
+	{name: "worker", usage: "consume Kafka messages only", modules: []string{"broker"}},
+}
+
 func main() {
-	ctx := context.Background()
-	container.StartApp(ctx)
+	cmd, args, err := parseCommand(os.Args[1:])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		usage()
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := container.StartApp(ctx, args, cmd.modules...); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// parseCommand picks the subcommand from the first argument. Without one, or
+// when the first argument is a flag, it runs everything.
+func parseCommand(args []string) (command, []string, error) {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return commands[0], args, nil
+	}
+	for _, cmd := range commands {
+		if cmd.name == args[0] {
+			return cmd, args[1:], nil
+		}
+	}
+	return command{}, nil, fmt.Errorf("unknown command %q", args[0])
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: %s [command] [-config file] [-section.key=value ...]\n\ncommands:\n", os.Args[0])
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-12s %s\n", cmd.name, cmd.usage)
+	}
 }
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseCommand(t *testing.T) {
+	for _, tc := range []struct {
+		args    []string
+		name    string
+		modules []string
+		rest    []string
+	}{
+		{nil, "all", nil, nil},
+		{[]string{"-config", "app.yaml"}, "all", nil, []string{"-config", "app.yaml"}},
+		{[]string{"all", "-log.level=debug"}, "all", nil, []string{"-log.level=debug"}},
+		{[]string{"serve-http"}, "serve-http", []string{"http"}, []string{}},
+		{[]string{"serve-grpc", "-grpc.port=9091"}, "serve-grpc", []string{"grpc"}, []string{"-grpc.port=9091"}},
+		{[]string{"worker", "-config", "worker.yaml"}, "worker", []string{"broker"}, []string{"-config", "worker.yaml"}},
+	} {
+		t.Run(strings.Join(tc.args, " "), func(t *testing.T) {
+			cmd, rest, err := parseCommand(tc.args)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cmd.name != tc.name || !slices.Equal(cmd.modules, tc.modules) || !slices.Equal(rest, tc.rest) {
+				t.Errorf("got %s %v %q, want %s %v %q", cmd.name, cmd.modules, rest, tc.name, tc.modules, tc.rest)
+			}
+		})
+	}
+
+	if _, _, err := parseCommand([]string{"serve"}); err == nil || !strings.Contains(err.Error(), `unknown command "serve"`) {
+		t.Errorf("unknown command: err = %v", err)
+	}
+}
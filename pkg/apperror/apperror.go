@@ -0,0 +1,164 @@
+// Package apperror defines the domain errors shared by every transport. A
+// usecase returns an *Error and the HTTP, gRPC and broker layers map its Kind
+// to their own status codes, so the mapping lives in one place.
+package apperror
+
+import (
+	"errors"
+	"maps"
+)
+
+type Kind int
+
+const (
+	// KindInternal is any error that is not an *Error.
+	KindInternal Kind = iota
+	KindNotFound
+	KindConflict
+	KindValidation
+	KindUnauthorized
+	KindForbidden
+	KindRateLimited
+	KindUnavailable
+	// KindUnsupportedMedia is a request body in an encoding the server does
+	// not read.
+	KindUnsupportedMedia
+	// KindNotAcceptable is a request for a representation the server cannot
+	// produce.
+	KindNotAcceptable
+)
+
+func (k Kind) String() string {
+	switch k {
+	case KindNotFound:
+		return "not_found"
+	case KindConflict:
+		return "conflict"
+	case KindValidation:
+		return "validation"
+	case KindUnauthorized:
+		return "unauthorized"
+	case KindForbidden:
+		return "forbidden"
+	case KindRateLimited:
+		return "rate_limited"
+	case KindUnavailable:
+		return "unavailable"
+	case KindUnsupportedMedia:
+		return "unsupported_media"
+	case KindNotAcceptable:
+		return "not_acceptable"
+	}
+	return "internal"
+}
+
+// Error is a domain error. Code is stable and meant for clients to branch
+// on, e.g. "user.not_found"; Message is for humans and may change.
+type Error struct {
+	Kind    Kind
+	Code    string
+	Message string
+	Details map[string]any
+	cause   error
+}
+
+func New(kind Kind, code, message string) *Error {
+	return &Error{Kind: kind, Code: code, Message: message}
+}
+
+func NotFound(code, message string) *Error {
+	return New(KindNotFound, code, message)
+}
+
+func Conflict(code, message string) *Error {
+	return New(KindConflict, code, message)
+}
+
+func Validation(code, message string) *Error {
+	return New(KindValidation, code, message)
+}
+
+func Unauthorized(code, message string) *Error {
+	return New(KindUnauthorized, code, message)
+}
+
+func Forbidden(code, message string) *Error {
+	return New(KindForbidden, code, message)
+}
+
+func RateLimited(code, message string) *Error {
+	return New(KindRateLimited, code, message)
+}
+
+func Unavailable(code, message string) *Error {
+	return New(KindUnavailable, code, message)
+}
+
+func UnsupportedMedia(code, message string) *Error {
+	return New(KindUnsupportedMedia, code, message)
+}
+
+func NotAcceptable(code, message string) *Error {
+	return New(KindNotAcceptable, code, message)
+}
+
+func (e *Error) Error() string {
+	if e.cause != nil {
+		return e.Code + ": " + e.Message + ": " + e.cause.Error()
+	}
+	return e.Code + ": " + e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.cause
+}
+
+// Is matches errors with the same Code, so a package level error stays
+// comparable with errors.Is after WithDetail or Wrap.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+// WithDetail returns a copy of e with key set in Details.
+func (e *Error) WithDetail(key string, value any) *Error {
+	cp := *e
+	cp.Details = maps.Clone(e.Details)
+	if cp.Details == nil {
+		cp.Details = map[string]any{}
+	}
+	cp.Details[key] = value
+	return &cp
+}
+
+// Wrap returns a copy of e caused by err. The cause is logged but never
+// sent to clients.
+func (e *Error) Wrap(err error) *Error {
+	cp := *e
+	cp.cause = err
+	return &cp
+}
+
+// From returns the *Error in err's chain, or an internal error wrapping err.
+func From(err error) *Error {
+	var e *Error
+	if errors.As(err, &e) {
+		return e
+	}
+	return New(KindInternal, "internal", "internal error").Wrap(err)
+}
+
+// KindOf returns the Kind of err, KindInternal for foreign errors.
+func KindOf(err error) Kind {
+	return From(err).Kind
+}
+
+// Retryable reports whether retrying the same operation may succeed.
+// Unknown errors are assumed transient.
+func Retryable(err error) bool {
+	switch KindOf(err) {
+	case KindInternal, KindRateLimited, KindUnavailable:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,66 @@
+package apperror
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+)
+
+func TestKindMapping(t *testing.T) {
+	for _, tc := range []struct {
+		kind      Kind
+		http      int
+		grpc      codes.Code
+		retryable bool
+	}{
+		{KindInternal, http.StatusInternalServerError, codes.Internal, true},
+		{KindNotFound, http.StatusNotFound, codes.NotFound, false},
+		{KindConflict, http.StatusConflict, codes.AlreadyExists, false},
+		{KindValidation, http.StatusBadRequest, codes.InvalidArgument, false},
+		{KindUnauthorized, http.StatusUnauthorized, codes.Unauthenticated, false},
+		{KindForbidden, http.StatusForbidden, codes.PermissionDenied, false},
+		{KindRateLimited, http.StatusTooManyRequests, codes.ResourceExhausted, true},
+		{KindUnavailable, http.StatusServiceUnavailable, codes.Unavailable, true},
+		{KindUnsupportedMedia, http.StatusUnsupportedMediaType, codes.InvalidArgument, false},
+		{KindNotAcceptable, http.StatusNotAcceptable, codes.InvalidArgument, false},
+	} {
+		t.Run(tc.kind.String(), func(t *testing.T) {
+			if got := HTTPStatus(tc.kind); got != tc.http {
+				t.Errorf("HTTPStatus = %d, want %d", got, tc.http)
+			}
+			if got := GRPCCode(tc.kind); got != tc.grpc {
+				t.Errorf("GRPCCode = %s, want %s", got, tc.grpc)
+			}
+			if got := Retryable(New(tc.kind, "code", "message")); got != tc.retryable {
+				t.Errorf("Retryable = %v, want %v", got, tc.retryable)
+			}
+		})
+	}
+}
+
+func TestFrom(t *testing.T) {
+	notFound := NotFound("user.not_found", "user not found")
+
+	wrapped := fmt.Errorf("get user: %w", notFound.WithDetail("id", "u1"))
+	if e := From(wrapped); e.Kind != KindNotFound || e.Details["id"] != "u1" {
+		t.Errorf("From(wrapped) = %+v", e)
+	}
+	if !errors.Is(wrapped, notFound) {
+		t.Error("errors.Is does not match through WithDetail")
+	}
+	if notFound.Details != nil {
+		t.Errorf("WithDetail changed the original: %v", notFound.Details)
+	}
+
+	cause := errors.New("pq: connection reset")
+	e := From(cause)
+	if e.Kind != KindInternal || e.Code != "internal" || !errors.Is(e, cause) {
+		t.Errorf("From(foreign) = %+v", e)
+	}
+	if KindOf(cause) != KindInternal || !Retryable(cause) {
+		t.Error("foreign errors must be internal and retryable")
+	}
+}
@@ -0,0 +1,60 @@
+package apperror
+
+import (
+	"errors"
+	"fmt"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func GRPCCode(kind Kind) codes.Code {
+	switch kind {
+	case KindNotFound:
+		return codes.NotFound
+	case KindConflict:
+		return codes.AlreadyExists
+	case KindValidation, KindUnsupportedMedia, KindNotAcceptable:
+		return codes.InvalidArgument
+	case KindUnauthorized:
+		return codes.Unauthenticated
+	case KindForbidden:
+		return codes.PermissionDenied
+	case KindRateLimited:
+		return codes.ResourceExhausted
+	case KindUnavailable:
+		return codes.Unavailable
+	}
+	return codes.Internal
+}
+
+// GRPCStatus lets status.FromError, and so grpc-go itself, convert an *Error
+// returned by a handler. The code and details travel as an ErrorInfo.
+func (e *Error) GRPCStatus() *status.Status {
+	st := status.New(GRPCCode(e.Kind), e.Message)
+
+	info := &errdetails.ErrorInfo{Reason: e.Code, Metadata: map[string]string{}}
+	for k, v := range e.Details {
+		info.Metadata[k] = fmt.Sprint(v)
+	}
+	if withDetails, err := st.WithDetails(info); err == nil {
+		return withDetails
+	}
+	return st
+}
+
+// GRPCError converts any error returned by a handler to a status error,
+// hiding the message of internal errors.
+func GRPCError(err error) error {
+	if err == nil {
+		return nil
+	}
+	var e *Error
+	if errors.As(err, &e) {
+		return e.GRPCStatus().Err()
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	return From(err).GRPCStatus().Err()
+}
@@ -0,0 +1,62 @@
+package apperror
+
+import (
+	"errors"
+	"testing"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestGRPCError(t *testing.T) {
+	if GRPCError(nil) != nil {
+		t.Error("GRPCError(nil) != nil")
+	}
+
+	for _, tc := range []struct {
+		name    string
+		err     error
+		code    codes.Code
+		message string
+		reason  string
+		meta    map[string]string
+	}{
+		{"app error", Conflict("user.exists", "user exists").WithDetail("attempt", 2),
+			codes.AlreadyExists, "user exists", "user.exists", map[string]string{"attempt": "2"}},
+		{"wrapped app error", errors.Join(errors.New("context"), Forbidden("auth.forbidden", "forbidden")),
+			codes.PermissionDenied, "forbidden", "auth.forbidden", nil},
+		{"foreign error", errors.New("pq: connection reset"),
+			codes.Internal, "internal error", "internal", nil},
+		// status errors from a downstream call pass through untouched
+		{"status error", status.Error(codes.DeadlineExceeded, "upstream slow"),
+			codes.DeadlineExceeded, "upstream slow", "", nil},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			st := status.Convert(GRPCError(tc.err))
+			if st.Code() != tc.code || st.Message() != tc.message {
+				t.Fatalf("status = %s %q, want %s %q", st.Code(), st.Message(), tc.code, tc.message)
+			}
+			var info *errdetails.ErrorInfo
+			for _, d := range st.Details() {
+				if i, ok := d.(*errdetails.ErrorInfo); ok {
+					info = i
+				}
+			}
+			if tc.reason == "" {
+				if info != nil {
+					t.Errorf("unexpected ErrorInfo %v", info)
+				}
+				return
+			}
+			if info.GetReason() != tc.reason {
+				t.Errorf("reason = %q, want %q", info.GetReason(), tc.reason)
+			}
+			for k, v := range tc.meta {
+				if info.GetMetadata()[k] != v {
+					t.Errorf("metadata %s = %q, want %q", k, info.GetMetadata()[k], v)
+				}
+			}
+		})
+	}
+}
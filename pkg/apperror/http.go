@@ -0,0 +1,69 @@
+package apperror
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"service/pkg/logger"
+	"service/pkg/otel"
+	"service/pkg/requestid"
+)
+
+// ErrorResponse is the body of every HTTP error.
+type ErrorResponse struct {
+	Error ErrorBody `json:"error"`
+}
+
+type ErrorBody struct {
+	Code    string         `json:"code"`
+	Message string         `json:"message"`
+	Details map[string]any `json:"details,omitempty"`
+	TraceID string         `json:"trace_id"`
+}
+
+func HTTPStatus(kind Kind) int {
+	switch kind {
+	case KindNotFound:
+		return http.StatusNotFound
+	case KindConflict:
+		return http.StatusConflict
+	case KindValidation:
+		return http.StatusBadRequest
+	case KindUnauthorized:
+		return http.StatusUnauthorized
+	case KindForbidden:
+		return http.StatusForbidden
+	case KindRateLimited:
+		return http.StatusTooManyRequests
+	case KindUnavailable:
+		return http.StatusServiceUnavailable
+	case KindUnsupportedMedia:
+		return http.StatusUnsupportedMediaType
+	case KindNotAcceptable:
+		return http.StatusNotAcceptable
+	}
+	return http.StatusInternalServerError
+}
+
+// AbortHTTP writes err as the JSON error envelope and aborts the chain.
+// Internal errors are logged and their message is not exposed.
+func AbortHTTP(c *gin.Context, err error) {
+	if KindOf(err) == KindInternal {
+		logger.Logger.Error("request failed",
+			logger.F("error", err.Error()),
+			logger.F("path", c.FullPath()),
+			logger.F("trace_id", otel.GetTraceID(c.Request.Context())),
+			logger.F("request_id", requestid.From(c.Request.Context())))
+	}
+	WriteHTTP(c, err)
+}
+
+// WriteHTTP is AbortHTTP without logging, for callers that already logged.
+func WriteHTTP(c *gin.Context, err error) {
+	e := From(err)
+	c.AbortWithStatusJSON(HTTPStatus(e.Kind), ErrorResponse{Error: ErrorBody{
+		Code:    e.Code,
+		Message: e.Message,
+		Details: e.Details,
+		TraceID: otel.GetTraceID(c.Request.Context()),
+	}})
+}
@@ -0,0 +1,56 @@
+package apperror
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"service/pkg/logger"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap/zaptest"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+func TestWriteHTTP(t *testing.T) {
+	prev := logger.Logger
+	logger.Use(zaptest.NewLogger(t))
+	t.Cleanup(func() { logger.Logger = prev })
+
+	for _, tc := range []struct {
+		name   string
+		err    error
+		status int
+		body   ErrorBody
+	}{
+		{"app error", NotFound("user.not_found", "user not found").WithDetail("id", "u1"),
+			http.StatusNotFound, ErrorBody{Code: "user.not_found", Message: "user not found", Details: map[string]any{"id": "u1"}}},
+		{"cause stays on the server", Unavailable("database.unavailable", "try again").Wrap(errors.New("dial tcp: refused")),
+			http.StatusServiceUnavailable, ErrorBody{Code: "database.unavailable", Message: "try again"}},
+		{"foreign error", errors.New("pq: connection reset"),
+			http.StatusInternalServerError, ErrorBody{Code: "internal", Message: "internal error"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			r := gin.New()
+			r.GET("/", func(c *gin.Context) { AbortHTTP(c, tc.err) })
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			if rec.Code != tc.status {
+				t.Errorf("status = %d, want %d", rec.Code, tc.status)
+			}
+			var got ErrorResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+				t.Fatal(err)
+			}
+			if got.Error.Code != tc.body.Code || got.Error.Message != tc.body.Message ||
+				len(got.Error.Details) != len(tc.body.Details) || got.Error.Details["id"] != tc.body.Details["id"] {
+				t.Errorf("body = %s, want %+v", rec.Body, tc.body)
+			}
+		})
+	}
+}
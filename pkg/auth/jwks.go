@@ -0,0 +1,219 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"service/pkg/logger"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// minJWKSRefetch throttles refetches triggered by unknown kids so forged
+// tokens cannot hammer the JWKS source.
+const minJWKSRefetch = time.Minute
+
+// jwks caches the keys of a JWKS document, read from a file or fetched
+// over HTTP, and swaps them whole on every refresh. Lookups never wait on a
+// lock; concurrent refreshes share a single fetch.
+type jwks struct {
+	source  string
+	refresh time.Duration
+	client  *http.Client
+
+	set   atomic.Pointer[keySet]
+	fetch singleflight.Group
+}
+
+// keySet is an immutable snapshot of the document.
+type keySet struct {
+	keys    map[string]interface{}
+	fetched time.Time
+}
+
+func newJWKS(source string, refresh time.Duration) *jwks {
+	j := &jwks{
+		source:  source,
+		refresh: refresh,
+		client:  &http.Client{Timeout: 10 * time.Second},
+	}
+	j.set.Store(&keySet{})
+	return j
+}
+
+func (j *jwks) key(ctx context.Context, kid string) (interface{}, error) {
+	set := j.set.Load()
+
+	stale := set.keys == nil || (j.refresh > 0 && time.Since(set.fetched) > j.refresh)
+	if _, known := set.keys[kid]; !known && time.Since(set.fetched) > minJWKSRefetch {
+		stale = true
+	}
+	if stale {
+		// the fetch is shared, one caller going away must not fail the others
+		v, _, _ := j.fetch.Do(j.source, func() (interface{}, error) {
+			return j.load(context.WithoutCancel(ctx), set), nil
+		})
+		set = v.(*keySet)
+	}
+
+	if k, ok := set.keys[kid]; ok {
+		return k, nil
+	}
+	return nil, fmt.Errorf("unknown key id %q", kid)
+}
+
+// load fetches the document and swaps in the new key set, unless another
+// fetch already replaced seen. On failure the previous keys stay in use, with
+// the fetch time bumped to throttle retries.
+func (j *jwks) load(ctx context.Context, seen *keySet) *keySet {
+	prev := j.set.Load()
+	if prev != seen {
+		return prev
+	}
+
+	keys, err := j.parse(ctx)
+	if err != nil {
+		// keep serving the previous keys while the source is down
+		logger.Logger.Warn("failed to refresh jwks", logger.F("source", j.source), logger.F("error", err.Error()))
+		keys = prev.keys
+	}
+	next := &keySet{keys: keys, fetched: time.Now()}
+	j.set.Store(next)
+	return next
+}
+
+func (j *jwks) parse(ctx context.Context) (map[string]interface{}, error) {
+	raw, err := j.read(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return nil, fmt.Errorf("parse jwks: %w", err)
+	}
+
+	keys := make(map[string]interface{}, len(doc.Keys))
+	for _, k := range doc.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		pub, err := k.publicKey()
+		if err != nil {
+			logger.Logger.Warn("skipping jwks key", logger.F("kid", k.Kid), logger.F("error", err.Error()))
+			continue
+		}
+		keys[k.Kid] = pub
+	}
+	return keys, nil
+}
+
+func (j *jwks) read(ctx context.Context) ([]byte, error) {
+	if !strings.HasPrefix(j.source, "http://") && !strings.HasPrefix(j.source, "https://") {
+		return os.ReadFile(strings.TrimPrefix(j.source, "file://"))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, j.source, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := j.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetch jwks: status %d", resp.StatusCode)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+}
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	// RSA
+	N string `json:"n"`
+	E string `json:"e"`
+	// EC
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+	// symmetric
+	K string `json:"k"`
+}
+
+func (k jsonWebKey) publicKey() (interface{}, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		if k.Crv != "P-256" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}, nil
+	case "oct":
+		return base64.RawURLEncoding.DecodeString(k.K)
+	}
+	return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(raw), nil
+}
+
+func parsePublicKeyPEM(raw []byte) (crypto.PublicKey, error) {
+	block, _ := pem.Decode(raw)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+	if cert, err := x509.ParseCertificate(block.Bytes); err == nil {
+		return cert.PublicKey, nil
+	}
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parse public key: %w", err)
+	}
+	switch pub.(type) {
+	case *rsa.PublicKey, *ecdsa.PublicKey:
+		return pub, nil
+	}
+	return nil, fmt.Errorf("unsupported public key type %T", pub)
+}
@@ -0,0 +1,35 @@
+package auth
+
+import (
+	"context"
+	"service/pkg/apperror"
+)
+
+// ErrForbidden is returned when the caller lacks a permission, named under
+// the "permission" detail.
+var ErrForbidden = apperror.Forbidden("auth.forbidden", "missing permission")
+
+// PermissionChecker reports whether a user holds a permission.
+type PermissionChecker interface {
+	HasPermission(ctx context.Context, userID, permission string) (bool, error)
+}
+
+// Require returns nil when the caller authenticated for ctx has every listed
+// permission, ErrMissingToken when nobody is authenticated and ErrForbidden
+// otherwise.
+func Require(ctx context.Context, checker PermissionChecker, permissions ...string) error {
+	principal, ok := PrincipalFrom(ctx)
+	if !ok {
+		return ErrMissingToken
+	}
+	for _, permission := range permissions {
+		allowed, err := checker.HasPermission(ctx, principal.Subject, permission)
+		if err != nil {
+			return err
+		}
+		if !allowed {
+			return ErrForbidden.WithDetail("permission", permission)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+// Package auth verifies bearer tokens and carries the authenticated
+// principal through the request context.
+package auth
+
+import (
+	"context"
+	"slices"
+)
+
+// Principal is the authenticated caller.
+type Principal struct {
+	Subject string
+	Email   string
+	Roles   []string
+	Scopes  []string
+	// Token is the raw bearer token, for calls made on the caller's behalf.
+	Token string
+}
+
+func (p *Principal) HasRole(role string) bool {
+	return slices.Contains(p.Roles, role)
+}
+
+type ctxKey struct{}
+
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, ctxKey{}, p)
+}
+
+// PrincipalFrom returns the caller authenticated for ctx, if any.
+func PrincipalFrom(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(ctxKey{}).(*Principal)
+	return p, ok && p != nil
+}
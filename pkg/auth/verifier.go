@@ -0,0 +1,144 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"errors"
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+	"service/config"
+	"service/pkg/apperror"
+	"service/pkg/logger"
+	"strings"
+	"sync/atomic"
+)
+
+var (
+	ErrMissingToken = apperror.Unauthorized("auth.missing_token", "bearer token required")
+	ErrInvalidToken = apperror.Unauthorized("auth.invalid_token", "invalid bearer token")
+)
+
+type claims struct {
+	jwt.RegisteredClaims
+	Email string   `json:"email"`
+	Roles []string `json:"roles"`
+	Scope string   `json:"scope"`
+}
+
+// Verifier validates JWTs against a static key and/or a JWKS document.
+type Verifier struct {
+	parser *jwt.Parser
+	secret config.Secret
+	static atomic.Pointer[staticKey]
+	jwks   *jwks
+}
+
+// staticKey is auth.key parsed from raw. A rotated secret is parsed again on
+// the next verification.
+type staticKey struct {
+	raw     string
+	public  crypto.PublicKey
+	hmacKey []byte
+}
+
+func parseStaticKey(raw string) (*staticKey, error) {
+	k := &staticKey{raw: raw}
+	switch {
+	case raw == "":
+	case strings.Contains(raw, "-----BEGIN"):
+		pub, err := parsePublicKeyPEM([]byte(raw))
+		if err != nil {
+			return nil, fmt.Errorf("auth.key: %w", err)
+		}
+		k.public = pub
+	default:
+		k.hmacKey = []byte(raw)
+	}
+	return k, nil
+}
+
+func NewVerifier(cfg *config.Auth) (*Verifier, error) {
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods(cfg.Algorithms),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(cfg.Leeway),
+	}
+	if cfg.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(cfg.Issuer))
+	}
+	if cfg.Audience != "" {
+		opts = append(opts, jwt.WithAudience(cfg.Audience))
+	}
+
+	v := &Verifier{parser: jwt.NewParser(opts...), secret: cfg.Key}
+
+	key, err := parseStaticKey(cfg.Key.Value())
+	if err != nil {
+		return nil, err
+	}
+	v.static.Store(key)
+	if cfg.JWKS != "" {
+		v.jwks = newJWKS(cfg.JWKS, cfg.JWKSRefresh)
+	}
+	return v, nil
+}
+
+// Verify parses and validates a raw token. Any failure is ErrInvalidToken
+// with the reason kept as its cause.
+func (v *Verifier) Verify(ctx context.Context, raw string) (*Principal, error) {
+	c := &claims{}
+	_, err := v.parser.ParseWithClaims(raw, c, func(t *jwt.Token) (interface{}, error) {
+		return v.key(ctx, t)
+	})
+	if err != nil {
+		return nil, ErrInvalidToken.Wrap(err)
+	}
+
+	p := &Principal{
+		Subject: c.Subject,
+		Email:   c.Email,
+		Roles:   c.Roles,
+		Token:   raw,
+	}
+	if c.Scope != "" {
+		p.Scopes = strings.Fields(c.Scope)
+	}
+	return p, nil
+}
+
+func (v *Verifier) key(ctx context.Context, t *jwt.Token) (interface{}, error) {
+	if kid, _ := t.Header["kid"].(string); kid != "" && v.jwks != nil {
+		return v.jwks.key(ctx, kid)
+	}
+
+	key := v.staticKey()
+	switch t.Method.(type) {
+	case *jwt.SigningMethodHMAC:
+		if key.hmacKey != nil {
+			return key.hmacKey, nil
+		}
+	default:
+		if key.public != nil {
+			return key.public, nil
+		}
+	}
+	return nil, errors.New("no key configured for " + t.Method.Alg())
+}
+
+// staticKey returns auth.key, parsing it again when the secret was rotated.
+// A rotated value that does not parse keeps the previous key in use.
+func (v *Verifier) staticKey() *staticKey {
+	key := v.static.Load()
+	raw := v.secret.Value()
+	if raw == key.raw {
+		return key
+	}
+	next, err := parseStaticKey(raw)
+	if err != nil {
+		logger.Logger.Warn("ignoring rotated auth key", logger.F("error", err.Error()))
+		// remember the bad value so it is not parsed on every request
+		next = &staticKey{raw: raw, public: key.public, hmacKey: key.hmacKey}
+	}
+	v.static.CompareAndSwap(key, next)
+	return next
+}
@@ -0,0 +1,416 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"service/config"
+	"service/pkg/logger"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"go.uber.org/zap/zaptest"
+)
+
+func useTestLogger(t *testing.T) {
+	prev := logger.Logger
+	logger.Use(zaptest.NewLogger(t))
+	t.Cleanup(func() { logger.Logger = prev })
+}
+
+func testConfig() config.Auth {
+	cfg := config.NewConfig().Auth
+	cfg.Algorithms = []string{"HS256", "RS256", "ES256"}
+	cfg.Issuer = "https://issuer.test"
+	cfg.Audience = "service"
+	cfg.Leeway = 0
+	return cfg
+}
+
+func validClaims() jwt.MapClaims {
+	return jwt.MapClaims{
+		"iss":   "https://issuer.test",
+		"aud":   "service",
+		"sub":   "user-1",
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"email": "user@example.com",
+		"scope": "users:read users:write",
+	}
+}
+
+func sign(t *testing.T, method jwt.SigningMethod, key interface{}, kid string, c jwt.MapClaims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(method, c)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	raw, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
+
+func newRSAKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func publicPEM(t *testing.T, pub interface{}) string {
+	t.Helper()
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+}
+
+func TestVerifyStaticKey(t *testing.T) {
+	rsaKey := newRSAKey(t)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name   string
+		key    string
+		method jwt.SigningMethod
+		sign   interface{}
+	}{
+		{"HS256", "shared-secret", jwt.SigningMethodHS256, []byte("shared-secret")},
+		{"RS256", publicPEM(t, &rsaKey.PublicKey), jwt.SigningMethodRS256, rsaKey},
+		{"ES256", publicPEM(t, &ecKey.PublicKey), jwt.SigningMethodES256, ecKey},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := testConfig()
+			cfg.Key = config.NewSecret(tc.key)
+			v, err := NewVerifier(&cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			p, err := v.Verify(context.Background(), sign(t, tc.method, tc.sign, "", validClaims()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if p.Subject != "user-1" || p.Email != "user@example.com" || len(p.Scopes) != 2 {
+				t.Errorf("principal = %+v", p)
+			}
+		})
+	}
+}
+
+func TestVerifyRejects(t *testing.T) {
+	cfg := testConfig()
+	cfg.Key = config.NewSecret("shared-secret")
+	v, err := NewVerifier(&cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other := newRSAKey(t)
+
+	for _, tc := range []struct {
+		name   string
+		change func(c jwt.MapClaims)
+		token  func(c jwt.MapClaims) string
+		cause  error
+	}{
+		{
+			name:   "expired",
+			change: func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Minute).Unix() },
+			cause:  jwt.ErrTokenExpired,
+		},
+		{
+			name:   "missing expiry",
+			change: func(c jwt.MapClaims) { delete(c, "exp") },
+			cause:  jwt.ErrTokenRequiredClaimMissing,
+		},
+		{
+			name:   "wrong audience",
+			change: func(c jwt.MapClaims) { c["aud"] = "another-service" },
+			cause:  jwt.ErrTokenInvalidAudience,
+		},
+		{
+			name:   "wrong issuer",
+			change: func(c jwt.MapClaims) { c["iss"] = "https://evil.test" },
+			cause:  jwt.ErrTokenInvalidIssuer,
+		},
+		{
+			name:  "wrong secret",
+			token: func(c jwt.MapClaims) string { return sign(t, jwt.SigningMethodHS256, []byte("guess"), "", c) },
+			cause: jwt.ErrTokenSignatureInvalid,
+		},
+		{
+			name:  "no key for the algorithm",
+			token: func(c jwt.MapClaims) string { return sign(t, jwt.SigningMethodRS256, other, "", c) },
+			cause: jwt.ErrTokenUnverifiable,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			c := validClaims()
+			if tc.change != nil {
+				tc.change(c)
+			}
+			raw := sign(t, jwt.SigningMethodHS256, []byte("shared-secret"), "", c)
+			if tc.token != nil {
+				raw = tc.token(c)
+			}
+			_, err := v.Verify(context.Background(), raw)
+			if !errors.Is(err, ErrInvalidToken) {
+				t.Fatalf("err = %v, want ErrInvalidToken", err)
+			}
+			if !errors.Is(err, tc.cause) {
+				t.Errorf("err = %v, want cause %v", err, tc.cause)
+			}
+		})
+	}
+}
+
+func TestStaticKeyRotation(t *testing.T) {
+	useTestLogger(t)
+	path := filepath.Join(t.TempDir(), "jwt.key")
+	if err := os.WriteFile(path, []byte("old-secret\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("SERVICE_AUTH_KEY", "file://"+path)
+	t.Setenv("SERVICE_AUTH_ALGORITHMS", "HS256")
+	loader := config.NewLoader()
+	cfg, sources, err := loader.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := config.NewStore(loader, cfg, sources)
+	v, err := NewVerifier(&store.Get().Auth)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims := validClaims()
+	delete(claims, "iss")
+	delete(claims, "aud")
+	if _, err := v.Verify(context.Background(), sign(t, jwt.SigningMethodHS256, []byte("old-secret"), "", claims)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(path, []byte("new-secret\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go store.RefreshSecrets(ctx, 5*time.Millisecond)
+
+	rotated := sign(t, jwt.SigningMethodHS256, []byte("new-secret"), "", claims)
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if _, err = v.Verify(context.Background(), rotated); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("rotated key not picked up: %v", err)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if _, err := v.Verify(context.Background(), sign(t, jwt.SigningMethodHS256, []byte("old-secret"), "", claims)); err == nil {
+		t.Error("old key still accepted after rotation")
+	}
+}
+
+// jwksServer serves the public halves of keys as a JWKS document and counts
+// the fetches.
+type jwksServer struct {
+	*httptest.Server
+	mu      sync.Mutex
+	keys    map[string]*rsa.PrivateKey
+	fetches atomic.Int32
+	delay   time.Duration
+	// hold, when set, stalls fetches until it is closed
+	hold chan struct{}
+}
+
+func newJWKSServer(t *testing.T) *jwksServer {
+	s := &jwksServer{keys: map[string]*rsa.PrivateKey{}}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.fetches.Add(1)
+		time.Sleep(s.delay)
+		if s.hold != nil {
+			<-s.hold
+		}
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		doc := struct {
+			Keys []jsonWebKey `json:"keys"`
+		}{}
+		for kid, key := range s.keys {
+			doc.Keys = append(doc.Keys, jsonWebKey{
+				Kty: "RSA",
+				Kid: kid,
+				Use: "sig",
+				N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			})
+		}
+		_ = json.NewEncoder(w).Encode(doc)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *jwksServer) add(kid string, key *rsa.PrivateKey) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys[kid] = key
+}
+
+func TestJWKSRotation(t *testing.T) {
+	useTestLogger(t)
+	server := newJWKSServer(t)
+	first := newRSAKey(t)
+	server.add("k1", first)
+
+	cfg := testConfig()
+	cfg.JWKS = server.URL
+	v, err := NewVerifier(&cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if _, err := v.Verify(ctx, sign(t, jwt.SigningMethodRS256, first, "k1", validClaims())); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := server.fetches.Load(); n != 1 {
+		t.Errorf("%d fetches, want the keys cached after the first", n)
+	}
+
+	second := newRSAKey(t)
+	server.add("k2", second)
+	rotated := sign(t, jwt.SigningMethodRS256, second, "k2", validClaims())
+
+	// unknown kids refetch at most once per minJWKSRefetch
+	if _, err := v.Verify(ctx, rotated); err == nil {
+		t.Fatal("unknown kid accepted right after a fetch")
+	}
+	if n := server.fetches.Load(); n != 1 {
+		t.Errorf("%d fetches, want the refetch throttled", n)
+	}
+
+	set := v.jwks.set.Load()
+	v.jwks.set.Store(&keySet{keys: set.keys, fetched: set.fetched.Add(-2 * minJWKSRefetch)})
+	if _, err := v.Verify(ctx, rotated); err != nil {
+		t.Fatalf("rotated key rejected: %v", err)
+	}
+	if n := server.fetches.Load(); n != 2 {
+		t.Errorf("%d fetches, want an unknown kid to refetch", n)
+	}
+
+	// the source going down keeps the cached keys
+	server.Close()
+	set = v.jwks.set.Load()
+	v.jwks.set.Store(&keySet{keys: set.keys, fetched: set.fetched.Add(-2 * cfg.JWKSRefresh)})
+	if _, err := v.Verify(ctx, rotated); err != nil {
+		t.Errorf("cached key rejected while the source is down: %v", err)
+	}
+}
+
+func TestJWKSConcurrentRefetch(t *testing.T) {
+	useTestLogger(t)
+	server := newJWKSServer(t)
+	key := newRSAKey(t)
+	server.add("k1", key)
+	server.delay = 50 * time.Millisecond
+
+	cfg := testConfig()
+	cfg.JWKS = server.URL
+	v, err := NewVerifier(&cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw := sign(t, jwt.SigningMethodRS256, key, "k1", validClaims())
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+	for i := 0; i < cap(errs); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := v.Verify(context.Background(), raw)
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Error(err)
+		}
+	}
+	if n := server.fetches.Load(); n != 1 {
+		t.Errorf("%d fetches, want concurrent callers to share one", n)
+	}
+}
+
+func TestJWKSLookupsDoNotWaitForFetch(t *testing.T) {
+	useTestLogger(t)
+	server := newJWKSServer(t)
+	key := newRSAKey(t)
+	server.add("k1", key)
+
+	cfg := testConfig()
+	cfg.JWKS = server.URL
+	v, err := NewVerifier(&cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	known := sign(t, jwt.SigningMethodRS256, key, "k1", validClaims())
+	if _, err := v.Verify(ctx, known); err != nil {
+		t.Fatal(err)
+	}
+
+	// an unknown kid starts a fetch that stalls
+	set := v.jwks.set.Load()
+	v.jwks.set.Store(&keySet{keys: set.keys, fetched: set.fetched.Add(-2 * minJWKSRefetch)})
+	server.hold = make(chan struct{})
+	defer close(server.hold)
+	go func() {
+		_, _ = v.Verify(ctx, sign(t, jwt.SigningMethodRS256, key, "unknown", validClaims()))
+	}()
+	for server.fetches.Load() < 2 {
+		time.Sleep(time.Millisecond)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := v.Verify(ctx, known)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Error(err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("known kid waited for the fetch in flight")
+	}
+}
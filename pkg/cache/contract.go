@@ -1,9 +1,22 @@
 package cache
 
-import "context"
+import (
+	"context"
+	"service/pkg/datastore/redis"
+)
 
+// ErrMiss is returned by Get when the key does not exist.
+var ErrMiss = redis.ErrMiss
+
+// ErrLocked is returned by Lock when the lock could not be acquired in time.
+var ErrLocked = redis.ErrLocked
+
+// ICache stores values as JSON.
 type ICache interface {
 	Get(ctx context.Context, key string, dest interface{}) error
 	Set(ctx context.Context, key string, data interface{}, second int) error
+	Delete(ctx context.Context, keys ...string) error
 	Lock(ctx context.Context, key string, ttl int64, proses func(ctx context.Context) error) error
+	Ping(ctx context.Context) error
+	Close() error
 }
@@ -2,17 +2,16 @@ package cache
 
 import (
 	"context"
-	"log"
+	"fmt"
 	"service/config"
 	"service/pkg/datastore/redis"
 )
 
-func NewCache(ctx context.Context, cfg *config.Config) ICache {
-	if cfg.Cache.Driver == "redis" {
+func NewCache(ctx context.Context, cfg *config.Config) (ICache, error) {
+	if cfg.Cache.Driver == config.CacheDriverRedis {
 
-		return redis.NewRedis(ctx, cfg)
+		return redis.NewRedis(ctx, cfg), nil
 	}
 
-	log.Fatal("not support cache driver")
-	return nil
+	return nil, fmt.Errorf("not support cache driver: %s", cfg.Cache.Driver)
 }
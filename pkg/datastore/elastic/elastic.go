@@ -2,12 +2,12 @@ package elastic
 
 import (
 	"context"
+	"fmt"
 	"github.com/elastic/go-elasticsearch/v9"
-	"log"
 	"service/config"
 )
 
-func NewElasticClient(ctx context.Context, cfg *config.Config) *elasticsearch.Client {
+func NewElasticClient(ctx context.Context, cfg *config.Config) (*elasticsearch.Client, error) {
 	elasticCfg := elasticsearch.Config{
 		Addresses: []string{
 			cfg.Elastic.Host,
@@ -18,8 +18,22 @@ func NewElasticClient(ctx context.Context, cfg *config.Config) *elasticsearch.Cl
 	es, err := elasticsearch.NewClient(elasticCfg)
 
 	if err != nil {
-		log.Fatal("failed to connect to elasticsearch:")
+		return nil, fmt.Errorf("failed to create elasticsearch client: %w", err)
 	}
 
-	return es
+	return es, nil
+}
+
+// Ping checks that the cluster answers.
+func Ping(ctx context.Context, es *elasticsearch.Client) error {
+	res, err := es.Ping(es.Ping.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return fmt.Errorf("elasticsearch ping: %s", res.Status())
+	}
+	return nil
 }
@@ -1,5 +1,7 @@
 package elastic
 
+import "encoding/json"
+
 type QueryRequest struct {
 	Must          []map[string]interface{} `json:"must,omitempty"`
 	MustNot       []map[string]interface{} `json:"must_not,omitempty"`
@@ -11,6 +13,7 @@ type QueryRequest struct {
 	Aggeregations map[string]interface{}   `json:"aggregations,omitempty"`
 	Fields        map[string]interface{}   `json:"fields,omitempty"`
 	ScriptFields  map[string]interface{}   `json:"script_fields,omitempty"`
+	SearchAfter   []interface{}            `json:"search_after,omitempty"`
 }
 
 type QueryFunction struct {
@@ -43,3 +46,15 @@ type QueryRequestRange struct {
 	GTE      string
 	LTE      string
 }
+
+// SearchResponse is the part of a search response Search decodes.
+type SearchResponse struct {
+	Hits struct {
+		Total struct {
+			Value int64 `json:"value"`
+		} `json:"total"`
+		Hits []struct {
+			Source json.RawMessage `json:"_source"`
+		} `json:"hits"`
+	} `json:"hits"`
+}
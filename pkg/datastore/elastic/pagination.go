@@ -0,0 +1,42 @@
+package elastic
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"service/pkg/pagination"
+)
+
+// Paginate sets the sort and search_after of jsonData for req and returns
+// the from and size to query with.
+func (elastic *Elastic) Paginate(jsonData *QueryRequest, req pagination.Request) (start int, size int) {
+	jsonData.Sort = nil
+	for _, s := range req.QuerySort() {
+		order := "asc"
+		if s.Desc {
+			order = "desc"
+		}
+		jsonData.Sort = append(jsonData.Sort, map[string]interface{}{s.Field: order})
+	}
+	jsonData.SearchAfter = req.After
+	return req.Offset(), req.FetchLimit()
+}
+
+// Search runs jsonData paginated by req and decodes the hits into T. The
+// total is the number of matching documents.
+func Search[T any](ctx context.Context, elastic *Elastic, jsonData *QueryRequest, req pagination.Request) ([]T, int64, error) {
+	start, size := elastic.Paginate(jsonData, req)
+	res := SearchResponse{}
+	if err := elastic.searchDataPagination(ctx, elastic.generateQuery(jsonData, start, size), &res); err != nil {
+		return nil, 0, err
+	}
+	rows := make([]T, 0, len(res.Hits.Hits))
+	for _, hit := range res.Hits.Hits {
+		var row T
+		if err := json.Unmarshal(hit.Source, &row); err != nil {
+			return nil, 0, fmt.Errorf("error decode hit: %s", err)
+		}
+		rows = append(rows, row)
+	}
+	return rows, res.Hits.Total.Value, nil
+}
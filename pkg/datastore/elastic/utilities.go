@@ -207,6 +207,9 @@ func (elastic *Elastic) generateQuery(json_objects *QueryRequest, start int, siz
 	if len(json_objects.ScriptFields) != 0 {
 		output_map_query["script_fields"] = json_objects.ScriptFields
 	}
+	if len(json_objects.SearchAfter) != 0 {
+		output_map_query["search_after"] = json_objects.SearchAfter
+	}
 
 	return output_map_query
 }
@@ -2,39 +2,45 @@ package mongodb
 
 import (
 	"context"
+	"fmt"
 	"go.mongodb.org/mongo-driver/event"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
 	"log"
 	"service/config"
-	"strconv"
-	"strings"
 	"time"
 )
 
-func NewMongodb(ctx context.Context, cfg *config.Config) *mongo.Client {
+// NewMongodb creates a client. The driver connects lazily, use Ping to check
+// that the server is reachable.
+func NewMongodb(ctx context.Context, cfg *config.Config) (*mongo.Client, error) {
 	var err error
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 	mongoOptions := options.Client().ApplyURI(cfg.Mongodb.Url)
-	maxPoolConn, _ := strconv.ParseUint(cfg.Mongodb.MaxPoolConn, 10, 64)
-	if maxPoolConn != 0 {
-		mongoOptions.SetMaxPoolSize(maxPoolConn)
+	if cfg.Mongodb.Username != "" {
+		// the driver has no callback for the password, a rotated one is
+		// used after a restart
+		mongoOptions.SetAuth(options.Credential{
+			Username: cfg.Mongodb.Username,
+			Password: cfg.Mongodb.Password.Value(),
+		})
 	}
-	maxIdleConn, _ := strconv.ParseUint(cfg.Mongodb.MaxIdleConn, 10, 64)
-	if maxIdleConn != 0 {
-		duration := (time.Duration(maxPoolConn) * time.Hour)
-		mongoOptions.SetMaxConnIdleTime(duration)
+	if cfg.Mongodb.MaxPoolConn != 0 {
+		mongoOptions.SetMaxPoolSize(cfg.Mongodb.MaxPoolConn)
+	}
+	if cfg.Mongodb.MaxConnIdleTime != 0 {
+		mongoOptions.SetMaxConnIdleTime(cfg.Mongodb.MaxConnIdleTime)
 	}
 
-	if cfg.Mongodb.Compression != "" {
-		compression := strings.Split(",", cfg.Mongodb.Compression)
-		mongoOptions.Compressors = compression
+	if len(cfg.Mongodb.Compression) != 0 {
+		mongoOptions.SetCompressors(cfg.Mongodb.Compression)
 	}
 
 	//.SetMaxPoolSize(50).SetCompressors([]string{"snappy"})
-	if cfg.App.Environment == "development" || cfg.App.Environment == "staging" {
+	if cfg.App.Environment == config.EnvDevelopment || cfg.App.Environment == config.EnvStaging {
 		cmdMonitor := &event.CommandMonitor{
 			Started: func(_ context.Context, evt *event.CommandStartedEvent) {
 				log.Print(evt.Command)
@@ -46,8 +52,13 @@ func NewMongodb(ctx context.Context, cfg *config.Config) *mongo.Client {
 	client, err := mongo.Connect(ctx, mongoOptions)
 
 	if err != nil {
-		log.Fatalf("failed to connect to mongodb: %s", err.Error())
+		return nil, fmt.Errorf("failed to connect to mongodb: %w", err)
 	}
 
-	return client
+	return client, nil
+}
+
+// Ping checks that the primary answers.
+func Ping(ctx context.Context, client *mongo.Client) error {
+	return client.Ping(ctx, readpref.Primary())
 }
@@ -0,0 +1,52 @@
+package mongodb
+
+import (
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"service/pkg/pagination"
+)
+
+// Paginate adds the cursor seek to filter and returns the find options for
+// req. Count the total with the original filter.
+func Paginate(filter bson.M, req pagination.Request) (bson.M, *options.FindOptions) {
+	sort := req.QuerySort()
+	order := bson.D{}
+	for _, s := range sort {
+		direction := 1
+		if s.Desc {
+			direction = -1
+		}
+		order = append(order, bson.E{Key: s.Field, Value: direction})
+	}
+	opts := options.Find().
+		SetSort(order).
+		SetSkip(int64(req.Offset())).
+		SetLimit(int64(req.FetchLimit()))
+
+	if req.After == nil {
+		return filter, opts
+	}
+	seek := seek(sort, req.After)
+	if len(filter) == 0 {
+		return seek, opts
+	}
+	return bson.M{"$and": bson.A{filter, seek}}, opts
+}
+
+// seek matches the documents after values in sort order, see orm.Paginate.
+func seek(sort []pagination.Sort, values []any) bson.M {
+	or := make(bson.A, len(sort))
+	for i, s := range sort {
+		and := bson.M{}
+		for j := 0; j < i; j++ {
+			and[sort[j].Field] = values[j]
+		}
+		op := "$gt"
+		if s.Desc {
+			op = "$lt"
+		}
+		and[s.Field] = bson.M{op: values[i]}
+		or[i] = and
+	}
+	return bson.M{"$or": or}
+}
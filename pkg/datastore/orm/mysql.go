@@ -1,54 +1,67 @@
 package orm
 
 import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
 	"fmt"
+	gomysql "github.com/go-sql-driver/mysql"
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
-	"log"
+	"net"
 	"service/config"
 	"strconv"
 	"time"
 )
 
-func newMysql(dbCfg *config.Database) *Orm {
-	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=Local",
-		dbCfg.User,
-		dbCfg.Password,
-		dbCfg.Host,
-		dbCfg.Port,
-		dbCfg.Name)
-	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{})
+func newMysql(dbCfg *config.Database) (*Orm, error) {
+	db, err := gorm.Open(mysql.New(mysql.Config{Conn: sql.OpenDB(mysqlConnector{cfg: dbCfg})}), &gorm.Config{
+		DisableAutomaticPing: true,
+		TranslateError:       true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database mysql: %w", err)
+	}
 
 	// Access the raw *sql.DB object
 	sqlDB, err := db.DB()
 	if err != nil {
-		fmt.Println("Error getting *sql.DB object:", err)
-
+		return nil, fmt.Errorf("failed to get *sql.DB object: %w", err)
 	}
 
-	maxOpenConn, _ := strconv.Atoi(dbCfg.MaxOpenConn)
-	maxIdleConn, _ := strconv.Atoi(dbCfg.MaxIdleConn)
-	connMaxLifetime, _ := strconv.Atoi(dbCfg.MaxConnLifetime)
+	configurePool(sqlDB, dbCfg)
 
-	// Configure connection pooling
-	if maxIdleConn != 0 {
-		// Maximum number of open connections
-		sqlDB.SetMaxOpenConns(maxOpenConn)
-	}
-
-	if maxIdleConn != 0 {
-		// Maximum number of open connections
-		sqlDB.SetMaxIdleConns(maxIdleConn)
-	}
+	return &Orm{db: db}, nil
+}
 
-	if connMaxLifetime != 0 {
-		// Maximum amount of time a connection can be reused (0 means no limit)
-		sqlDB.SetConnMaxLifetime(time.Duration(connMaxLifetime) * time.Second)
-	}
-	// Maximum number of idle connections
+// mysqlConnector reads the password for every new connection, so rotated
+// credentials are used once the pool opens another connection.
+type mysqlConnector struct {
+	cfg *config.Database
+}
 
+func (c mysqlConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	connector, err := gomysql.NewConnector(c.config())
 	if err != nil {
-		log.Fatal(fmt.Sprintf("failed to connect database mysql: %s", err.Error()))
+		return nil, err
 	}
-	return &Orm{db: db}
+	return connector.Connect(ctx)
+}
+
+func (c mysqlConnector) Driver() driver.Driver {
+	return &gomysql.MySQLDriver{}
+}
+
+// config returns the driver config with the current password.
+func (c mysqlConnector) config() *gomysql.Config {
+	dsn := gomysql.NewConfig()
+	dsn.User = c.cfg.User
+	dsn.Passwd = c.cfg.Password.Value()
+	dsn.Net = "tcp"
+	dsn.Addr = net.JoinHostPort(c.cfg.Host, strconv.Itoa(c.cfg.Port))
+	dsn.DBName = c.cfg.Name
+	dsn.Params = map[string]string{"charset": "utf8mb4"}
+	dsn.ParseTime = true
+	dsn.Loc = time.Local
+	return dsn
 }
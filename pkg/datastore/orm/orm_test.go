@@ -0,0 +1,132 @@
+package orm
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"service/config"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// loadRotating loads the config with the database password read from the
+// DB_PASS environment variable and returns a func that rotates it.
+func loadRotating(t *testing.T, password string) (*config.Config, func(password string)) {
+	t.Helper()
+	t.Setenv("DB_PASS", password)
+	t.Setenv("SERVICE_DATABASE_PASSWORD", "env://DB_PASS")
+	loader := config.NewLoader()
+	cfg, sources, err := loader.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := config.NewStore(loader, cfg, sources)
+	current := store.Get()
+
+	return current, func(password string) {
+		t.Helper()
+		t.Setenv("DB_PASS", password)
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		go store.RefreshSecrets(ctx, time.Millisecond)
+		for current.Database.Password.Value() != password {
+			if ctx.Err() != nil {
+				t.Fatal("secret not refreshed")
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+}
+
+// fakePostgres asks every client for a cleartext password, sends it on
+// the returned channel and hangs up.
+func fakePostgres(t *testing.T) (host string, port int, passwords <-chan string) {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = lis.Close() })
+
+	out := make(chan string, 10)
+	go func() {
+		for {
+			conn, err := lis.Accept()
+			if err != nil {
+				return
+			}
+			r := bufio.NewReader(conn)
+			var size int32
+			// startup message: length, protocol and parameters
+			if binary.Read(r, binary.BigEndian, &size) != nil {
+				_ = conn.Close()
+				continue
+			}
+			_, _ = io.CopyN(io.Discard, r, int64(size-4))
+			// AuthenticationCleartextPassword
+			_, _ = conn.Write([]byte{'R', 0, 0, 0, 8, 0, 0, 0, 3})
+			// PasswordMessage: 'p', length, password and a NUL
+			if tag, err := r.ReadByte(); err == nil && tag == 'p' {
+				_ = binary.Read(r, binary.BigEndian, &size)
+				body := make([]byte, size-4)
+				_, _ = io.ReadFull(r, body)
+				out <- strings.TrimRight(string(body), "\x00")
+			}
+			_ = conn.Close()
+		}
+	}()
+
+	addr := lis.Addr().(*net.TCPAddr)
+	return addr.IP.String(), addr.Port, out
+}
+
+func TestPostgresReadsRotatedPassword(t *testing.T) {
+	cfg, rotate := loadRotating(t, "first")
+	host, port, passwords := fakePostgres(t)
+	cfg.Database.Driver = config.DriverPostgres
+	cfg.Database.Host = host
+	cfg.Database.Port = port
+
+	db, err := NewProvider(&cfg.Database)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	for _, want := range []string{"first", "second"} {
+		if want != "first" {
+			rotate(want)
+		}
+		// the fake server hangs up, so every ping opens a new connection
+		_ = db.Ping(context.Background())
+		select {
+		case got := <-passwords:
+			if got != want {
+				t.Errorf("password = %q, want %q", got, want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("no connection attempt")
+		}
+	}
+}
+
+func TestMysqlReadsRotatedPassword(t *testing.T) {
+	cfg, rotate := loadRotating(t, "first")
+	connector := mysqlConnector{cfg: &cfg.Database}
+
+	if got := connector.config().Passwd; got != "first" {
+		t.Errorf("password = %q, want first", got)
+	}
+	rotate("second")
+	dsn := connector.config()
+	if dsn.Passwd != "second" {
+		t.Errorf("password = %q, want second", dsn.Passwd)
+	}
+	if want := net.JoinHostPort(cfg.Database.Host, strconv.Itoa(cfg.Database.Port)); dsn.Addr != want || !dsn.ParseTime {
+		t.Errorf("config = %+v", dsn)
+	}
+}
@@ -0,0 +1,45 @@
+package orm
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"service/pkg/pagination"
+)
+
+// Paginate orders, seeks and limits db for req. Sort fields are used as
+// column names, so they must come from pagination.Options.Sortable.
+// Count the total on a separate session before calling it.
+func Paginate(db *gorm.DB, req pagination.Request) *gorm.DB {
+	sort := req.QuerySort()
+	for _, s := range sort {
+		db = db.Order(clause.OrderByColumn{Column: clause.Column{Name: s.Field}, Desc: s.Desc})
+	}
+	if req.After != nil {
+		db = db.Where(seek(sort, req.After))
+	}
+	if offset := req.Offset(); offset > 0 {
+		db = db.Offset(offset)
+	}
+	return db.Limit(req.FetchLimit())
+}
+
+// seek matches the rows after values in sort order. Columns may sort in
+// different directions, so rather than a row comparison it expands to
+// (a > ?) OR (a = ? AND b > ?) OR ...
+func seek(sort []pagination.Sort, values []any) clause.Expression {
+	or := make([]clause.Expression, len(sort))
+	for i, s := range sort {
+		and := make([]clause.Expression, 0, i+1)
+		for j := 0; j < i; j++ {
+			and = append(and, clause.Eq{Column: clause.Column{Name: sort[j].Field}, Value: values[j]})
+		}
+		column := clause.Column{Name: s.Field}
+		if s.Desc {
+			and = append(and, clause.Lt{Column: column, Value: values[i]})
+		} else {
+			and = append(and, clause.Gt{Column: column, Value: values[i]})
+		}
+		or[i] = clause.And(and...)
+	}
+	return clause.Or(or...)
+}
@@ -1,50 +1,46 @@
 package orm
 
 import (
+	"context"
 	"fmt"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
-	"log"
 	"service/config"
-	"strconv"
-	"time"
 )
 
-func newPostgres(dbCfg *config.Database) *Orm {
+func newPostgres(dbCfg *config.Database) (*Orm, error) {
+	// the password is left out here and read for every new connection, so
+	// rotated credentials are used once the pool opens another connection
 	dsn := fmt.Sprintf(
-		"host=%s user=%s password=%s dbname=%s port=%s sslmode=disable TimeZone=%s",
-		dbCfg.Host, dbCfg.User, dbCfg.Password, dbCfg.Name, dbCfg.Port, "UTC",
+		"host=%s user=%s dbname=%s port=%d sslmode=disable",
+		dbCfg.Host, dbCfg.User, dbCfg.Name, dbCfg.Port,
 	)
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
+	connConfig, err := pgx.ParseConfig(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse database postgres config: %w", err)
+	}
+	connConfig.RuntimeParams["timezone"] = "UTC"
+	conn := stdlib.OpenDB(*connConfig, stdlib.OptionBeforeConnect(func(ctx context.Context, cc *pgx.ConnConfig) error {
+		cc.Password = dbCfg.Password.Value()
+		return nil
+	}))
+
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: conn}), &gorm.Config{
 		SkipDefaultTransaction: true,
+		DisableAutomaticPing:   true,
+		TranslateError:         true,
 	})
 	if err != nil {
-		log.Fatal(fmt.Sprintf("failed to connect database postgres: %s", err.Error()))
+		return nil, fmt.Errorf("failed to open database postgres: %w", err)
 	}
 	sqlDB, err := db.DB()
 	if err != nil {
-		fmt.Println("Error getting *sql.DB object:", err)
-
+		return nil, fmt.Errorf("failed to get *sql.DB object: %w", err)
 	}
 
-	maxOpenConn, _ := strconv.Atoi(dbCfg.MaxOpenConn)
-	maxIdleConn, _ := strconv.Atoi(dbCfg.MaxIdleConn)
-	connMaxLifetime, _ := strconv.Atoi(dbCfg.MaxConnLifetime)
+	configurePool(sqlDB, dbCfg)
 
-	// Configure connection pooling
-	if maxIdleConn != 0 {
-		// Maximum number of open connections
-		sqlDB.SetMaxOpenConns(maxOpenConn)
-	}
-
-	if maxIdleConn != 0 {
-		// Maximum number of open connections
-		sqlDB.SetMaxIdleConns(maxIdleConn)
-	}
-
-	if connMaxLifetime != 0 {
-		// Maximum amount of time a connection can be reused (0 means no limit)
-		sqlDB.SetConnMaxLifetime(time.Duration(connMaxLifetime) * time.Second)
-	}
-	return &Orm{db: db}
+	return &Orm{db: db}, nil
 }
@@ -2,8 +2,9 @@ package orm
 
 import (
 	"context"
+	"database/sql"
+	"fmt"
 	"gorm.io/gorm"
-	"log"
 	"service/config"
 )
 
@@ -13,17 +14,36 @@ type Orm struct {
 
 var _ IDatabase = &Orm{}
 
-func NewProvider(cfg *config.Database) *Orm {
-	if cfg.Driver == "mariadb" || cfg.Driver == "mysql" {
+// NewProvider opens the pool without connecting. Use Ping to check that the
+// database is reachable.
+func NewProvider(cfg *config.Database) (*Orm, error) {
+	switch cfg.Driver {
+	case config.DriverMariadb, config.DriverMysql:
 		return newMysql(cfg)
+	case config.DriverPostgres:
+		return newPostgres(cfg)
 	}
 
-	if cfg.Driver == "postgres" {
-		return newPostgres(cfg)
+	return nil, fmt.Errorf("not support database driver: %s", cfg.Driver)
+}
+
+// configurePool applies the pool limits from config. Zero values keep the
+// database/sql defaults.
+func configurePool(sqlDB *sql.DB, cfg *config.Database) {
+	if cfg.MaxOpenConn != 0 {
+		// Maximum number of open connections
+		sqlDB.SetMaxOpenConns(cfg.MaxOpenConn)
 	}
 
-	log.Fatal("not support database driver")
-	return nil
+	if cfg.MaxIdleConn != 0 {
+		// Maximum number of idle connections
+		sqlDB.SetMaxIdleConns(cfg.MaxIdleConn)
+	}
+
+	if cfg.MaxConnLifetime != 0 {
+		// Maximum amount of time a connection can be reused (0 means no limit)
+		sqlDB.SetConnMaxLifetime(cfg.MaxConnLifetime)
+	}
 }
 
 func (d Orm) DB(ctx context.Context) *gorm.DB {
@@ -34,6 +54,24 @@ func (d Orm) DB(ctx context.Context) *gorm.DB {
 	return d.db.WithContext(ctx)
 }
 
+// Ping checks that a connection from the pool can reach the database.
+func (d Orm) Ping(ctx context.Context) error {
+	sqlDB, err := d.db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
+
+// Close closes the underlying connection pool.
+func (d Orm) Close() error {
+	sqlDB, err := d.db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
+}
+
 func (d Orm) WithTx(ctx context.Context) *gorm.DB {
 	tx, ok := ctx.Value(txCtxKey{}).(*gorm.DB)
 	if ok {
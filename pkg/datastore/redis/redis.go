@@ -2,9 +2,13 @@ package redis
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"github.com/go-redsync/redsync/v4"
 	"github.com/go-redsync/redsync/v4/redis/goredis/v9"
 	"github.com/redis/go-redis/v9"
+	"net"
 	"service/config"
 	"strconv"
 	"time"
@@ -15,15 +19,42 @@ type Redis struct {
 	rs  *redsync.Redsync
 }
 
+// ErrMiss is returned by Get when the key does not exist.
+var ErrMiss = errors.New("cache miss")
+
+// ErrLocked is returned by Lock when another holder kept the lock for as
+// long as Lock was willing to wait.
+var ErrLocked = errors.New("lock is held elsewhere")
+
+// Get decodes the JSON stored by Set into dest.
 func (r *Redis) Get(ctx context.Context, key string, dest interface{}) error {
-	return r.rdb.HGetAll(ctx, key).Scan(&dest)
+	raw, err := r.rdb.Get(ctx, key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return ErrMiss
+	}
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, dest)
 }
 
+// Set stores data as JSON for second seconds, 0 keeps it until deleted.
 func (r *Redis) Set(ctx context.Context, key string, data interface{}, second int) error {
-	statusCmd := r.rdb.Set(ctx, key, data, time.Second*time.Duration(second))
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	statusCmd := r.rdb.Set(ctx, key, raw, time.Second*time.Duration(second))
 	return statusCmd.Err()
 }
 
+func (r *Redis) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	return r.rdb.Del(ctx, keys...).Err()
+}
+
 //var _ cache.ICache = &Redis{}
 
 func (r *Redis) Lock(ctx context.Context, key string, ttl int64, proses func(ctx context.Context) error) error {
@@ -31,6 +62,10 @@ func (r *Redis) Lock(ctx context.Context, key string, ttl int64, proses func(ctx
 
 	mutex := r.rs.NewMutex(key, cfgExpiry)
 	if err := mutex.LockContext(ctx); err != nil {
+		var taken *redsync.ErrTaken
+		if errors.Is(err, redsync.ErrFailed) || errors.As(err, &taken) {
+			return fmt.Errorf("%w: %s", ErrLocked, key)
+		}
 		return err
 	}
 
@@ -41,17 +76,64 @@ func (r *Redis) Lock(ctx context.Context, key string, ttl int64, proses func(ctx
 	return err
 }
 
-func NewRedis(_ context.Context, config *config.Config) *Redis {
+// RunScript runs a Lua script atomically, by SHA once Redis has cached it.
+func (r *Redis) RunScript(ctx context.Context, script *redis.Script, keys []string, args ...interface{}) (interface{}, error) {
+	return script.Run(ctx, r.rdb, keys, args...).Result()
+}
 
-	dbName, err := strconv.Atoi(config.Redis.Name)
+// Publish sends data as JSON to the subscribers of channel.
+func (r *Redis) Publish(ctx context.Context, channel string, data interface{}) error {
+	raw, err := json.Marshal(data)
 	if err != nil {
-		dbName = 0
+		return err
+	}
+	return r.rdb.Publish(ctx, channel, raw).Err()
+}
+
+// Subscribe calls handle with every message published on channel until ctx
+// is done. The client resubscribes by itself after connection errors;
+// messages published meanwhile are lost.
+func (r *Redis) Subscribe(ctx context.Context, channel string, handle func(payload []byte)) error {
+	sub := r.rdb.Subscribe(ctx, channel)
+	defer sub.Close()
+
+	messages := sub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case msg, ok := <-messages:
+			if !ok {
+				return nil
+			}
+			handle([]byte(msg.Payload))
+		}
+	}
+}
+
+func (r *Redis) Ping(ctx context.Context) error {
+	return r.rdb.Ping(ctx).Err()
+}
+
+func (r *Redis) Close() error {
+	return r.rdb.Close()
+}
+
+func NewRedis(_ context.Context, config *config.Config) *Redis {
+
+	addr := config.Redis.Host
+	if config.Redis.Port != 0 {
+		addr = net.JoinHostPort(config.Redis.Host, strconv.Itoa(config.Redis.Port))
 	}
 
+	password := config.Redis.Password
 	rdb := redis.NewClient(&redis.Options{
-		Addr:     config.Redis.Host,
-		Password: config.Redis.Password, // no password set
-		DB:       dbName,                // use default DB
+		Addr: addr,
+		// read on every new connection so a refreshed secret is picked up
+		CredentialsProvider: func() (string, string) {
+			return "", password.Value()
+		},
+		DB: config.Redis.DB,
 	})
 
 	pool := goredis.NewPool(rdb)
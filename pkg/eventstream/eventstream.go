@@ -0,0 +1,277 @@
+// Package eventstream fans events out to live subscribers, across replicas
+// through Redis pub/sub, and keeps a short history so a subscriber that
+// reconnects can resume where it left off.
+package eventstream
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/google/uuid"
+	"service/config"
+	"service/pkg/apperror"
+	"service/pkg/logger"
+	"slices"
+	"sync"
+	"time"
+)
+
+// TypeReset is sent first to a subscriber resuming from an event that is no
+// longer in the history: it missed events and should reload its state.
+const TypeReset = "stream.reset"
+
+var (
+	// ErrPublish is retryable, the event can be published again.
+	ErrPublish = apperror.Unavailable("stream.publish_failed", "event could not be published")
+	// ErrDisabled refuses subscriptions while stream.enabled is off.
+	ErrDisabled = apperror.Unavailable("stream.disabled", "event streams are disabled")
+
+	// ErrLagged ends a subscription that fell behind by more than the
+	// buffer. It resumes from the history when it reconnects.
+	ErrLagged = errors.New("subscriber fell behind")
+	// ErrClosed ends the subscriptions of a closed hub.
+	ErrClosed = errors.New("event stream closed")
+
+	errUnsubscribed = errors.New("unsubscribed")
+)
+
+type Event struct {
+	// ID orders events; subscribers resume after it with Last-Event-ID.
+	ID   string `json:"id"`
+	Type string `json:"type"`
+	// Subject is what the event is about, e.g. the user ID.
+	Subject string          `json:"subject"`
+	Time    time.Time       `json:"time"`
+	Data    json.RawMessage `json:"data,omitempty" swaggertype:"object"`
+}
+
+// Filter selects events by type and subject. Empty lists match everything.
+type Filter struct {
+	Types    []string
+	Subjects []string
+}
+
+func (f Filter) Match(e Event) bool {
+	if e.Type == TypeReset {
+		return true
+	}
+	return (len(f.Types) == 0 || slices.Contains(f.Types, e.Type)) &&
+		(len(f.Subjects) == 0 || slices.Contains(f.Subjects, e.Subject))
+}
+
+// PubSub carries events between replicas; the Redis cache implements it.
+type PubSub interface {
+	Publish(ctx context.Context, channel string, data interface{}) error
+	Subscribe(ctx context.Context, channel string, handle func(payload []byte)) error
+}
+
+type Hub struct {
+	cfg    *config.Stream
+	pubsub PubSub
+
+	mu      sync.Mutex
+	history []Event
+	// next is where the following event goes once history is full
+	next   int
+	subs   map[*Subscription]struct{}
+	closed bool
+
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// NewHub returns a hub publishing through pubsub. Without pubsub events
+// only reach the subscribers of this process.
+func NewHub(cfg *config.Stream, pubsub PubSub) *Hub {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Hub{
+		cfg:    cfg,
+		pubsub: pubsub,
+		subs:   map[*Subscription]struct{}{},
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// Publish sends an event about subject to the subscribers of every replica.
+// It does nothing while the stream is disabled.
+func (h *Hub) Publish(ctx context.Context, eventType, subject string, data interface{}) error {
+	if !h.cfg.Enabled {
+		return nil
+	}
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	id, err := uuid.NewV7()
+	if err != nil {
+		return ErrPublish.Wrap(err)
+	}
+	event := Event{
+		ID:      id.String(),
+		Type:    eventType,
+		Subject: subject,
+		Time:    time.Now().UTC(),
+		Data:    raw,
+	}
+
+	if h.pubsub == nil {
+		h.deliver(event)
+		return nil
+	}
+	// the event comes back through Run, like those of other replicas
+	if err := h.pubsub.Publish(ctx, h.cfg.Channel, event); err != nil {
+		return ErrPublish.Wrap(err)
+	}
+	return nil
+}
+
+// Run delivers the events published by every replica until Close.
+func (h *Hub) Run() error {
+	if h.pubsub == nil {
+		<-h.ctx.Done()
+		return nil
+	}
+	return h.pubsub.Subscribe(h.ctx, h.cfg.Channel, func(payload []byte) {
+		var event Event
+		if err := json.Unmarshal(payload, &event); err != nil {
+			logger.Logger.Warn("malformed stream event", logger.F("error", err.Error()))
+			return
+		}
+		h.deliver(event)
+	})
+}
+
+// Close ends Run and every subscription with ErrClosed.
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.closed = true
+	h.cancel()
+	for sub := range h.subs {
+		h.end(sub, ErrClosed)
+	}
+}
+
+// Subscribe starts a subscription to the events matching filter. With
+// lastEventID it first replays the matching events after that one, or
+// TypeReset when it is no longer known.
+func (h *Hub) Subscribe(filter Filter, lastEventID string) (*Subscription, error) {
+	if !h.cfg.Enabled {
+		return nil, ErrDisabled
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var replay []Event
+	if lastEventID != "" {
+		missed, ok := h.since(lastEventID)
+		if !ok {
+			missed = []Event{{Type: TypeReset, Time: time.Now().UTC()}}
+		}
+		for _, event := range missed {
+			if filter.Match(event) {
+				replay = append(replay, event)
+			}
+		}
+	}
+
+	sub := &Subscription{
+		hub:    h,
+		filter: filter,
+		events: make(chan Event, h.cfg.Buffer+len(replay)),
+	}
+	for _, event := range replay {
+		sub.events <- event
+	}
+	if h.closed {
+		h.end(sub, ErrClosed)
+		return sub, nil
+	}
+	h.subs[sub] = struct{}{}
+	return sub, nil
+}
+
+func (h *Hub) deliver(event Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.remember(event)
+	for sub := range h.subs {
+		if !sub.filter.Match(event) {
+			continue
+		}
+		select {
+		case sub.events <- event:
+		default:
+			// waiting would hold up every other subscriber
+			h.end(sub, ErrLagged)
+		}
+	}
+}
+
+// remember keeps event in the ring of the last cfg.History events.
+func (h *Hub) remember(event Event) {
+	if h.cfg.History <= 0 {
+		return
+	}
+	if len(h.history) < h.cfg.History {
+		h.history = append(h.history, event)
+		return
+	}
+	h.history[h.next] = event
+	h.next = (h.next + 1) % len(h.history)
+}
+
+// since returns the remembered events after id, oldest first, and whether
+// id was found.
+func (h *Hub) since(id string) ([]Event, bool) {
+	ordered := append(slices.Clone(h.history[h.next:]), h.history[:h.next]...)
+	for i, event := range ordered {
+		if event.ID == id {
+			return ordered[i+1:], true
+		}
+	}
+	return nil, false
+}
+
+// end closes the events of sub; the caller holds h.mu.
+func (h *Hub) end(sub *Subscription, err error) {
+	if sub.err != nil {
+		return
+	}
+	sub.err = err
+	close(sub.events)
+	delete(h.subs, sub)
+}
+
+type Subscription struct {
+	hub    *Hub
+	filter Filter
+	events chan Event
+	err    error
+}
+
+// Events yields the matching events until the subscription ends.
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Err tells why Events was closed: ErrLagged, ErrClosed, or nil after
+// Close.
+func (s *Subscription) Err() error {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	if errors.Is(s.err, errUnsubscribed) {
+		return nil
+	}
+	return s.err
+}
+
+// Close ends the subscription.
+func (s *Subscription) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	s.hub.end(s, errUnsubscribed)
+}
@@ -0,0 +1,219 @@
+package eventstream
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"service/config"
+	"testing"
+	"time"
+)
+
+// fakePubSub loops published events back through Subscribe, like a Redis
+// channel with this replica as its only subscriber.
+type fakePubSub struct {
+	messages chan []byte
+}
+
+func newFakePubSub() *fakePubSub {
+	return &fakePubSub{messages: make(chan []byte, 100)}
+}
+
+func (f *fakePubSub) Publish(ctx context.Context, channel string, data interface{}) error {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	f.messages <- raw
+	return nil
+}
+
+func (f *fakePubSub) Subscribe(ctx context.Context, channel string, handle func(payload []byte)) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case raw := <-f.messages:
+			handle(raw)
+		}
+	}
+}
+
+func newTestHub(t *testing.T, history, buffer int) *Hub {
+	t.Helper()
+	cfg := config.NewConfig().Stream
+	cfg.History = history
+	cfg.Buffer = buffer
+	hub := NewHub(&cfg, newFakePubSub())
+	done := make(chan error, 1)
+	go func() { done <- hub.Run() }()
+	t.Cleanup(func() {
+		hub.Close()
+		if err := <-done; err != nil {
+			t.Errorf("Run: %v", err)
+		}
+	})
+	return hub
+}
+
+func subscribe(t *testing.T, hub *Hub, filter Filter, lastEventID string) *Subscription {
+	t.Helper()
+	sub, err := hub.Subscribe(filter, lastEventID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(sub.Close)
+	return sub
+}
+
+// receive returns the next event of sub, failing the test after a second.
+func receive(t *testing.T, sub *Subscription) Event {
+	t.Helper()
+	select {
+	case event, ok := <-sub.Events():
+		if !ok {
+			t.Fatalf("subscription ended: %v", sub.Err())
+		}
+		return event
+	case <-time.After(time.Second):
+		t.Fatal("no event")
+	}
+	return Event{}
+}
+
+// publish publishes an event and waits until the hub delivered it to sync.
+func publish(t *testing.T, hub *Hub, sync *Subscription, subject string) Event {
+	t.Helper()
+	if err := hub.Publish(context.Background(), "user.updated", subject, map[string]string{"id": subject}); err != nil {
+		t.Fatal(err)
+	}
+	return receive(t, sync)
+}
+
+func TestFanOut(t *testing.T) {
+	hub := newTestHub(t, 10, 10)
+	all := subscribe(t, hub, Filter{}, "")
+	other := subscribe(t, hub, Filter{}, "")
+	onlyU2 := subscribe(t, hub, Filter{Subjects: []string{"u2"}}, "")
+	otherType := subscribe(t, hub, Filter{Types: []string{"user.deleted"}}, "")
+
+	first := publish(t, hub, all, "u1")
+	second := publish(t, hub, all, "u2")
+
+	if first.Subject != "u1" || first.ID == "" || string(first.Data) != `{"id":"u1"}` {
+		t.Errorf("event = %+v", first)
+	}
+	if first.ID >= second.ID {
+		t.Errorf("ids out of order: %s, %s", first.ID, second.ID)
+	}
+	if got := receive(t, other); got.ID != first.ID {
+		t.Errorf("other got %+v first", got)
+	}
+	if got := receive(t, other); got.ID != second.ID {
+		t.Errorf("other got %+v second", got)
+	}
+	if got := receive(t, onlyU2); got.ID != second.ID {
+		t.Errorf("filtered subscriber got %+v", got)
+	}
+	if n := len(onlyU2.Events()) + len(otherType.Events()); n != 0 {
+		t.Errorf("%d unmatched events delivered", n)
+	}
+}
+
+func TestReplay(t *testing.T) {
+	hub := newTestHub(t, 10, 10)
+	sync := subscribe(t, hub, Filter{}, "")
+	var events []Event
+	for _, subject := range []string{"u1", "u2", "u3", "u2"} {
+		events = append(events, publish(t, hub, sync, subject))
+	}
+
+	resumed := subscribe(t, hub, Filter{}, events[1].ID)
+	for _, want := range events[2:] {
+		if got := receive(t, resumed); got.ID != want.ID {
+			t.Errorf("replayed %+v, want %s", got, want.ID)
+		}
+	}
+
+	// the filter applies to the replay too
+	filtered := subscribe(t, hub, Filter{Subjects: []string{"u2"}}, events[0].ID)
+	if got := receive(t, filtered); got.ID != events[1].ID {
+		t.Errorf("replayed %+v, want %s", got, events[1].ID)
+	}
+	if got := receive(t, filtered); got.ID != events[3].ID {
+		t.Errorf("replayed %+v, want %s", got, events[3].ID)
+	}
+
+	// live events follow the replay
+	live := publish(t, hub, sync, "u4")
+	if got := receive(t, resumed); got.ID != live.ID {
+		t.Errorf("live %+v, want %s", got, live.ID)
+	}
+}
+
+func TestReplayExpired(t *testing.T) {
+	hub := newTestHub(t, 2, 10)
+	sync := subscribe(t, hub, Filter{}, "")
+	expired := publish(t, hub, sync, "u1")
+	publish(t, hub, sync, "u2")
+	publish(t, hub, sync, "u3")
+
+	for name, id := range map[string]string{"pushed out": expired.ID, "unknown": "not-an-id"} {
+		sub := subscribe(t, hub, Filter{Types: []string{"user.deleted"}}, id)
+		if got := receive(t, sub); got.Type != TypeReset {
+			t.Errorf("%s: first event %+v, want %s", name, got, TypeReset)
+		}
+		if len(sub.Events()) != 0 {
+			t.Errorf("%s: events after the reset", name)
+		}
+	}
+}
+
+func TestSlowSubscriberDropped(t *testing.T) {
+	hub := newTestHub(t, 10, 2)
+	sync := subscribe(t, hub, Filter{}, "")
+	slow := subscribe(t, hub, Filter{}, "")
+
+	for _, subject := range []string{"u1", "u2", "u3"} {
+		publish(t, hub, sync, subject)
+	}
+	// the buffered events are still delivered, then the channel closes
+	receive(t, slow)
+	receive(t, slow)
+	if _, ok := <-slow.Events(); ok {
+		t.Fatal("slow subscriber not dropped")
+	}
+	if !errors.Is(slow.Err(), ErrLagged) {
+		t.Errorf("Err = %v, want ErrLagged", slow.Err())
+	}
+	// the others keep receiving
+	publish(t, hub, sync, "u4")
+}
+
+func TestClose(t *testing.T) {
+	cfg := config.NewConfig().Stream
+	hub := NewHub(&cfg, nil)
+	sub, err := hub.Subscribe(Filter{}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	unsubscribed, _ := hub.Subscribe(Filter{}, "")
+	unsubscribed.Close()
+	hub.Close()
+
+	if _, ok := <-sub.Events(); ok || !errors.Is(sub.Err(), ErrClosed) {
+		t.Errorf("Err = %v, want ErrClosed", sub.Err())
+	}
+	if unsubscribed.Err() != nil {
+		t.Errorf("Err after Close = %v, want nil", unsubscribed.Err())
+	}
+	late, _ := hub.Subscribe(Filter{}, "")
+	if _, ok := <-late.Events(); ok || !errors.Is(late.Err(), ErrClosed) {
+		t.Errorf("subscribed after Close: Err = %v", late.Err())
+	}
+
+	cfg.Enabled = false
+	if _, err := NewHub(&cfg, nil).Subscribe(Filter{}, ""); !errors.Is(err, ErrDisabled) {
+		t.Errorf("disabled: err = %v", err)
+	}
+}
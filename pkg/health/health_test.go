@@ -0,0 +1,88 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func up(ctx context.Context) error { return nil }
+
+func down(ctx context.Context) error { return errors.New("connection refused") }
+
+func TestCheckStatus(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		checks func(r *Registry)
+		want   Status
+	}{
+		{"no checks", func(r *Registry) {}, StatusUp},
+		{"all up", func(r *Registry) {
+			r.Add("database", true, up)
+			r.Add("elastic", false, up)
+		}, StatusUp},
+		{"non-critical down", func(r *Registry) {
+			r.Add("database", true, up)
+			r.Add("elastic", false, down)
+		}, StatusDegraded},
+		{"critical down", func(r *Registry) {
+			r.Add("database", true, down)
+			r.Add("elastic", false, up)
+		}, StatusDown},
+		// a later non-critical failure must not lift down to degraded
+		{"critical and non-critical down", func(r *Registry) {
+			r.Add("database", true, down)
+			r.Add("elastic", false, down)
+		}, StatusDown},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			r := NewRegistry(time.Second)
+			tc.checks(r)
+			if got := r.Check(context.Background()).Status; got != tc.want {
+				t.Errorf("status = %s, want %s", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestCheckResults(t *testing.T) {
+	r := NewRegistry(time.Second)
+	r.Add("database", true, up)
+	r.Add("cache", false, down)
+
+	report := r.Check(context.Background())
+	if len(report.Checks) != 2 {
+		t.Fatalf("checks = %v", report.Checks)
+	}
+	if db := report.Checks["database"]; db.Status != StatusUp || !db.Critical || db.Error != "" || db.Latency == "" {
+		t.Errorf("database = %+v", db)
+	}
+	if cache := report.Checks["cache"]; cache.Status != StatusDown || cache.Critical || cache.Error != "connection refused" {
+		t.Errorf("cache = %+v", cache)
+	}
+}
+
+func TestCheckTimeout(t *testing.T) {
+	r := NewRegistry(20 * time.Millisecond)
+	release := make(chan struct{})
+	defer close(release)
+	// ignores ctx like a client library that does not honour it
+	r.Add("mongodb", true, func(ctx context.Context) error {
+		<-release
+		return nil
+	})
+	r.Add("cache", false, up)
+
+	start := time.Now()
+	report := r.Check(context.Background())
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("check took %s", elapsed)
+	}
+	if mongo := report.Checks["mongodb"]; mongo.Status != StatusDown || mongo.Error != context.DeadlineExceeded.Error() {
+		t.Errorf("mongodb = %+v", mongo)
+	}
+	if report.Status != StatusDown || report.Checks["cache"].Status != StatusUp {
+		t.Errorf("report = %+v", report)
+	}
+}
@@ -0,0 +1,105 @@
+// Package httpcache stores rendered HTTP responses in the cache, grouped by
+// the resource they are built from so a change to the resource drops them
+// all at once.
+//
+// Every resource has a generation and stored responses are keyed by it.
+// Invalidate moves the resource to a new generation instead of deleting
+// keys, so the old responses are never read again and simply expire.
+package httpcache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"service/pkg/cache"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Response is a rendered response.
+type Response struct {
+	Status int         `json:"status"`
+	Header http.Header `json:"header"`
+	Body   []byte      `json:"body"`
+}
+
+type Store struct {
+	cache cache.ICache
+}
+
+func NewStore(cache cache.ICache) *Store {
+	return &Store{cache: cache}
+}
+
+// Generation returns the current generation of resource. Callers read it
+// before rendering and store the response under it, so a response rendered
+// while the resource changed is stored under the old generation and never
+// served.
+func (s *Store) Generation(ctx context.Context, resource string) (string, error) {
+	var gen string
+	err := s.cache.Get(ctx, generationKey(resource), &gen)
+	if errors.Is(err, cache.ErrMiss) {
+		return "0", nil
+	}
+	return gen, err
+}
+
+// Get reads the response stored under key for generation gen of resource.
+// It returns cache.ErrMiss when there is none.
+func (s *Store) Get(ctx context.Context, resource, gen, key string) (*Response, error) {
+	resp := &Response{}
+	if err := s.cache.Get(ctx, responseKey(resource, gen, key), resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// Set stores resp under key for generation gen of resource.
+func (s *Store) Set(ctx context.Context, resource, gen, key string, resp *Response, ttl time.Duration) error {
+	return s.cache.Set(ctx, responseKey(resource, gen, key), resp, int(ttl.Seconds()))
+}
+
+// Invalidate drops every stored response of the resources.
+func (s *Store) Invalidate(ctx context.Context, resources ...string) error {
+	gen := strconv.FormatInt(time.Now().UnixNano(), 36)
+	var errs []error
+	for _, resource := range resources {
+		// the generation outlives every response stored under it
+		errs = append(errs, s.cache.Set(ctx, generationKey(resource), gen, 0))
+	}
+	return errors.Join(errs...)
+}
+
+func generationKey(resource string) string {
+	return "httpcache:" + resource + ":gen"
+}
+
+func responseKey(resource, gen, key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return "httpcache:" + resource + ":" + gen + ":" + base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// ETag is the strong entity tag of body.
+func ETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + base64.RawURLEncoding.EncodeToString(sum[:16]) + `"`
+}
+
+// NotModified reports whether an If-None-Match header value lets the client
+// reuse its copy tagged etag. As RFC 9110 requires for If-None-Match, weak
+// tags compare equal to their strong form.
+func NotModified(header, etag string) bool {
+	if header == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
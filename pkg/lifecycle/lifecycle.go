@@ -0,0 +1,222 @@
+// Package lifecycle starts and stops application modules in dependency order.
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"golang.org/x/sync/errgroup"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Module is one infrastructure or application piece managed by a Registry.
+type Module struct {
+	Name string
+	// DependsOn lists modules that must be started before this one and
+	// stopped after it.
+	DependsOn []string
+	// Start initialises the module. An error aborts startup.
+	Start func(ctx context.Context) error
+	// Run, when set, blocks while the module serves traffic and returns
+	// once Stop has been called. An error triggers shutdown of every module.
+	Run func() error
+	// Stop releases the module within ctx's deadline.
+	Stop func(ctx context.Context) error
+}
+
+type Registry struct {
+	modules map[string]Module
+	names   []string
+}
+
+func NewRegistry() *Registry {
+	return &Registry{modules: map[string]Module{}}
+}
+
+// Register adds a module. Only enabled modules should be registered; a
+// registered module depending on a missing one fails at Run.
+func (r *Registry) Register(m Module) {
+	if _, ok := r.modules[m.Name]; !ok {
+		r.names = append(r.names, m.Name)
+	}
+	r.modules[m.Name] = m
+}
+
+// Run starts the target modules and their dependencies, or every module when
+// no target is given, in dependency order and blocks until ctx is done or a
+// module's Run fails. It then stops the modules in reverse order, modules
+// that do not depend on each other concurrently, within shutdownTimeout.
+func (r *Registry) Run(ctx context.Context, shutdownTimeout time.Duration, targets ...string) error {
+	order, err := r.resolve(targets)
+	if err != nil {
+		return err
+	}
+
+	var started []string
+	for _, name := range order {
+		m := r.modules[name]
+		if m.Start != nil {
+			log.Printf("starting module %s", name)
+			if err := m.Start(ctx); err != nil {
+				startErr := fmt.Errorf("start module %s: %w", name, err)
+				return errors.Join(startErr, r.stop(started, shutdownTimeout))
+			}
+		}
+		started = append(started, name)
+	}
+
+	g, gctx := errgroup.WithContext(ctx)
+	for _, name := range started {
+		m := r.modules[name]
+		if m.Run == nil {
+			continue
+		}
+		g.Go(func() error {
+			if err := m.Run(); err != nil {
+				return fmt.Errorf("module %s: %w", name, err)
+			}
+			return nil
+		})
+	}
+
+	<-gctx.Done()
+	log.Printf("shutting down")
+
+	stopErr := r.stop(started, shutdownTimeout)
+	return errors.Join(g.Wait(), stopErr)
+}
+
+// Order returns the modules Run would start for targets, in start order.
+func (r *Registry) Order(targets ...string) ([]string, error) {
+	return r.resolve(targets)
+}
+
+// stop stops the started modules level by level, dependents first.
+func (r *Registry) stop(started []string, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	levels := r.levels(started)
+	var errs []error
+	for i := len(levels) - 1; i >= 0; i-- {
+		var (
+			wg sync.WaitGroup
+			mu sync.Mutex
+		)
+		for _, name := range levels[i] {
+			m := r.modules[name]
+			if m.Stop == nil {
+				continue
+			}
+			wg.Add(1)
+			go func(name string) {
+				defer wg.Done()
+				if err := m.Stop(ctx); err != nil {
+					mu.Lock()
+					errs = append(errs, fmt.Errorf("stop module %s: %w", name, err))
+					mu.Unlock()
+					return
+				}
+				log.Printf("stopped module %s", name)
+			}(name)
+		}
+		wg.Wait()
+	}
+
+	return errors.Join(errs...)
+}
+
+// resolve returns the targets and everything they depend on in dependency
+// order, keeping registration order between independent modules.
+func (r *Registry) resolve(targets []string) ([]string, error) {
+	var errs []error
+	for _, name := range targets {
+		if _, ok := r.modules[name]; !ok {
+			errs = append(errs, fmt.Errorf("module %s is not enabled", name))
+		}
+	}
+	for _, name := range r.names {
+		for _, dep := range r.modules[name].DependsOn {
+			if _, ok := r.modules[dep]; !ok {
+				errs = append(errs, fmt.Errorf("module %s depends on %s, which is not enabled", name, dep))
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	const (
+		visiting = iota + 1
+		done
+	)
+	state := map[string]int{}
+	order := make([]string, 0, len(r.names))
+
+	var visit func(name string, path []string) error
+	visit = func(name string, path []string) error {
+		switch state[name] {
+		case done:
+			return nil
+		case visiting:
+			return fmt.Errorf("module dependency cycle: %s", strings.Join(append(path, name), " -> "))
+		}
+		state[name] = visiting
+		for _, dep := range r.modules[name].DependsOn {
+			if err := visit(dep, append(path, name)); err != nil {
+				return err
+			}
+		}
+		state[name] = done
+		order = append(order, name)
+		return nil
+	}
+
+	if len(targets) == 0 {
+		targets = r.names
+	}
+	for _, name := range targets {
+		if err := visit(name, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	return order, nil
+}
+
+// levels groups modules by their depth in the dependency graph so that a
+// level only depends on lower levels.
+func (r *Registry) levels(names []string) [][]string {
+	depth := map[string]int{}
+	var depthOf func(name string) int
+	depthOf = func(name string) int {
+		if d, ok := depth[name]; ok {
+			return d
+		}
+		d := 0
+		for _, dep := range r.modules[name].DependsOn {
+			if dd := depthOf(dep) + 1; dd > d {
+				d = dd
+			}
+		}
+		depth[name] = d
+		return d
+	}
+
+	var levels [][]string
+	for _, name := range names {
+		d := depthOf(name)
+		for len(levels) <= d {
+			levels = append(levels, nil)
+		}
+		levels[d] = append(levels[d], name)
+	}
+	for _, level := range levels {
+		sort.Strings(level)
+	}
+	return levels
+}
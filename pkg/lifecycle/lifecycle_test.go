@@ -0,0 +1,170 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recorder logs the start and stop calls of the modules it builds.
+type recorder struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (rec *recorder) add(event string) {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	rec.events = append(rec.events, event)
+}
+
+func (rec *recorder) module(name string, deps ...string) Module {
+	return Module{
+		Name:      name,
+		DependsOn: deps,
+		Start: func(ctx context.Context) error {
+			rec.add("start " + name)
+			return nil
+		},
+		Stop: func(ctx context.Context) error {
+			rec.add("stop " + name)
+			return nil
+		},
+	}
+}
+
+// index returns the position of event, failing the test when it is missing.
+func (rec *recorder) index(t *testing.T, event string) int {
+	t.Helper()
+	i := slices.Index(rec.events, event)
+	if i < 0 {
+		t.Fatalf("%q not in %v", event, rec.events)
+	}
+	return i
+}
+
+func TestOrder(t *testing.T) {
+	rec := &recorder{}
+	reg := NewRegistry()
+	reg.Register(rec.module("http", "app"))
+	reg.Register(rec.module("database"))
+	reg.Register(rec.module("cache"))
+	reg.Register(rec.module("app", "database", "cache"))
+	reg.Register(rec.module("admin"))
+
+	for _, tc := range []struct {
+		targets []string
+		want    string
+	}{
+		{nil, "database,cache,app,http,admin"},
+		{[]string{"app"}, "database,cache,app"},
+		{[]string{"admin", "http"}, "admin,database,cache,app,http"},
+	} {
+		order, err := reg.Order(tc.targets...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := strings.Join(order, ","); got != tc.want {
+			t.Errorf("%v: order = %s, want %s", tc.targets, got, tc.want)
+		}
+	}
+}
+
+func TestOrderRejects(t *testing.T) {
+	reg := NewRegistry()
+	reg.Register(Module{Name: "a", DependsOn: []string{"b"}})
+	reg.Register(Module{Name: "b", DependsOn: []string{"a"}})
+	if _, err := reg.Order(); err == nil || !strings.Contains(err.Error(), "cycle: a -> b -> a") {
+		t.Errorf("cycle: err = %v", err)
+	}
+
+	reg.Register(Module{Name: "c", DependsOn: []string{"kafka"}})
+	if _, err := reg.Order(); err == nil || !strings.Contains(err.Error(), "module c depends on kafka, which is not enabled") {
+		t.Errorf("missing dependency: err = %v", err)
+	}
+
+	if _, err := NewRegistry().Order("grpc"); err == nil || !strings.Contains(err.Error(), "module grpc is not enabled") {
+		t.Errorf("missing target: err = %v", err)
+	}
+}
+
+func TestRunStopsInReverse(t *testing.T) {
+	rec := &recorder{}
+	reg := NewRegistry()
+	reg.Register(rec.module("database"))
+	reg.Register(rec.module("cache"))
+	reg.Register(rec.module("app", "database", "cache"))
+	http := rec.module("http", "app")
+	served := make(chan struct{})
+	done := make(chan struct{})
+	http.Run = func() error {
+		close(served)
+		<-done
+		return nil
+	}
+	stop := http.Stop
+	http.Stop = func(ctx context.Context) error {
+		close(done)
+		return stop(ctx)
+	}
+	reg.Register(http)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-served
+		cancel()
+	}()
+	if err := reg.Run(ctx, time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"start database", "start cache", "start app", "start http", "stop http", "stop app"}
+	if got := rec.events[:len(want)]; !slices.Equal(got, want) {
+		t.Errorf("events = %v, want %v first", rec.events, want)
+	}
+	// database and cache do not depend on each other and stop last, in
+	// either order
+	if len(rec.events) != 8 || rec.index(t, "stop database") < 6 || rec.index(t, "stop cache") < 6 {
+		t.Errorf("events = %v", rec.events)
+	}
+}
+
+func TestRunStartFailure(t *testing.T) {
+	rec := &recorder{}
+	reg := NewRegistry()
+	reg.Register(rec.module("database"))
+	broken := rec.module("cache")
+	broken.Start = func(ctx context.Context) error { return errors.New("connection refused") }
+	reg.Register(broken)
+	reg.Register(rec.module("app", "database", "cache"))
+
+	err := reg.Run(context.Background(), time.Second)
+	if err == nil || !strings.Contains(err.Error(), "start module cache: connection refused") {
+		t.Fatalf("err = %v", err)
+	}
+	// only the started modules are stopped
+	if want := []string{"start database", "stop database"}; !slices.Equal(rec.events, want) {
+		t.Errorf("events = %v, want %v", rec.events, want)
+	}
+}
+
+func TestRunFailureShutsDown(t *testing.T) {
+	rec := &recorder{}
+	reg := NewRegistry()
+	reg.Register(rec.module("database"))
+	grpc := rec.module("grpc", "database")
+	grpc.Run = func() error { return errors.New("address in use") }
+	reg.Register(grpc)
+
+	err := reg.Run(context.Background(), time.Second)
+	if err == nil || !strings.Contains(err.Error(), "module grpc: address in use") {
+		t.Fatalf("err = %v", err)
+	}
+	if rec.index(t, "stop grpc") > rec.index(t, "stop database") {
+		t.Errorf("events = %v", rec.events)
+	}
+}
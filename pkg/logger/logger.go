@@ -3,6 +3,7 @@ package logger
 import (
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
+	"service/config"
 	"service/pkg/setting"
 )
 
@@ -16,7 +17,8 @@ func F(key string, val interface{}) Field {
 }
 
 type Log struct {
-	lg *zap.Logger
+	lg    *zap.Logger
+	level zap.AtomicLevel
 }
 
 func (l *Log) Debug(msg string, fields ...Field) {
@@ -47,6 +49,14 @@ func (l *Log) Flush() error {
 	return l.lg.Sync()
 }
 
+// SetLevel changes the minimum level without rebuilding the logger.
+func (l *Log) SetLevel(lvl Level) {
+	if l.lg == nil {
+		return
+	}
+	l.level.SetLevel(zapcore.Level(lvl))
+}
+
 func (l *Log) Log(lvl Level, msg string, fields ...Field) {
 	zapFields := make([]zap.Field, len(fields))
 	for i, field := range fields {
@@ -59,11 +69,30 @@ var Logger Log
 
 func NewLogger(level Level) Log {
 	lgCfg := zap.NewProductionConfig()
-	if !setting.Setting.IsProduction() {
+	if !setting.Get().IsProduction() {
 		lgCfg = zap.NewDevelopmentConfig()
 	}
 	lgCfg.Level.SetLevel(zapcore.Level(level))
 	lg, _ := lgCfg.Build()
-	Logger = Log{lg: lg}
+	Logger = Log{lg: lg, level: lgCfg.Level}
+	return Logger
+}
+
+// Use replaces the global Logger with lg, e.g. a zaptest observer in tests.
+// The level of lg is fixed, SetLevel leaves it unchanged.
+func Use(lg *zap.Logger) Log {
+	Logger = Log{lg: lg, level: zap.NewAtomicLevelAt(lg.Level())}
 	return Logger
 }
+
+// SubscribeConfig applies reloaded log levels to the global Logger.
+func SubscribeConfig(store *config.Store) {
+	store.Subscribe("log", func(cfg *config.Config) {
+		var level Level
+		if err := level.FromString(cfg.Log.Level); err != nil {
+			Logger.Warn("ignoring log level from config", F("level", cfg.Log.Level), F("error", err))
+			return
+		}
+		Logger.SetLevel(level)
+	})
+}
@@ -0,0 +1,56 @@
+package kafka
+
+import (
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/ThreeDotsLabs/watermill/message/router/middleware"
+	"service/config"
+	"service/pkg/apperror"
+)
+
+// skipRetry hides non-retryable errors from retry so a message that can
+// never succeed is not retried, and returns them once retry is done.
+func skipRetry(retry message.HandlerMiddleware) message.HandlerMiddleware {
+	return func(h message.HandlerFunc) message.HandlerFunc {
+		return func(msg *message.Message) ([]*message.Message, error) {
+			var permanent error
+			produced, err := retry(func(msg *message.Message) ([]*message.Message, error) {
+				produced, err := h(msg)
+				if err != nil && !apperror.Retryable(err) {
+					permanent = err
+					return nil, nil
+				}
+				return produced, err
+			})(msg)
+			if permanent != nil {
+				return nil, permanent
+			}
+			return produced, err
+		}
+	}
+}
+
+// permanentErrors moves messages that failed with a non-retryable error to
+// cfg.PoisonTopic, or logs and acks them when no topic is configured, so
+// they do not block the partition with redeliveries.
+func permanentErrors(cfg *config.Kafka, pub message.Publisher) (message.HandlerMiddleware, error) {
+	if cfg.PoisonTopic != "" {
+		return middleware.PoisonQueueWithFilter(pub, cfg.PoisonTopic, func(err error) bool {
+			return !apperror.Retryable(err)
+		})
+	}
+
+	return func(h message.HandlerFunc) message.HandlerFunc {
+		return func(msg *message.Message) ([]*message.Message, error) {
+			produced, err := h(msg)
+			if err != nil && !apperror.Retryable(err) {
+				logger.Error("dropping message after non-retryable error", err, watermill.LogFields{
+					"message_uuid": msg.UUID,
+					"handler":      message.HandlerNameFromCtx(msg.Context()),
+				})
+				return nil, nil
+			}
+			return produced, err
+		}
+	}, nil
+}
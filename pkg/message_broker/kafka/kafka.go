@@ -2,11 +2,12 @@ package kafka
 
 import (
 	"context"
+	"errors"
 	"github.com/ThreeDotsLabs/watermill"
 	"github.com/ThreeDotsLabs/watermill-kafka/v3/pkg/kafka"
 	"github.com/ThreeDotsLabs/watermill/message"
 	"github.com/ThreeDotsLabs/watermill/message/router/middleware"
-	"github.com/ThreeDotsLabs/watermill/message/router/plugin"
+	"net"
 	"service/app/controllers/broker"
 	"service/config"
 	"strings"
@@ -22,7 +23,7 @@ type groupsHandlers func(
 	brokerHandler *broker.BrokerHandler,
 )
 
-func NewSubscriber(ctx context.Context, cfg *config.Kafka) *kafka.Subscriber {
+func NewSubscriber(ctx context.Context, cfg *config.Kafka) (*kafka.Subscriber, error) {
 	saramaSubscriberConfig := kafka.DefaultSaramaSubscriberConfig()
 
 	kafkaHost := strings.Split(cfg.Host, ",")
@@ -38,12 +39,28 @@ func NewSubscriber(ctx context.Context, cfg *config.Kafka) *kafka.Subscriber {
 	)
 
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
-	return subscriber
+	return subscriber, nil
 }
 
-func NewProducer(ctx context.Context, cfg *config.Kafka) *kafka.Publisher {
+// Ping checks that at least one configured broker accepts TCP connections.
+func Ping(ctx context.Context, cfg *config.Kafka) error {
+	var dialer net.Dialer
+	var errs []error
+	for _, host := range strings.Split(cfg.Host, ",") {
+		conn, err := dialer.DialContext(ctx, "tcp", strings.TrimSpace(host))
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		_ = conn.Close()
+		return nil
+	}
+	return errors.Join(errs...)
+}
+
+func NewProducer(ctx context.Context, cfg *config.Kafka) (*kafka.Publisher, error) {
 
 	kafkaHost := strings.Split(cfg.Host, ",")
 
@@ -55,40 +72,48 @@ func NewProducer(ctx context.Context, cfg *config.Kafka) *kafka.Publisher {
 		logger,
 	)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 
-	return publisher
+	return publisher, nil
 }
 
+// NewMessageBroker builds the router with every handler registered. The
+// caller runs it with router.Run and stops it with router.Close, which waits
+// up to closeTimeout for in-flight handlers.
 func NewMessageBroker(
 	ctx context.Context,
 	cfg *config.Kafka,
+	closeTimeout time.Duration,
 	sub *kafka.Subscriber,
 	pub *kafka.Publisher,
 	broker *broker.BrokerHandler,
 	groupHandlers ...groupsHandlers,
-) {
-	router, err := message.NewRouter(message.RouterConfig{}, logger)
+) (*message.Router, error) {
+	router, err := message.NewRouter(message.RouterConfig{CloseTimeout: closeTimeout}, logger)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 
-	// SignalsHandler will gracefully shutdown Router when SIGTERM is received.
-	// You can also close the router by just calling `r.Close()`.
-	router.AddPlugin(plugin.SignalsHandler)
+	permanent, err := permanentErrors(cfg, pub)
+	if err != nil {
+		return nil, err
+	}
 
 	router.AddMiddleware(
 		// CorrelationID will copy the correlation id from the incoming message's metadata to the produced messages
 		middleware.CorrelationID,
 
-		// The handler function is retried if it returns an error.
+		// Non-retryable errors skip Retry and are sent to the poison topic.
+		permanent,
+
+		// The handler function is retried if it returns a retryable error.
 		// After MaxRetries, the message is Nacked and it's up to the PubSub to resend it.
-		middleware.Retry{
+		skipRetry(middleware.Retry{
 			MaxRetries:      3,
 			InitialInterval: time.Millisecond * 100,
 			Logger:          logger,
-		}.Middleware,
+		}.Middleware),
 
 		// Recoverer handles panics from handlers.
 		// In this case, it passes them as errors to the Retry middleware.
@@ -99,8 +124,5 @@ func NewMessageBroker(
 		groupHandlers[i](router, sub, pub, broker)
 	}
 
-	if err := router.Run(ctx); err != nil {
-		panic(err)
-	}
-
+	return router, nil
 }
@@ -0,0 +1,159 @@
+package openapi
+
+import (
+	"bytes"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+	"github.com/gin-gonic/gin"
+	"io"
+	"service/pkg/apperror"
+	"strings"
+)
+
+// ErrRequest is returned when a request does not match the document; the
+// reason is under the "reason" detail.
+var ErrRequest = apperror.Validation("openapi.request_invalid", "request does not match the API document")
+
+// ErrResponse replaces a response that does not match the document.
+var ErrResponse = apperror.New(apperror.KindInternal, "openapi.response_invalid", "response does not match the API document")
+
+// pendingKey holds the request check of an operation that requires
+// authentication until ValidateAuthenticated runs it.
+const pendingKey = "openapi.pending"
+
+// Validate checks requests and responses of documented routes against doc.
+// A mismatching response is replaced by a 500 naming the mismatch, so it is
+// meant for development and staging only. Routes missing from the document
+// pass through; the route drift test catches those. So do the responses of
+// operations without a JSON response, such as event streams.
+//
+// The request of an operation the document secures is only checked by
+// ValidateAuthenticated, so an anonymous caller gets a 401 rather than the
+// schema.
+func Validate(doc *openapi3.T) (gin.HandlerFunc, error) {
+	router, err := gorillamux.NewRouter(doc)
+	if err != nil {
+		return nil, err
+	}
+	options := &openapi3filter.Options{
+		// authentication is checked by the middlewares, not the document
+		AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+	}
+
+	return func(c *gin.Context) {
+		route, pathParams, err := router.FindRoute(c.Request)
+		if err != nil {
+			c.Next()
+			return
+		}
+
+		// the document describes JSON bodies; other negotiated formats are
+		// only checked for their status and parameters
+		opts := *options
+		opts.ExcludeRequestBody = !isJSON(c.ContentType())
+		input := &openapi3filter.RequestValidationInput{
+			Request:    c.Request,
+			PathParams: pathParams,
+			Route:      route,
+			Options:    &opts,
+		}
+		validateRequest := func(c *gin.Context) {
+			input.Request = c.Request
+			if err := openapi3filter.ValidateRequest(c.Request.Context(), input); err != nil {
+				apperror.AbortHTTP(c, ErrRequest.WithDetail("reason", err.Error()))
+			}
+		}
+		if secured(doc, route.Operation) {
+			c.Set(pendingKey, validateRequest)
+		} else if validateRequest(c); c.IsAborted() {
+			return
+		}
+
+		if !hasJSONResponse(route.Operation) {
+			// streamed responses cannot be held back
+			c.Next()
+			return
+		}
+
+		w := &bufferWriter{ResponseWriter: c.Writer}
+		c.Writer = w
+		c.Next()
+		c.Writer = w.ResponseWriter
+
+		respOpts := opts
+		respOpts.ExcludeResponseBody = !isJSON(w.Header().Get("Content-Type"))
+		input.Options = &respOpts
+		err = openapi3filter.ValidateResponse(c.Request.Context(), &openapi3filter.ResponseValidationInput{
+			RequestValidationInput: input,
+			Status:                 w.Status(),
+			Header:                 w.Header(),
+			Body:                   io.NopCloser(bytes.NewReader(w.body.Bytes())),
+			Options:                &respOpts,
+		})
+		if err != nil {
+			apperror.AbortHTTP(c, ErrResponse.WithDetail("reason", err.Error()))
+			return
+		}
+		_, _ = w.ResponseWriter.Write(w.body.Bytes())
+	}, nil
+}
+
+// ValidateAuthenticated checks the request Validate held back because its
+// operation requires authentication. The authentication middleware calls it
+// once the caller is known; it aborts c when the request does not match.
+func ValidateAuthenticated(c *gin.Context) {
+	pending, _ := c.Get(pendingKey)
+	validate, ok := pending.(func(*gin.Context))
+	if !ok {
+		return
+	}
+	c.Set(pendingKey, nil)
+	validate(c)
+}
+
+// secured reports whether op, or the document when op does not say, has a
+// security requirement.
+func secured(doc *openapi3.T, op *openapi3.Operation) bool {
+	if op.Security != nil {
+		return len(*op.Security) > 0
+	}
+	return len(doc.Security) > 0
+}
+
+// hasJSONResponse reports whether op documents any JSON response.
+func hasJSONResponse(op *openapi3.Operation) bool {
+	for _, response := range op.Responses.Map() {
+		if response.Value != nil && response.Value.Content.Get(gin.MIMEJSON) != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// isJSON reports whether contentType is JSON. An empty type counts, a
+// request without a body has none.
+func isJSON(contentType string) bool {
+	mime, _, _ := strings.Cut(contentType, ";")
+	mime = strings.TrimSpace(mime)
+	return mime == "" || mime == gin.MIMEJSON
+}
+
+// bufferWriter holds the body back until it has been validated. The status
+// set by the handler is only sent with the first real write.
+type bufferWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *bufferWriter) Write(b []byte) (int, error) {
+	return w.body.Write(b)
+}
+
+func (w *bufferWriter) WriteString(s string) (int, error) {
+	return w.body.WriteString(s)
+}
+
+func (w *bufferWriter) Written() bool {
+	return w.body.Len() > 0 || w.ResponseWriter.Written()
+}
@@ -0,0 +1,197 @@
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"service/pkg/apperror"
+	"service/pkg/logger"
+	"strings"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap/zaptest"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+const testDoc = `{
+  "openapi": "3.0.3",
+  "info": {"title": "test", "version": "1"},
+  "servers": [{"url": "/api"}],
+  "components": {
+    "securitySchemes": {"BearerAuth": {"type": "apiKey", "in": "header", "name": "Authorization"}}
+  },
+  "paths": {
+    "/items": {
+      "post": {
+        "requestBody": {"content": {"application/json": {"schema": {
+          "type": "object", "required": ["name"], "properties": {"name": {"type": "string"}}
+        }}}},
+        "responses": {"201": {"description": "created", "content": {"application/json": {"schema": {
+          "type": "object", "required": ["id"], "properties": {"id": {"type": "integer"}}
+        }}}}}
+      }
+    },
+    "/private": {
+      "get": {
+        "security": [{"BearerAuth": []}],
+        "parameters": [{"name": "limit", "in": "query", "schema": {"type": "integer"}}],
+        "responses": {"200": {"description": "ok", "content": {"application/json": {"schema": {"type": "object"}}}}}
+      }
+    },
+    "/events": {
+      "get": {
+        "responses": {"200": {"description": "stream", "content": {"text/event-stream": {"schema": {"type": "string"}}}}}
+      }
+    }
+  }
+}`
+
+// newValidated mounts the validator on /api like a version group, with a
+// stand-in for the authentication middleware on /api/private.
+func newValidated(t *testing.T, handler gin.HandlerFunc) *gin.Engine {
+	t.Helper()
+	prev := logger.Logger
+	logger.Use(zaptest.NewLogger(t))
+	t.Cleanup(func() { logger.Logger = prev })
+
+	doc, err := openapi3.NewLoader().LoadFromData([]byte(testDoc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	validate, err := Validate(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	authenticate := func(c *gin.Context) {
+		if c.GetHeader("Authorization") != "Bearer good" {
+			apperror.AbortHTTP(c, apperror.New(apperror.KindUnauthorized, "auth.missing_token", "missing token"))
+			return
+		}
+		if ValidateAuthenticated(c); c.IsAborted() {
+			return
+		}
+		c.Next()
+	}
+
+	r := gin.New()
+	api := r.Group("/api", validate)
+	api.POST("/items", handler)
+	api.GET("/private", authenticate, handler)
+	api.GET("/events", handler)
+	api.GET("/undocumented", handler)
+	return r
+}
+
+func serve(r *gin.Engine, method, path, contentType, body, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	return rec
+}
+
+func errorCode(rec *httptest.ResponseRecorder) string {
+	var body apperror.ErrorResponse
+	_ = json.Unmarshal(rec.Body.Bytes(), &body)
+	return body.Error.Code
+}
+
+func TestValidateRequest(t *testing.T) {
+	r := newValidated(t, func(c *gin.Context) {
+		c.JSON(http.StatusCreated, gin.H{"id": 1})
+	})
+
+	tests := []struct {
+		name        string
+		path        string
+		contentType string
+		body        string
+		token       string
+		method      string
+		want        int
+		wantCode    string
+	}{
+		{"valid body", "/api/items", gin.MIMEJSON, `{"name":"a"}`, "", http.MethodPost, http.StatusCreated, ""},
+		{"missing field", "/api/items", gin.MIMEJSON, `{}`, "", http.MethodPost, http.StatusBadRequest, "openapi.request_invalid"},
+		{"wrong type", "/api/items", gin.MIMEJSON, `{"name":1}`, "", http.MethodPost, http.StatusBadRequest, "openapi.request_invalid"},
+		{"other format skips the body", "/api/items", "text/csv", "name\n", "", http.MethodPost, http.StatusCreated, ""},
+		{"undocumented route", "/api/undocumented", "", "", "", http.MethodGet, http.StatusCreated, ""},
+		{"anonymous bad query", "/api/private?limit=x", "", "", "", http.MethodGet, http.StatusUnauthorized, "auth.missing_token"},
+		{"authenticated bad query", "/api/private?limit=x", "", "", "good", http.MethodGet, http.StatusBadRequest, "openapi.request_invalid"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(r, tt.method, tt.path, tt.contentType, tt.body, tt.token)
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body)
+			}
+			if tt.wantCode != "" && errorCode(rec) != tt.wantCode {
+				t.Errorf("code = %q, want %q", errorCode(rec), tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestValidateResponse(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string
+		handler  gin.HandlerFunc
+		want     int
+		wantBody string
+	}{
+		{
+			name:     "matching response",
+			path:     "/api/items",
+			handler:  func(c *gin.Context) { c.JSON(http.StatusCreated, gin.H{"id": 1}) },
+			want:     http.StatusCreated,
+			wantBody: `{"id":1}`,
+		},
+		{
+			name:    "mismatching response",
+			path:    "/api/items",
+			handler: func(c *gin.Context) { c.JSON(http.StatusCreated, gin.H{"id": "one"}) },
+			want:    http.StatusInternalServerError,
+		},
+		{
+			name:     "streamed response",
+			path:     "/api/events",
+			handler:  func(c *gin.Context) { c.Data(http.StatusOK, "text/event-stream", []byte("data: 1\n\n")) },
+			want:     http.StatusOK,
+			wantBody: "data: 1\n\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newValidated(t, tt.handler)
+			method := http.MethodGet
+			if tt.path == "/api/items" {
+				method = http.MethodPost
+			}
+			rec := serve(r, method, tt.path, gin.MIMEJSON, `{"name":"a"}`, "")
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body)
+			}
+			if tt.want == http.StatusInternalServerError {
+				if code := errorCode(rec); code != "openapi.response_invalid" {
+					t.Errorf("code = %q, want openapi.response_invalid", code)
+				}
+				return
+			}
+			if rec.Body.String() != tt.wantBody {
+				t.Errorf("body = %q, want %q", rec.Body, tt.wantBody)
+			}
+		})
+	}
+}
@@ -2,17 +2,36 @@ package otel
 
 import (
 	"go.opentelemetry.io/otel/sdk/trace"
+	"math"
+	"service/config"
+	"sync/atomic"
 )
 
+// probability holds the sampling ratio as float64 bits so it can be changed
+// on config reload without rebuilding the tracer provider.
+var probability atomic.Uint64
+
+// SetProbability changes the sampling ratio used by every sampler created
+// by InitTracing.
+func SetProbability(p float64) {
+	probability.Store(math.Float64bits(p))
+}
+
+// SubscribeConfig applies reloaded sampling probabilities.
+func SubscribeConfig(store *config.Store) {
+	store.Subscribe("otel", func(cfg *config.Config) {
+		SetProbability(cfg.Otel.Probability)
+	})
+}
+
 type endpointExcluder struct {
-	endpoints   map[string]struct{}
-	probability float64
+	endpoints map[string]struct{}
 }
 
-func newEndpointExcluder(endpoints map[string]struct{}, probability float64) endpointExcluder {
+func newEndpointExcluder(endpoints map[string]struct{}, p float64) endpointExcluder {
+	SetProbability(p)
 	return endpointExcluder{
-		endpoints:   endpoints,
-		probability: probability,
+		endpoints: endpoints,
 	}
 }
 
@@ -27,7 +46,8 @@ func (ee endpointExcluder) ShouldSample(parameters trace.SamplingParameters) tra
 		}
 	}
 
-	return trace.TraceIDRatioBased(ee.probability).ShouldSample(parameters)
+	p := math.Float64frombits(probability.Load())
+	return trace.TraceIDRatioBased(p).ShouldSample(parameters)
 }
 
 // Description implements the sampler interface.
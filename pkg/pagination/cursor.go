@@ -0,0 +1,61 @@
+package pagination
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"time"
+)
+
+// cursor is the content of the opaque token. Values are the sort values of
+// a boundary row. They go through JSON, so numbers come back as
+// json.Number; times are tagged to come back as time.Time so backends that
+// compare by type (MongoDB) still match them.
+type cursor struct {
+	Sort     string `json:"s"`
+	Values   []any  `json:"v"`
+	Backward bool   `json:"b,omitempty"`
+}
+
+type timeValue struct {
+	Time time.Time `json:"t"`
+}
+
+func encodeCursor(c cursor) string {
+	values := make([]any, len(c.Values))
+	for i, v := range c.Values {
+		if t, ok := v.(time.Time); ok {
+			v = timeValue{Time: t}
+		}
+		values[i] = v
+	}
+	c.Values = values
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeCursor(token string) (cursor, error) {
+	c := cursor{}
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return c, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	if err := dec.Decode(&c); err != nil {
+		return c, err
+	}
+	for i, v := range c.Values {
+		m, ok := v.(map[string]any)
+		if !ok {
+			continue
+		}
+		s, _ := m["t"].(string)
+		t, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			return c, err
+		}
+		c.Values[i] = t
+	}
+	return c, nil
+}
@@ -0,0 +1,60 @@
+package pagination
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"net/url"
+	"strings"
+)
+
+// Envelope is the body of every list response.
+type Envelope[T any] struct {
+	Data []T  `json:"data"`
+	Meta Meta `json:"meta"`
+}
+
+type Meta struct {
+	Limit      int    `json:"limit"`
+	Page       int    `json:"page,omitempty"`
+	Total      *int64 `json:"total,omitempty"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
+}
+
+// Bind parses the list parameters of the request.
+func Bind(c *gin.Context, opts Options) (Request, error) {
+	return Parse(c.Request.URL.Query(), opts)
+}
+
+// NewEnvelope converts the page for encoding.
+func NewEnvelope[T any](page *Page[T]) Envelope[T] {
+	env := Envelope[T]{Data: page.Items, Meta: Meta{Limit: page.Limit}}
+	if page.Page > 0 {
+		env.Meta.Page = page.Page
+		env.Meta.Total = &page.Total
+	} else {
+		env.Meta.NextCursor, env.Meta.PrevCursor = page.Next, page.Prev
+	}
+	return env
+}
+
+// Link formats the RFC 8288 Link header value for the page, keeping the
+// other query parameters of u.
+func Link[T any](u *url.URL, page *Page[T]) string {
+	param := "cursor"
+	if page.Page > 0 {
+		param = "page"
+	}
+	var links []string
+	for _, l := range []struct{ rel, value string }{{"next", page.Next}, {"prev", page.Prev}} {
+		if l.value == "" {
+			continue
+		}
+		target := *u
+		query := target.Query()
+		query.Set(param, l.value)
+		target.RawQuery = query.Encode()
+		links = append(links, fmt.Sprintf("<%s>; rel=%q", target.RequestURI(), l.rel))
+	}
+	return strings.Join(links, ", ")
+}
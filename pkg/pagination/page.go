@@ -0,0 +1,64 @@
+package pagination
+
+import (
+	"slices"
+	"strconv"
+)
+
+// Page is one page of a list.
+type Page[T any] struct {
+	Items []T
+	Limit int
+	// Page and Total are only set in page mode.
+	Page  int
+	Total int64
+	// Next and Prev are cursor tokens in cursor mode; in page mode they are
+	// the neighbouring page numbers. Empty when there is no such page.
+	Next string
+	Prev string
+}
+
+// NewPage builds the page from rows read with QuerySort and FetchLimit.
+// key returns the values of a row for the fields of req.Sort, in order.
+func NewPage[T any](req Request, rows []T, total int64, key func(T) []any) *Page[T] {
+	more := len(rows) > req.Limit
+	if more {
+		rows = rows[:req.Limit]
+	}
+	page := &Page[T]{Items: rows, Limit: req.Limit}
+	if page.Items == nil {
+		page.Items = []T{}
+	}
+
+	if !req.IsCursor() {
+		page.Page, page.Total = req.Page, total
+		if more {
+			page.Next = strconv.Itoa(req.Page + 1)
+		}
+		if req.Page > 1 {
+			page.Prev = strconv.Itoa(req.Page - 1)
+		}
+		return page
+	}
+
+	if req.Backward {
+		slices.Reverse(rows)
+	}
+	// reading forward there is a previous page once a cursor was followed
+	// and a next one if the extra row came back; backward it is the reverse
+	hasNext, hasPrev := more, req.After != nil
+	if req.Backward {
+		hasNext, hasPrev = true, more
+	}
+	if len(rows) == 0 {
+		return page
+	}
+	sort := formatSort(req.Sort)
+	if hasNext {
+		page.Next = encodeCursor(cursor{Sort: sort, Values: key(rows[len(rows)-1])})
+	}
+	if hasPrev {
+		page.Prev = encodeCursor(cursor{Sort: sort, Values: key(rows[0]), Backward: true})
+	}
+	return page
+}
@@ -0,0 +1,175 @@
+// Package pagination is the list contract shared by every list endpoint. A
+// request is parsed once into a Request, whichever backend serves it, and
+// the result is answered with the same envelope and Link headers.
+//
+// Two modes are supported. With `page` the backend skips (page-1)*limit
+// rows and the total is counted. Without it the list is walked with opaque
+// cursors: the backend seeks past the sort values of the last row it
+// returned, which stays stable while rows are inserted.
+package pagination
+
+import (
+	"net/url"
+	"service/pkg/apperror"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	DefaultLimit = 20
+	MaxLimit     = 100
+)
+
+// ErrInvalid is returned, with the offending parameter under the "param"
+// detail, when page, limit, cursor or sort cannot be used.
+var ErrInvalid = apperror.Validation("pagination.invalid", "invalid pagination parameters")
+
+// Sort orders by one field. Field is the name clients use, which is also
+// the column, document or index field name in every backend.
+type Sort struct {
+	Field string
+	Desc  bool
+}
+
+// Options are the limits of one list endpoint.
+type Options struct {
+	// DefaultLimit and MaxLimit fall back to the package defaults when 0.
+	DefaultLimit int
+	MaxLimit     int
+	// Sortable lists the fields clients may sort by.
+	Sortable []string
+	// DefaultSort applies when the request has no sort.
+	DefaultSort []Sort
+	// Key is the unique field appended to every sort so rows with equal
+	// sort values keep a stable order and cursors never skip one.
+	Key string
+}
+
+// Request is a parsed list request.
+type Request struct {
+	// Page is 1-based and 0 in cursor mode.
+	Page  int
+	Limit int
+	// Sort always ends with the unique key.
+	Sort []Sort
+	// After holds the sort values of the row to seek past, nil on the first
+	// page in cursor mode.
+	After []any
+	// Backward is set when After comes from a previous-page cursor. The
+	// backend then reads in the reverse order, see QuerySort.
+	Backward bool
+}
+
+// Parse reads `page`, `limit`, `cursor` and `sort` from query.
+func Parse(query url.Values, opts Options) (Request, error) {
+	if opts.DefaultLimit == 0 {
+		opts.DefaultLimit = DefaultLimit
+	}
+	if opts.MaxLimit == 0 {
+		opts.MaxLimit = MaxLimit
+	}
+	req := Request{Limit: opts.DefaultLimit}
+
+	if v := query.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > opts.MaxLimit {
+			return req, ErrInvalid.WithDetail("param", "limit").WithDetail("max", opts.MaxLimit)
+		}
+		req.Limit = limit
+	}
+
+	sort, err := parseSort(query.Get("sort"), opts)
+	if err != nil {
+		return req, err
+	}
+	req.Sort = sort
+
+	page, cursor := query.Get("page"), query.Get("cursor")
+	switch {
+	case page != "" && cursor != "":
+		return req, ErrInvalid.WithDetail("param", "page").WithDetail("reason", "page and cursor are exclusive")
+	case page != "":
+		req.Page, err = strconv.Atoi(page)
+		if err != nil || req.Page < 1 {
+			return req, ErrInvalid.WithDetail("param", "page")
+		}
+	case cursor != "":
+		c, err := decodeCursor(cursor)
+		// a cursor only makes sense with the sort it was issued for
+		if err != nil || c.Sort != formatSort(req.Sort) || len(c.Values) != len(req.Sort) {
+			return req, ErrInvalid.WithDetail("param", "cursor")
+		}
+		req.After, req.Backward = c.Values, c.Backward
+	}
+	return req, nil
+}
+
+func parseSort(raw string, opts Options) ([]Sort, error) {
+	var sort []Sort
+	if raw == "" {
+		sort = slices.Clone(opts.DefaultSort)
+	}
+	for _, field := range strings.Split(raw, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		s := Sort{Field: strings.TrimPrefix(field, "-"), Desc: strings.HasPrefix(field, "-")}
+		if !slices.Contains(opts.Sortable, s.Field) && s.Field != opts.Key {
+			return nil, ErrInvalid.WithDetail("param", "sort").WithDetail("allowed", opts.Sortable)
+		}
+		if slices.ContainsFunc(sort, func(o Sort) bool { return o.Field == s.Field }) {
+			return nil, ErrInvalid.WithDetail("param", "sort").WithDetail("reason", "duplicate field "+s.Field)
+		}
+		sort = append(sort, s)
+	}
+	if opts.Key != "" && !slices.ContainsFunc(sort, func(s Sort) bool { return s.Field == opts.Key }) {
+		sort = append(sort, Sort{Field: opts.Key})
+	}
+	return sort, nil
+}
+
+func formatSort(sort []Sort) string {
+	fields := make([]string, len(sort))
+	for i, s := range sort {
+		fields[i] = s.Field
+		if s.Desc {
+			fields[i] = "-" + s.Field
+		}
+	}
+	return strings.Join(fields, ",")
+}
+
+// Offset is the number of rows to skip, 0 in cursor mode.
+func (r Request) Offset() int {
+	if r.Page < 1 {
+		return 0
+	}
+	return (r.Page - 1) * r.Limit
+}
+
+// FetchLimit is one more than Limit; the extra row tells NewPage whether
+// there is another page without counting.
+func (r Request) FetchLimit() int {
+	return r.Limit + 1
+}
+
+// IsCursor reports whether the request walks the list with cursors.
+func (r Request) IsCursor() bool {
+	return r.Page == 0
+}
+
+// QuerySort is the order the backend reads in: Sort, reversed when paging
+// backward so the rows just before the cursor come first. NewPage restores
+// the order.
+func (r Request) QuerySort() []Sort {
+	if !r.Backward {
+		return r.Sort
+	}
+	sort := make([]Sort, len(r.Sort))
+	for i, s := range r.Sort {
+		sort[i] = Sort{Field: s.Field, Desc: !s.Desc}
+	}
+	return sort
+}
@@ -0,0 +1,204 @@
+package pagination
+
+import (
+	"cmp"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"service/pkg/apperror"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type row struct {
+	ID      string
+	Created time.Time
+}
+
+var testOptions = Options{
+	DefaultLimit: 2,
+	Sortable:     []string{"created_at"},
+	DefaultSort:  []Sort{{Field: "created_at", Desc: true}},
+	Key:          "id",
+}
+
+func rowKey(r row) []any {
+	return []any{r.Created, r.ID}
+}
+
+// compareKeys orders two keys of rowKey by sort.
+func compareKeys(a, b []any, sort []Sort) int {
+	for i, s := range sort {
+		var c int
+		switch av := a[i].(type) {
+		case time.Time:
+			c = av.Compare(b[i].(time.Time))
+		case string:
+			c = cmp.Compare(av, b[i].(string))
+		}
+		if s.Desc {
+			c = -c
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// list serves a cursor request from rows like a backend: seek past After
+// in QuerySort order and read FetchLimit rows.
+func list(t *testing.T, rows []row, query url.Values) *Page[row] {
+	t.Helper()
+	req, err := Parse(query, testOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort := req.QuerySort()
+	sorted := slices.Clone(rows)
+	slices.SortFunc(sorted, func(a, b row) int { return compareKeys(rowKey(a), rowKey(b), sort) })
+
+	var out []row
+	for _, r := range sorted {
+		if req.After != nil && compareKeys(rowKey(r), req.After, sort) <= 0 {
+			continue
+		}
+		out = append(out, r)
+		if len(out) == req.FetchLimit() {
+			break
+		}
+	}
+	return NewPage(req, out, int64(len(rows)), rowKey)
+}
+
+func ids(page *Page[row]) string {
+	out := make([]string, len(page.Items))
+	for i, r := range page.Items {
+		out[i] = r.ID
+	}
+	return strings.Join(out, ",")
+}
+
+func TestCursorWalk(t *testing.T) {
+	base := time.Date(2026, 1, 1, 0, 0, 0, 123456789, time.UTC)
+	// b and c share a timestamp, the ascending id keeps their order stable
+	rows := []row{
+		{ID: "a", Created: base.Add(4 * time.Hour)},
+		{ID: "b", Created: base.Add(3 * time.Hour)},
+		{ID: "c", Created: base.Add(3 * time.Hour)},
+		{ID: "d", Created: base.Add(2 * time.Hour)},
+		{ID: "e", Created: base.Add(time.Hour)},
+	}
+
+	first := list(t, rows, url.Values{})
+	if ids(first) != "a,b" || first.Prev != "" || first.Next == "" {
+		t.Fatalf("first page %s prev %q next %q", ids(first), first.Prev, first.Next)
+	}
+	second := list(t, rows, url.Values{"cursor": {first.Next}})
+	if ids(second) != "c,d" || second.Prev == "" || second.Next == "" {
+		t.Fatalf("second page %s prev %q next %q", ids(second), second.Prev, second.Next)
+	}
+	last := list(t, rows, url.Values{"cursor": {second.Next}})
+	if ids(last) != "e" || last.Next != "" {
+		t.Fatalf("last page %s next %q", ids(last), last.Next)
+	}
+
+	back := list(t, rows, url.Values{"cursor": {last.Prev}})
+	if ids(back) != "c,d" {
+		t.Errorf("back from the last page: %s", ids(back))
+	}
+	back = list(t, rows, url.Values{"cursor": {back.Prev}})
+	if ids(back) != "a,b" {
+		t.Errorf("back to the first page: %s", ids(back))
+	}
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	created := time.Date(2026, 1, 1, 12, 30, 0, 123456789, time.FixedZone("WIB", 7*3600))
+	req, err := Parse(url.Values{"sort": {"-created_at"}}, testOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token := encodeCursor(cursor{Sort: formatSort(req.Sort), Values: []any{created, "id-1"}, Backward: true})
+
+	got, err := Parse(url.Values{"sort": {"-created_at"}, "cursor": {token}}, testOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Backward || len(got.After) != 2 {
+		t.Fatalf("request = %+v", got)
+	}
+	if at, ok := got.After[0].(time.Time); !ok || !at.Equal(created) {
+		t.Errorf("time value = %#v, want %v", got.After[0], created)
+	}
+	if got.After[1] != "id-1" {
+		t.Errorf("key value = %#v", got.After[1])
+	}
+}
+
+func TestParseRejects(t *testing.T) {
+	valid := list(t, []row{{ID: "a"}, {ID: "b"}, {ID: "c"}}, url.Values{}).Next
+	raw, _ := base64.RawURLEncoding.DecodeString(valid)
+
+	for _, tc := range []struct {
+		name  string
+		query url.Values
+		param string
+	}{
+		{"garbage cursor", url.Values{"cursor": {"not a cursor!"}}, "cursor"},
+		{"truncated cursor", url.Values{"cursor": {valid[:len(valid)-4]}}, "cursor"},
+		{"cursor of another sort", url.Values{"cursor": {valid}, "sort": {"created_at"}}, "cursor"},
+		{"edited sort", url.Values{"cursor": {base64.RawURLEncoding.EncodeToString(
+			[]byte(strings.Replace(string(raw), `"s":"-created_at,id"`, `"s":"created_at,id"`, 1)))}}, "cursor"},
+		{"missing values", url.Values{"cursor": {base64.RawURLEncoding.EncodeToString([]byte(`{"s":"-created_at,id","v":["x"]}`))}}, "cursor"},
+		{"bad time", url.Values{"cursor": {base64.RawURLEncoding.EncodeToString([]byte(`{"s":"-created_at,id","v":[{"t":"yesterday"},"x"]}`))}}, "cursor"},
+		{"page and cursor", url.Values{"cursor": {valid}, "page": {"2"}}, "page"},
+		{"page zero", url.Values{"page": {"0"}}, "page"},
+		{"limit too large", url.Values{"limit": {"101"}}, "limit"},
+		{"unknown sort", url.Values{"sort": {"password"}}, "sort"},
+		{"duplicate sort", url.Values{"sort": {"created_at,-created_at"}}, "sort"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Parse(tc.query, testOptions)
+			if !errors.Is(err, ErrInvalid) {
+				t.Fatalf("err = %v, want ErrInvalid", err)
+			}
+			if param := apperror.From(err).Details["param"]; param != tc.param {
+				t.Errorf("param = %v, want %s", param, tc.param)
+			}
+		})
+	}
+}
+
+func TestBindTamperedCursor(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/rows", func(c *gin.Context) {
+		if _, err := Bind(c, testOptions); err != nil {
+			apperror.WriteHTTP(c, err)
+			return
+		}
+		c.Status(http.StatusNoContent)
+	})
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/rows?cursor=eyJzIjoiaWQifQ", nil))
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), `"code":"pagination.invalid"`) {
+		t.Errorf("%d %s", rec.Code, rec.Body)
+	}
+}
+
+func TestLink(t *testing.T) {
+	u, _ := url.Parse("/rows?sort=name&page=2")
+	link := Link(u, &Page[row]{Page: 2, Next: "3", Prev: "1"})
+	want := `</rows?page=3&sort=name>; rel="next", </rows?page=1&sort=name>; rel="prev"`
+	if link != want {
+		t.Errorf("Link = %s, want %s", link, want)
+	}
+}
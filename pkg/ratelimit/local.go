@@ -0,0 +1,126 @@
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// maxLocalKeys bounds the fallback limiter's memory; expired entries are
+// swept once it is reached.
+const maxLocalKeys = 100_000
+
+// local mirrors the Lua scripts in process. It only sees this replica's
+// traffic, so while Redis is down the effective limit grows with the number
+// of replicas.
+type local struct {
+	mu      sync.Mutex
+	windows map[string]*windowState
+	buckets map[string]*bucketState
+}
+
+type windowState struct {
+	start      time.Time
+	cur, prev  int
+	expiration time.Time
+}
+
+type bucketState struct {
+	tokens float64
+	ts     time.Time
+	full   time.Time
+}
+
+func newLocal() *local {
+	return &local{
+		windows: map[string]*windowState{},
+		buckets: map[string]*bucketState{},
+	}
+}
+
+func (l *local) slidingWindow(key string, limit int, window time.Duration, now time.Time) Result {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	start := now.Truncate(window)
+	st, ok := l.windows[key]
+	if !ok {
+		l.sweep(now)
+		st = &windowState{start: start}
+		l.windows[key] = st
+	}
+	switch {
+	case st.start.Equal(start):
+	case st.start.Add(window).Equal(start):
+		st.prev, st.cur, st.start = st.cur, 0, start
+	default:
+		st.prev, st.cur, st.start = 0, 0, start
+	}
+	st.expiration = start.Add(2 * window)
+
+	elapsed := now.Sub(start)
+	left := window - elapsed
+	count := float64(st.prev)*float64(left)/float64(window) + float64(st.cur)
+
+	res := Result{Limit: limit, Reset: left}
+	if count+1 > float64(limit) {
+		res.RetryAfter = left
+		if st.prev > 0 && limit-1-st.cur >= 0 {
+			res.RetryAfter = left - time.Duration(float64(limit-1-st.cur)*float64(window)/float64(st.prev))
+		}
+		res.RetryAfter = max(res.RetryAfter, time.Millisecond)
+		return res
+	}
+
+	st.cur++
+	res.Allowed = true
+	res.Remaining = int(math.Floor(float64(limit) - count - 1))
+	return res
+}
+
+func (l *local) tokenBucket(key string, capacity int, window time.Duration, now time.Time) Result {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	st, ok := l.buckets[key]
+	if !ok {
+		l.sweep(now)
+		st = &bucketState{tokens: float64(capacity), ts: now}
+		l.buckets[key] = st
+	}
+
+	rate := float64(capacity) / float64(window)
+	st.tokens = math.Min(float64(capacity), st.tokens+float64(max(now.Sub(st.ts), 0))*rate)
+	st.ts = now
+
+	res := Result{Limit: capacity}
+	if st.tokens >= 1 {
+		st.tokens--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = time.Duration(math.Ceil((1 - st.tokens) / rate))
+	}
+	res.Remaining = int(math.Floor(st.tokens))
+	res.Reset = time.Duration(math.Ceil((float64(capacity) - st.tokens) / rate))
+	st.full = now.Add(res.Reset)
+	return res
+}
+
+// sweep drops idle entries once the maps grow too large. Called with mu held.
+func (l *local) sweep(now time.Time) {
+	if len(l.windows) >= maxLocalKeys {
+		for k, st := range l.windows {
+			if now.After(st.expiration) {
+				delete(l.windows, k)
+			}
+		}
+	}
+	if len(l.buckets) >= maxLocalKeys {
+		for k, st := range l.buckets {
+			// a refilled bucket is the same as a new one
+			if now.After(st.full) {
+				delete(l.buckets, k)
+			}
+		}
+	}
+}
@@ -0,0 +1,140 @@
+// Package ratelimit enforces request rate limits shared by every replica
+// through Redis, falling back to an in-process limiter when Redis fails.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"service/config"
+	"service/pkg/logger"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// ScriptRunner runs Lua scripts, see redis.Redis.RunScript.
+type ScriptRunner interface {
+	RunScript(ctx context.Context, script *redis.Script, keys []string, args ...interface{}) (interface{}, error)
+}
+
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// Reset is when the limit is fully available again.
+	Reset time.Duration
+	// RetryAfter is set when the request was denied.
+	RetryAfter time.Duration
+}
+
+type Limiter struct {
+	redis ScriptRunner
+	local *local
+	cfg   atomic.Pointer[config.RateLimit]
+	// skipUntil keeps requests off Redis for a while after a failure so
+	// they do not all wait for a timeout
+	skipUntil atomic.Int64
+	// lastWarn throttles the fallback warning to one per minute
+	lastWarn atomic.Int64
+}
+
+// redisCooldown is how long the in-process limiter is used after a Redis
+// failure before Redis is tried again.
+const redisCooldown = 5 * time.Second
+
+// NewLimiter creates a limiter on runner, or an in-process only one when
+// runner is nil.
+func NewLimiter(runner ScriptRunner, cfg *config.RateLimit) *Limiter {
+	l := &Limiter{redis: runner, local: newLocal()}
+	l.SetConfig(cfg)
+	return l
+}
+
+func (l *Limiter) SetConfig(cfg *config.RateLimit) {
+	l.cfg.Store(cfg)
+}
+
+// SubscribeConfig applies reloaded policies.
+func (l *Limiter) SubscribeConfig(store *config.Store) {
+	store.Subscribe("ratelimit", func(cfg *config.Config) {
+		l.SetConfig(&cfg.RateLimit)
+	})
+}
+
+// Policy returns the named policy of the current config. ok is false when
+// rate limiting is disabled or the policy does not exist.
+func (l *Limiter) Policy(name string) (config.RateLimitPolicy, bool) {
+	cfg := l.cfg.Load()
+	if !cfg.Enabled {
+		return config.RateLimitPolicy{}, false
+	}
+	p, ok := cfg.Policies[name]
+	return p, ok
+}
+
+// Allow takes one request from the limit of key under p.
+func (l *Limiter) Allow(ctx context.Context, key string, p config.RateLimitPolicy) Result {
+	now := time.Now()
+	if l.redis != nil && now.UnixNano() >= l.skipUntil.Load() {
+		res, err := l.allowRedis(ctx, key, p, now)
+		if err == nil {
+			return res
+		}
+		l.skipUntil.Store(now.Add(redisCooldown).UnixNano())
+		l.warnFallback(err)
+	}
+
+	if p.Algorithm == config.RateLimitTokenBucket {
+		return l.local.tokenBucket(key, p.Limit, p.Window, now)
+	}
+	return l.local.slidingWindow(key, p.Limit, p.Window, now)
+}
+
+func (l *Limiter) allowRedis(ctx context.Context, key string, p config.RateLimitPolicy, now time.Time) (Result, error) {
+	window := p.Window.Milliseconds()
+	nowMs := now.UnixMilli()
+
+	var (
+		raw interface{}
+		err error
+	)
+	if p.Algorithm == config.RateLimitTokenBucket {
+		raw, err = l.redis.RunScript(ctx, tokenBucketScript, []string{key}, p.Limit, window, nowMs)
+	} else {
+		idx := nowMs / window
+		keys := []string{key + ":" + strconv.FormatInt(idx, 10), key + ":" + strconv.FormatInt(idx-1, 10)}
+		raw, err = l.redis.RunScript(ctx, slidingWindowScript, keys, p.Limit, window, nowMs-idx*window)
+	}
+	if err != nil {
+		return Result{}, err
+	}
+
+	vals, ok := raw.([]interface{})
+	if !ok || len(vals) != 4 {
+		return Result{}, fmt.Errorf("unexpected script result %v", raw)
+	}
+	n := make([]int64, len(vals))
+	for i, v := range vals {
+		if n[i], ok = v.(int64); !ok {
+			return Result{}, fmt.Errorf("unexpected script result %v", raw)
+		}
+	}
+
+	return Result{
+		Allowed:    n[0] == 1,
+		Limit:      p.Limit,
+		Remaining:  int(n[1]),
+		RetryAfter: time.Duration(n[2]) * time.Millisecond,
+		Reset:      time.Duration(n[3]) * time.Millisecond,
+	}, nil
+}
+
+func (l *Limiter) warnFallback(err error) {
+	now := time.Now().Unix()
+	last := l.lastWarn.Load()
+	if now-last < 60 || !l.lastWarn.CompareAndSwap(last, now) {
+		return
+	}
+	logger.Logger.Warn("rate limiter falling back to in-process limits", logger.F("error", err.Error()))
+}
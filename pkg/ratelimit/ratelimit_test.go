@@ -0,0 +1,119 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"service/config"
+	"service/pkg/datastore/redis"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	goredis "github.com/redis/go-redis/v9"
+)
+
+// backend takes one request at now, from Redis or in process.
+type backend func(key string, p config.RateLimitPolicy, now time.Time) Result
+
+func backends(t *testing.T) map[string]backend {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	cfg := config.NewConfig()
+	cfg.Redis.Host = mr.Addr()
+	rdb := redis.NewRedis(context.Background(), &cfg)
+	t.Cleanup(func() { _ = rdb.Close() })
+
+	l := NewLimiter(rdb, &cfg.RateLimit)
+	return map[string]backend{
+		"lua": func(key string, p config.RateLimitPolicy, now time.Time) Result {
+			res, err := l.allowRedis(context.Background(), key, p, now)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return res
+		},
+		"local": func(key string, p config.RateLimitPolicy, now time.Time) Result {
+			if p.Algorithm == config.RateLimitTokenBucket {
+				return l.local.tokenBucket(key, p.Limit, p.Window, now)
+			}
+			return l.local.slidingWindow(key, p.Limit, p.Window, now)
+		},
+	}
+}
+
+type step struct {
+	at         time.Duration
+	allowed    bool
+	remaining  int
+	retryAfter time.Duration
+}
+
+func run(t *testing.T, allow backend, p config.RateLimitPolicy, steps []step) {
+	t.Helper()
+	// windows are aligned to the epoch, start on a boundary
+	start := time.UnixMilli(1_700_000_000_000).Truncate(p.Window)
+	for i, s := range steps {
+		res := allow("test", p, start.Add(s.at))
+		if res.Allowed != s.allowed || res.Remaining != s.remaining || res.RetryAfter != s.retryAfter {
+			t.Errorf("step %d at %s: allowed %v remaining %d retry %s, want %v %d %s",
+				i, s.at, res.Allowed, res.Remaining, res.RetryAfter, s.allowed, s.remaining, s.retryAfter)
+		}
+		if res.Limit != p.Limit {
+			t.Errorf("step %d: limit %d", i, res.Limit)
+		}
+	}
+}
+
+func TestSlidingWindow(t *testing.T) {
+	p := config.RateLimitPolicy{Algorithm: config.RateLimitSlidingWindow, Limit: 2, Window: time.Second}
+	for name, allow := range backends(t) {
+		t.Run(name, func(t *testing.T) {
+			run(t, allow, p, []step{
+				{at: 0, allowed: true, remaining: 1},
+				{at: 100 * time.Millisecond, allowed: true, remaining: 0},
+				{at: 200 * time.Millisecond, retryAfter: 800 * time.Millisecond},
+				// the next window still weighs half of the previous one
+				{at: 1500 * time.Millisecond, allowed: true, remaining: 0},
+				{at: 1600 * time.Millisecond, retryAfter: 400 * time.Millisecond},
+				// both windows are past
+				{at: 3 * time.Second, allowed: true, remaining: 1},
+			})
+		})
+	}
+}
+
+func TestTokenBucket(t *testing.T) {
+	p := config.RateLimitPolicy{Algorithm: config.RateLimitTokenBucket, Limit: 2, Window: time.Second}
+	for name, allow := range backends(t) {
+		t.Run(name, func(t *testing.T) {
+			run(t, allow, p, []step{
+				{at: 0, allowed: true, remaining: 1},
+				{at: 0, allowed: true, remaining: 0},
+				{at: 0, retryAfter: 500 * time.Millisecond},
+				// one token refilled
+				{at: 500 * time.Millisecond, allowed: true, remaining: 0},
+				{at: 3 * time.Second, allowed: true, remaining: 1},
+			})
+		})
+	}
+}
+
+type failingRunner struct{}
+
+func (failingRunner) RunScript(ctx context.Context, script *goredis.Script, keys []string, args ...interface{}) (interface{}, error) {
+	return nil, errors.New("connection refused")
+}
+
+func TestFallbackToLocal(t *testing.T) {
+	cfg := config.NewConfig().RateLimit
+	l := NewLimiter(failingRunner{}, &cfg)
+	l.lastWarn.Store(time.Now().Unix()) // keep the warning out of the test logger
+
+	p := config.RateLimitPolicy{Algorithm: config.RateLimitSlidingWindow, Limit: 1, Window: time.Minute}
+	if res := l.Allow(context.Background(), "fallback", p); !res.Allowed {
+		t.Error("first request denied while Redis is down")
+	}
+	if res := l.Allow(context.Background(), "fallback", p); res.Allowed {
+		t.Error("in-process limit not applied")
+	}
+}
@@ -0,0 +1,65 @@
+package ratelimit
+
+import "github.com/redis/go-redis/v9"
+
+// slidingWindowScript approximates a sliding window from two fixed window
+// counters, weighting the previous one by how much of it still overlaps.
+//
+// KEYS: current window counter, previous window counter
+// ARGV: limit, window ms, ms elapsed in the current window
+// returns: allowed (0/1), remaining, retry after ms, reset ms
+var slidingWindowScript = redis.NewScript(`
+local limit = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local elapsed = tonumber(ARGV[3])
+
+local cur = tonumber(redis.call("GET", KEYS[1]) or "0")
+local prev = tonumber(redis.call("GET", KEYS[2]) or "0")
+local count = prev * (window - elapsed) / window + cur
+
+if count + 1 > limit then
+	local retry = window - elapsed
+	if prev > 0 and limit - 1 - cur >= 0 then
+		retry = math.ceil(window - elapsed - (limit - 1 - cur) * window / prev)
+	end
+	return {0, 0, math.max(retry, 1), window - elapsed}
+end
+
+redis.call("INCR", KEYS[1])
+redis.call("PEXPIRE", KEYS[1], window * 2)
+return {1, math.floor(limit - count - 1), 0, window - elapsed}
+`)
+
+// tokenBucketScript refills limit tokens evenly over the window.
+//
+// KEYS: bucket hash
+// ARGV: capacity, window ms, now ms
+// returns: allowed (0/1), remaining, retry after ms, reset ms
+var tokenBucketScript = redis.NewScript(`
+local capacity = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local rate = capacity / window
+
+local state = redis.call("HMGET", KEYS[1], "tokens", "ts")
+local tokens = tonumber(state[1])
+local ts = tonumber(state[2])
+if tokens == nil then
+	tokens = capacity
+	ts = now
+end
+tokens = math.min(capacity, tokens + math.max(0, now - ts) * rate)
+
+local allowed = 0
+local retry = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+else
+	retry = math.ceil((1 - tokens) / rate)
+end
+
+redis.call("HSET", KEYS[1], "tokens", tostring(tokens), "ts", now)
+redis.call("PEXPIRE", KEYS[1], window)
+return {allowed, math.floor(tokens), retry, math.ceil((capacity - tokens) / rate)}
+`)
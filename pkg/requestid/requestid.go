@@ -0,0 +1,28 @@
+// Package requestid carries the X-Request-ID of the request being served.
+package requestid
+
+import (
+	"context"
+	"net/http"
+)
+
+const Header = "X-Request-ID"
+
+type ctxKey struct{}
+
+func With(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, ctxKey{}, id)
+}
+
+// From returns the request ID in ctx, or "" outside a request.
+func From(ctx context.Context) string {
+	id, _ := ctx.Value(ctxKey{}).(string)
+	return id
+}
+
+// AddToRequest propagates the request ID in ctx to an outgoing request.
+func AddToRequest(ctx context.Context, r *http.Request) {
+	if id := From(ctx); id != "" {
+		r.Header.Set(Header, id)
+	}
+}
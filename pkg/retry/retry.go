@@ -0,0 +1,68 @@
+// Package retry retries operations with exponential backoff.
+package retry
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"service/config"
+	"time"
+)
+
+// Do calls fn until it succeeds, ctx is done or cfg.Timeout has elapsed.
+// Each wait grows by cfg.Multiplier up to cfg.MaxInterval, with up to 20%
+// jitter so replicas do not retry in lockstep. Each attempt gets at most
+// cfg.AttemptTimeout and never outlives cfg.Timeout. A zero Timeout makes a
+// single attempt; a negative one retries until ctx is done.
+func Do(ctx context.Context, cfg config.Retry, name string, fn func(ctx context.Context) error) error {
+	var deadline time.Time
+	if cfg.Timeout > 0 {
+		deadline = time.Now().Add(cfg.Timeout)
+	}
+
+	interval := cfg.InitialInterval
+	for attempt := 1; ; attempt++ {
+		attemptCtx, cancel := attemptContext(ctx, cfg.AttemptTimeout, deadline)
+		err := fn(attemptCtx)
+		cancel()
+		if err == nil {
+			if attempt > 1 {
+				log.Printf("%s ready after %d attempts", name, attempt)
+			}
+			return nil
+		}
+
+		wait := interval + time.Duration(rand.Float64()*0.2*float64(interval))
+		if cfg.Timeout == 0 || (!deadline.IsZero() && time.Now().Add(wait).After(deadline)) {
+			return fmt.Errorf("%s not ready after %d attempts: %w", name, attempt, err)
+		}
+
+		log.Printf("%s not ready (attempt %d), retrying in %s: %v", name, attempt, wait.Round(time.Millisecond), err)
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("%s not ready: %w", name, ctx.Err())
+		case <-timer.C:
+		}
+
+		interval = time.Duration(float64(interval) * cfg.Multiplier)
+		if interval > cfg.MaxInterval {
+			interval = cfg.MaxInterval
+		}
+	}
+}
+
+// attemptContext bounds one attempt by timeout and the overall deadline,
+// whichever comes first. Zero values do not bound it.
+func attemptContext(ctx context.Context, timeout time.Duration, deadline time.Time) (context.Context, context.CancelFunc) {
+	if timeout > 0 && (deadline.IsZero() || time.Now().Add(timeout).Before(deadline)) {
+		return context.WithTimeout(ctx, timeout)
+	}
+	if !deadline.IsZero() {
+		return context.WithDeadline(ctx, deadline)
+	}
+	return context.WithCancel(ctx)
+}
@@ -0,0 +1,111 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"service/config"
+	"strings"
+	"testing"
+	"time"
+)
+
+var errRefused = errors.New("connection refused")
+
+// failing returns a dependency that fails n times, then succeeds, and the
+// times of its attempts.
+func failing(n int) (func(ctx context.Context) error, *[]time.Time) {
+	var attempts []time.Time
+	return func(ctx context.Context) error {
+		attempts = append(attempts, time.Now())
+		if len(attempts) <= n {
+			return errRefused
+		}
+		return nil
+	}, &attempts
+}
+
+func TestDoBackoff(t *testing.T) {
+	cfg := config.Retry{InitialInterval: 10 * time.Millisecond, MaxInterval: 40 * time.Millisecond, Multiplier: 2, Timeout: -1}
+	fn, attempts := failing(4)
+	if err := Do(context.Background(), cfg, "db", fn); err != nil {
+		t.Fatal(err)
+	}
+	if len(*attempts) != 5 {
+		t.Fatalf("%d attempts, want 5", len(*attempts))
+	}
+	// 10ms, 20ms, then capped at 40ms, each plus up to 20% jitter
+	for i, want := range []time.Duration{10, 20, 40, 40} {
+		want *= time.Millisecond
+		gap := (*attempts)[i+1].Sub((*attempts)[i])
+		if gap < want || gap > want*12/10+50*time.Millisecond {
+			t.Errorf("wait %d = %s, want %s plus jitter", i+1, gap, want)
+		}
+	}
+}
+
+func TestDoGivesUp(t *testing.T) {
+	cfg := config.Retry{InitialInterval: 10 * time.Millisecond, MaxInterval: 10 * time.Millisecond, Multiplier: 1, Timeout: 50 * time.Millisecond}
+	fn, attempts := failing(1000)
+
+	start := time.Now()
+	err := Do(context.Background(), cfg, "db", fn)
+	if !errors.Is(err, errRefused) || !strings.Contains(err.Error(), "db not ready after") {
+		t.Errorf("err = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 50*time.Millisecond+100*time.Millisecond {
+		t.Errorf("gave up after %s", elapsed)
+	}
+	if len(*attempts) < 2 {
+		t.Errorf("%d attempts", len(*attempts))
+	}
+
+	cfg.Timeout = 0
+	fn, attempts = failing(1)
+	if err := Do(context.Background(), cfg, "db", fn); err == nil || len(*attempts) != 1 {
+		t.Errorf("zero timeout: %d attempts, err %v", len(*attempts), err)
+	}
+}
+
+func TestDoCancelled(t *testing.T) {
+	cfg := config.Retry{InitialInterval: time.Hour, MaxInterval: time.Hour, Multiplier: 1, Timeout: -1}
+	ctx, cancel := context.WithCancel(context.Background())
+	fn, _ := failing(1000)
+	time.AfterFunc(10*time.Millisecond, cancel)
+
+	if err := Do(ctx, cfg, "db", fn); !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want context.Canceled", err)
+	}
+}
+
+func TestDoAttemptTimeout(t *testing.T) {
+	cfg := config.Retry{InitialInterval: time.Millisecond, MaxInterval: time.Millisecond, Multiplier: 1, Timeout: -1, AttemptTimeout: 20 * time.Millisecond}
+	attempts := 0
+	// hangs until its context ends, like a server that never answers
+	hung := func(ctx context.Context) error {
+		attempts++
+		if attempts == 3 {
+			return nil
+		}
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	start := time.Now()
+	if err := Do(context.Background(), cfg, "db", hung); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 40*time.Millisecond || elapsed > time.Second {
+		t.Errorf("two hung attempts took %s", elapsed)
+	}
+
+	// an attempt never outlives the total timeout
+	cfg.AttemptTimeout = time.Hour
+	cfg.Timeout = 30 * time.Millisecond
+	attempts = 0
+	start = time.Now()
+	if err := Do(context.Background(), cfg, "db", hung); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("gave up after %s", elapsed)
+	}
+}
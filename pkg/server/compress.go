@@ -0,0 +1,237 @@
+package server
+
+import (
+	"compress/gzip"
+	"github.com/gin-gonic/gin"
+	"github.com/klauspost/compress/zstd"
+	"io"
+	"net/http"
+	"service/pkg/apperror"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	encodingZstd = "zstd"
+	encodingGzip = "gzip"
+	// encodingIdentity is the uncompressed representation.
+	encodingIdentity = "identity"
+)
+
+// errEncodingNotAcceptable answers a request that rules out identity and
+// every encoding the server offers.
+var errEncodingNotAcceptable = apperror.NotAcceptable("request.encoding_not_acceptable", "no acceptable content encoding")
+
+var (
+	gzipWriters = sync.Pool{New: func() any {
+		w, _ := gzip.NewWriterLevel(nil, gzip.DefaultCompression)
+		return w
+	}}
+	zstdWriters = sync.Pool{New: func() any {
+		w, _ := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedDefault))
+		return w
+	}}
+)
+
+// encoder is what both compressors provide.
+type encoder interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+// compressMiddleware compresses bodies of at least minSize bytes with the
+// encoding the client prefers. A compressed representation has its own
+// bytes, so its ETag gets the encoding as suffix; If-None-Match is mapped
+// back before the handler compares it.
+func compressMiddleware(minSize int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Writer.Header().Add("Vary", "Accept-Encoding")
+		encoding, ok := acceptedEncoding(c.GetHeader("Accept-Encoding"))
+		if !ok {
+			apperror.AbortHTTP(c, errEncodingNotAcceptable.WithDetail("offered", []string{encodingZstd, encodingGzip, encodingIdentity}))
+			return
+		}
+		if encoding == "" || c.Request.Method == http.MethodHead {
+			c.Next()
+			return
+		}
+		if inm := c.GetHeader("If-None-Match"); inm != "" {
+			c.Request.Header.Set("If-None-Match", strings.ReplaceAll(inm, "-"+encoding+`"`, `"`))
+		}
+
+		w := &compressWriter{ResponseWriter: c.Writer, encoding: encoding, minSize: minSize}
+		c.Writer = w
+		defer func() {
+			w.finish()
+			c.Writer = w.ResponseWriter
+		}()
+
+		c.Next()
+	}
+}
+
+// acceptedEncoding picks zstd or gzip from Accept-Encoding, preferring the
+// higher q-value and zstd, then compression, on a tie. Empty means identity,
+// which is acceptable unless ruled out by "identity;q=0" or "*;q=0"; ok is
+// false when nothing offered is acceptable.
+func acceptedEncoding(header string) (encoding string, ok bool) {
+	qs := map[string]float64{}
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		q := 1.0
+		if v, found := strings.CutPrefix(strings.TrimSpace(params), "q="); found {
+			if parsed, err := strconv.ParseFloat(v, 64); err == nil {
+				q = parsed
+			}
+		}
+		qs[name] = q
+	}
+	// the wildcard covers every encoding not listed by name
+	quality := func(name string, unlisted float64) float64 {
+		if q, listed := qs[name]; listed {
+			return q
+		}
+		if q, listed := qs["*"]; listed {
+			return q
+		}
+		return unlisted
+	}
+
+	bestQ := 0.0
+	for _, name := range []string{encodingZstd, encodingGzip} {
+		if q := quality(name, 0); q > bestQ {
+			encoding, bestQ = name, q
+		}
+	}
+	identityQ := quality(encodingIdentity, 1)
+	if encoding != "" && bestQ >= identityQ {
+		return encoding, true
+	}
+	return "", identityQ > 0
+}
+
+// compressWriter holds the body back until minSize bytes are written or
+// the handler is done, then writes it compressed or as is.
+type compressWriter struct {
+	gin.ResponseWriter
+	encoding string
+	minSize  int
+	buf      []byte
+	decided  bool
+	enc      encoder
+}
+
+func (w *compressWriter) Write(b []byte) (int, error) {
+	if !w.decided {
+		w.buf = append(w.buf, b...)
+		if len(w.buf) < w.minSize {
+			return len(b), nil
+		}
+		w.decide(true)
+		if err := w.flushBuf(); err != nil {
+			return 0, err
+		}
+		return len(b), nil
+	}
+	if w.enc != nil {
+		return w.enc.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *compressWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *compressWriter) Written() bool {
+	return len(w.buf) > 0 || w.ResponseWriter.Written()
+}
+
+// WriteHeaderNow sends the headers, the ETag included, of a response
+// without a body such as a 304.
+func (w *compressWriter) WriteHeaderNow() {
+	w.tagETag()
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+// Flush sends what was written so far, for streamed responses.
+func (w *compressWriter) Flush() {
+	if !w.decided {
+		w.decide(true)
+		_ = w.flushBuf()
+	}
+	if w.enc != nil {
+		_ = w.enc.Flush()
+	}
+	w.ResponseWriter.Flush()
+}
+
+// decide compresses when asked to and the response allows it.
+func (w *compressWriter) decide(compress bool) {
+	w.decided = true
+	w.tagETag()
+	header := w.Header()
+	status := w.Status()
+	if !compress || header.Get("Content-Encoding") != "" ||
+		status == http.StatusNoContent || status == http.StatusNotModified {
+		return
+	}
+	header.Set("Content-Encoding", w.encoding)
+	header.Del("Content-Length")
+	if w.encoding == encodingZstd {
+		w.enc = zstdWriters.Get().(*zstd.Encoder)
+	} else {
+		w.enc = gzipWriters.Get().(*gzip.Writer)
+	}
+	w.enc.Reset(w.ResponseWriter)
+}
+
+func (w *compressWriter) flushBuf() error {
+	buf := w.buf
+	w.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	var err error
+	if w.enc != nil {
+		_, err = w.enc.Write(buf)
+	} else {
+		_, err = w.ResponseWriter.Write(buf)
+	}
+	return err
+}
+
+// tagETag marks the ETag with the negotiated encoding, whether or not this
+// response ends up compressed, so the tag of a URL does not depend on size.
+func (w *compressWriter) tagETag() {
+	etag := w.Header().Get("ETag")
+	if etag == "" || strings.HasSuffix(etag, "-"+w.encoding+`"`) {
+		return
+	}
+	w.Header().Set("ETag", strings.TrimSuffix(etag, `"`)+"-"+w.encoding+`"`)
+}
+
+func (w *compressWriter) finish() {
+	if !w.decided {
+		w.decide(len(w.buf) >= w.minSize)
+		_ = w.flushBuf()
+	}
+	if w.enc == nil {
+		return
+	}
+	_ = w.enc.Close()
+	// release the response writer before pooling
+	w.enc.Reset(nil)
+	if w.encoding == encodingZstd {
+		zstdWriters.Put(w.enc)
+	} else {
+		gzipWriters.Put(w.enc)
+	}
+	w.enc = nil
+}
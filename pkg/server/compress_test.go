@@ -0,0 +1,198 @@
+package server
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"service/config"
+	"service/pkg/apperror"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/klauspost/compress/zstd"
+)
+
+func TestAcceptedEncoding(t *testing.T) {
+	for _, tc := range []struct {
+		header   string
+		encoding string
+		ok       bool
+	}{
+		{"", "", true},
+		{"gzip", "gzip", true},
+		{"GZIP", "gzip", true},
+		{"gzip, zstd", "zstd", true},
+		{"br", "", true},
+		{"gzip;q=1, zstd;q=0.5", "gzip", true},
+		{"gzip, zstd;q=0", "gzip", true},
+		{"*", "zstd", true},
+		{"gzip;q=0.4, *;q=0.8", "zstd", true},
+		// the client prefers the uncompressed body
+		{"gzip;q=0.5, identity", "", true},
+		{"identity;q=0, gzip", "gzip", true},
+		{"*;q=0, identity", "", true},
+		{"identity;q=0", "", false},
+		{"*;q=0", "", false},
+		{"gzip;q=0, zstd;q=0, identity;q=0", "", false},
+		{"br, identity;q=0", "", false},
+	} {
+		encoding, ok := acceptedEncoding(tc.header)
+		if encoding != tc.encoding || ok != tc.ok {
+			t.Errorf("%q: got %q %v, want %q %v", tc.header, encoding, ok, tc.encoding, tc.ok)
+		}
+	}
+}
+
+func newCompressEngine(t *testing.T, minSize int) *gin.Engine {
+	t.Helper()
+	observeLogs(t)
+	cfg := config.NewConfig().Rest
+	cfg.Compression.Enabled = true
+	cfg.Compression.MinSize = minSize
+	return newTestEngine(t, &cfg, false)
+}
+
+func decodeBody(t *testing.T, rec *httptest.ResponseRecorder) string {
+	t.Helper()
+	var r io.Reader = rec.Body
+	switch rec.Header().Get("Content-Encoding") {
+	case encodingGzip:
+		gz, err := gzip.NewReader(rec.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r = gz
+	case encodingZstd:
+		zr, err := zstd.NewReader(rec.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer zr.Close()
+		r = zr
+	}
+	raw, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(raw)
+}
+
+func TestCompressMinSize(t *testing.T) {
+	r := newCompressEngine(t, 100)
+	large := strings.Repeat("payload ", 100)
+	r.GET("/small", func(c *gin.Context) { c.String(http.StatusOK, "tiny") })
+	r.GET("/large", func(c *gin.Context) { c.String(http.StatusOK, large) })
+	// written in pieces below the threshold, compressed once they add up
+	r.GET("/chunked", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+		for i := 0; i < 100; i++ {
+			_, _ = c.Writer.WriteString("payload ")
+		}
+	})
+
+	for _, tc := range []struct {
+		path, accept, encoding, body string
+	}{
+		{"/small", "gzip", "", "tiny"},
+		{"/large", "gzip", "gzip", large},
+		{"/large", "zstd, gzip", "zstd", large},
+		{"/large", "", "", large},
+		{"/chunked", "gzip", "gzip", large},
+	} {
+		req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+		req.Header.Set("Accept-Encoding", tc.accept)
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+
+		if got := rec.Header().Get("Content-Encoding"); got != tc.encoding {
+			t.Errorf("%s %q: Content-Encoding = %q, want %q", tc.path, tc.accept, got, tc.encoding)
+		}
+		if got := rec.Header().Values("Vary"); len(got) != 1 || got[0] != "Accept-Encoding" {
+			t.Errorf("%s %q: Vary = %v", tc.path, tc.accept, got)
+		}
+		if got := decodeBody(t, rec); got != tc.body {
+			t.Errorf("%s %q: body = %.20q..., want %.20q...", tc.path, tc.accept, got, tc.body)
+		}
+	}
+}
+
+func TestCompressETag(t *testing.T) {
+	r := newCompressEngine(t, 100)
+	body := strings.Repeat("payload ", 100)
+	for path, size := range map[string]int{"/large": len(body), "/small": 4} {
+		r.GET(path, func(c *gin.Context) {
+			c.Header("ETag", `"v1"`)
+			if c.GetHeader("If-None-Match") == `"v1"` {
+				c.Status(http.StatusNotModified)
+				return
+			}
+			c.String(http.StatusOK, body[:size])
+		})
+	}
+
+	for _, tc := range []struct {
+		path, accept, ifNoneMatch string
+		status                    int
+		etag                      string
+	}{
+		{"/large", "gzip", "", http.StatusOK, `"v1-gzip"`},
+		{"/large", "", "", http.StatusOK, `"v1"`},
+		// the tag does not depend on whether the body reached min_size
+		{"/small", "gzip", "", http.StatusOK, `"v1-gzip"`},
+		{"/large", "gzip", `"v1-gzip"`, http.StatusNotModified, `"v1-gzip"`},
+		{"/large", "zstd", `"v1-zstd"`, http.StatusNotModified, `"v1-zstd"`},
+		// a tag of another encoding is another representation
+		{"/large", "zstd", `"v1-gzip"`, http.StatusOK, `"v1-zstd"`},
+		{"/large", "", `"v1"`, http.StatusNotModified, `"v1"`},
+	} {
+		req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+		req.Header.Set("Accept-Encoding", tc.accept)
+		if tc.ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", tc.ifNoneMatch)
+		}
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+
+		if rec.Code != tc.status {
+			t.Errorf("%s %q %s: status = %d, want %d", tc.path, tc.accept, tc.ifNoneMatch, rec.Code, tc.status)
+		}
+		if got := rec.Header().Get("ETag"); got != tc.etag {
+			t.Errorf("%s %q %s: ETag = %s, want %s", tc.path, tc.accept, tc.ifNoneMatch, got, tc.etag)
+		}
+		if tc.status == http.StatusNotModified && (rec.Body.Len() > 0 || rec.Header().Get("Content-Encoding") != "") {
+			t.Errorf("%s %q: 304 with body %q, Content-Encoding %q", tc.path, tc.accept, rec.Body.Bytes(), rec.Header().Get("Content-Encoding"))
+		}
+	}
+}
+
+func TestCompressNotAcceptable(t *testing.T) {
+	r := newCompressEngine(t, 100)
+	called := false
+	r.GET("/large", func(c *gin.Context) {
+		called = true
+		c.String(http.StatusOK, "body")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/large", nil)
+	req.Header.Set("Accept-Encoding", "br, identity;q=0")
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusNotAcceptable {
+		t.Fatalf("status = %d, want 406", rec.Code)
+	}
+	if called {
+		t.Error("handler ran")
+	}
+	body := apperror.ErrorResponse{}
+	if err := json.Unmarshal(bytes.TrimSpace(rec.Body.Bytes()), &body); err != nil {
+		t.Fatal(err)
+	}
+	if body.Error.Code != "request.encoding_not_acceptable" {
+		t.Errorf("code = %q", body.Error.Code)
+	}
+}
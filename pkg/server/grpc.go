@@ -2,24 +2,131 @@ package server
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
+	"go.opentelemetry.io/otel/trace"
 	"google.golang.org/grpc"
-	"log"
+	"google.golang.org/grpc/credentials"
+	healthgrpc "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"net"
+	"os"
 	grpcController "service/app/controllers/grpc"
 	"service/config"
+	"service/pkg/health"
+	userv1 "service/proto/user/v1"
+	"time"
 )
 
-func RunGrpcServer(ctx context.Context, cfg *config.Config, grpcController *grpcController.Grpc) {
-	lis, err := net.Listen("tcp", fmt.Sprintf(":%s", cfg.Grpc.Port))
+type GrpcServer struct {
+	srv      *grpc.Server
+	port     int
+	health   *healthgrpc.Server
+	checks   *health.Registry
+	interval time.Duration
+}
+
+func NewGrpcServer(
+	cfg *config.Config,
+	tracer trace.Tracer,
+	verifier TokenVerifier,
+	grpcController *grpcController.Grpc,
+	checks *health.Registry,
+) (*GrpcServer, error) {
+	unary, stream := grpcInterceptors(&cfg.Grpc, tracer, verifier)
+	opts := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(unary),
+		grpc.ChainStreamInterceptor(stream),
+	}
+	if cfg.Grpc.TLS.CertFile != "" {
+		creds, err := grpcCredentials(&cfg.Grpc.TLS)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, grpc.Creds(creds))
+	}
+	s := grpc.NewServer(opts...)
+
+	userv1.RegisterUserServiceServer(s, grpcController.UserServer)
+
+	hs := healthgrpc.NewServer()
+	healthpb.RegisterHealthServer(s, hs)
+
+	return &GrpcServer{
+		srv:      s,
+		port:     cfg.Grpc.Port,
+		health:   hs,
+		checks:   checks,
+		interval: cfg.Health.GrpcInterval,
+	}, nil
+}
+
+// grpcCredentials serves TLS with the configured certificate. With a client
+// CA, certificates presented by clients are verified; clients without one
+// can still authenticate with a bearer token.
+func grpcCredentials(cfg *config.GrpcTLS) (credentials.TransportCredentials, error) {
+	cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
 	if err != nil {
-		log.Fatalf("grpc failed to listen: %v", err)
+		return nil, fmt.Errorf("grpc tls: %w", err)
+	}
+	tlsCfg := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+	if cfg.ClientCAFile != "" {
+		raw, err := os.ReadFile(cfg.ClientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("grpc tls client ca: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(raw) {
+			return nil, fmt.Errorf("grpc tls client ca: no certificate in %s", cfg.ClientCAFile)
+		}
+		tlsCfg.ClientCAs = pool
+		tlsCfg.ClientAuth = tls.VerifyClientCertIfGiven
 	}
+	return credentials.NewTLS(tlsCfg), nil
+}
+
+// Run serves until Shutdown is called. A clean shutdown returns nil.
+func (s *GrpcServer) Run() error {
+	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", s.port))
+	if err != nil {
+		return fmt.Errorf("grpc failed to listen: %w", err)
+	}
+	return s.serve(lis)
+}
 
-	s := grpc.NewServer()
+func (s *GrpcServer) serve(lis net.Listener) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go watchGrpcHealth(ctx, s.health, s.checks, s.interval)
 
-	if err := s.Serve(lis); err != nil {
-		log.Fatalf("grpc failed to serve: %v", err)
+	if err := s.srv.Serve(lis); err != nil {
+		return fmt.Errorf("grpc failed to serve: %w", err)
 	}
 
+	return nil
+}
+
+// Shutdown stops accepting connections and waits for pending RPCs. When ctx
+// expires first the remaining RPCs are cancelled.
+func (s *GrpcServer) Shutdown(ctx context.Context) error {
+	// tell clients to stop routing here before the listener goes away
+	s.health.Shutdown()
+
+	done := make(chan struct{})
+	go func() {
+		s.srv.GracefulStop()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		s.srv.Stop()
+		return ctx.Err()
+	}
 }
@@ -0,0 +1,251 @@
+package server
+
+import (
+	"context"
+	"crypto/x509"
+	"fmt"
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"runtime/debug"
+	"service/config"
+	"service/pkg/apperror"
+	"service/pkg/auth"
+	"service/pkg/logger"
+	"service/pkg/otel"
+	"service/pkg/validation"
+	"strings"
+	"time"
+)
+
+// TokenVerifier checks bearer tokens; *auth.Verifier implements it.
+type TokenVerifier interface {
+	Verify(ctx context.Context, raw string) (*auth.Principal, error)
+}
+
+// selfValidator is implemented by messages that check themselves, like
+// those generated by protoc-gen-validate.
+type selfValidator interface {
+	Validate() error
+}
+
+// grpcInterceptor runs around one call. Unary and streaming calls share it:
+// next continues the chain with the possibly enriched ctx.
+type grpcInterceptor func(ctx context.Context, method string, next func(ctx context.Context) error) error
+
+// grpcInterceptors builds the chain enabled in cfg. Tracing comes first so
+// every later log line carries the trace ID, recovery wraps everything that
+// runs application code.
+func grpcInterceptors(cfg *config.Grpc, tracer trace.Tracer, verifier TokenVerifier) (grpc.UnaryServerInterceptor, grpc.StreamServerInterceptor) {
+	var chain []grpcInterceptor
+	if tracer != nil {
+		chain = append(chain, traceInterceptor(tracer))
+	}
+	if cfg.AccessLog {
+		chain = append(chain, accessLogInterceptor)
+	}
+	if cfg.Recovery {
+		chain = append(chain, recoveryInterceptor)
+	}
+	if cfg.Auth.Enabled {
+		chain = append(chain, authInterceptor(cfg.Auth, verifier))
+	}
+	// the language picks the translation of validation messages in handlers
+	chain = append(chain, languageInterceptor)
+
+	run := func(ctx context.Context, method string, handler func(ctx context.Context) error) error {
+		next := handler
+		for i := len(chain) - 1; i >= 0; i-- {
+			interceptor, inner := chain[i], next
+			next = func(ctx context.Context) error {
+				return interceptor(ctx, method, inner)
+			}
+		}
+		return next(ctx)
+	}
+
+	unary := func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		var resp any
+		err := run(ctx, info.FullMethod, func(ctx context.Context) error {
+			if cfg.Validate {
+				if err := validateMessage(req); err != nil {
+					return err
+				}
+			}
+			var err error
+			resp, err = handler(ctx, req)
+			return err
+		})
+		return resp, err
+	}
+	stream := func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return run(ss.Context(), info.FullMethod, func(ctx context.Context) error {
+			return handler(srv, &serverStream{ServerStream: ss, ctx: ctx, validate: cfg.Validate})
+		})
+	}
+	return unary, stream
+}
+
+func traceInterceptor(tracer trace.Tracer) grpcInterceptor {
+	return func(ctx context.Context, method string, next func(ctx context.Context) error) error {
+		ctx = otel.InjectTracing(otel.ExtractFromMetadata(ctx), tracer, "")
+		service, name, _ := strings.Cut(strings.TrimPrefix(method, "/"), "/")
+		ctx, span := otel.AddSpan(ctx, strings.TrimPrefix(method, "/"),
+			attribute.String("rpc.system", "grpc"),
+			attribute.String("rpc.service", service),
+			attribute.String("rpc.method", name))
+		defer span.End()
+
+		err := next(ctx)
+		code := status.Code(err)
+		span.SetAttributes(attribute.Int("rpc.grpc.status_code", int(code)))
+		if isServerError(code) {
+			span.SetStatus(otelcodes.Error, err.Error())
+		}
+		return err
+	}
+}
+
+func accessLogInterceptor(ctx context.Context, method string, next func(ctx context.Context) error) error {
+	start := time.Now()
+
+	err := next(ctx)
+
+	code := status.Code(err)
+	lvl := logger.LevelInfo
+	if isServerError(code) {
+		lvl = logger.LevelError
+	}
+	client := ""
+	if p, ok := peer.FromContext(ctx); ok {
+		client = p.Addr.String()
+	}
+	logger.Logger.Log(lvl, "grpc call",
+		logger.F("method", method),
+		logger.F("code", code.String()),
+		logger.F("latency", time.Since(start).String()),
+		logger.F("peer", client),
+		logger.F("trace_id", otel.GetTraceID(ctx)))
+	return err
+}
+
+func recoveryInterceptor(ctx context.Context, method string, next func(ctx context.Context) error) (err error) {
+	defer func() {
+		rec := recover()
+		if rec == nil {
+			return
+		}
+		logger.Logger.Error("panic recovered",
+			logger.F("panic", fmt.Sprint(rec)),
+			logger.F("stack", string(debug.Stack())),
+			logger.F("method", method),
+			logger.F("trace_id", otel.GetTraceID(ctx)))
+		// internal errors do not expose their message
+		err = apperror.GRPCError(fmt.Errorf("panic: %v", rec))
+	}()
+
+	return next(ctx)
+}
+
+// authInterceptor stores the principal of a bearer token or, without one,
+// of a verified client certificate in ctx, see auth.PrincipalFrom.
+func authInterceptor(cfg config.GrpcAuth, verifier TokenVerifier) grpcInterceptor {
+	return func(ctx context.Context, method string, next func(ctx context.Context) error) error {
+		if cfg.IsPublic(method) {
+			return next(ctx)
+		}
+
+		principal, err := grpcPrincipal(ctx, verifier)
+		if err != nil {
+			return apperror.GRPCError(err)
+		}
+		return next(auth.WithPrincipal(ctx, principal))
+	}
+}
+
+func grpcPrincipal(ctx context.Context, verifier TokenVerifier) (*auth.Principal, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if values := md.Get("authorization"); len(values) > 0 {
+		scheme, token, _ := strings.Cut(values[0], " ")
+		if !strings.EqualFold(scheme, "Bearer") || token == "" {
+			return nil, auth.ErrMissingToken
+		}
+		return verifier.Verify(ctx, strings.TrimSpace(token))
+	}
+	if cert := clientCertificate(ctx); cert != nil {
+		return &auth.Principal{Subject: cert.Subject.CommonName}, nil
+	}
+	return nil, auth.ErrMissingToken
+}
+
+// clientCertificate returns the client certificate verified during the TLS
+// handshake, if any.
+func clientCertificate(ctx context.Context) *x509.Certificate {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return nil
+	}
+	info, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(info.State.VerifiedChains) == 0 || len(info.State.VerifiedChains[0]) == 0 {
+		return nil
+	}
+	return info.State.VerifiedChains[0][0]
+}
+
+func languageInterceptor(ctx context.Context, method string, next func(ctx context.Context) error) error {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if values := md.Get("accept-language"); len(values) > 0 {
+		ctx = validation.WithLanguage(ctx, validation.Language(values[0]))
+	}
+	return next(ctx)
+}
+
+// validateMessage runs the Validate method of msg, if it has one.
+func validateMessage(msg any) error {
+	v, ok := msg.(selfValidator)
+	if !ok {
+		return nil
+	}
+	if err := v.Validate(); err != nil {
+		return apperror.GRPCError(validation.ErrInvalid.WithDetail("reason", err.Error()))
+	}
+	return nil
+}
+
+// isServerError tells the codes that mean the server failed, the ones
+// logged as errors.
+func isServerError(code codes.Code) bool {
+	switch code {
+	case codes.Unknown, codes.Internal, codes.Unavailable, codes.DataLoss:
+		return true
+	}
+	return false
+}
+
+// serverStream carries the context built by the interceptors and validates
+// every received message.
+type serverStream struct {
+	grpc.ServerStream
+	ctx      context.Context
+	validate bool
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *serverStream) RecvMsg(m any) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	if s.validate {
+		return validateMessage(m)
+	}
+	return nil
+}
@@ -0,0 +1,276 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"service/config"
+	"service/pkg/apperror"
+	"service/pkg/auth"
+	"service/pkg/otel"
+	"strings"
+	"testing"
+
+	gootel "go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthgrpc "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// echoRequest is a message with a Validate method, like the ones generated
+// by protoc-gen-validate.
+type echoRequest struct {
+	*wrapperspb.StringValue
+}
+
+func (r *echoRequest) Validate() error {
+	if r.GetValue() == "" {
+		return errors.New("value is required")
+	}
+	return nil
+}
+
+// echo answers "<subject> <value> <trace id>", panics on "panic" and
+// fails with the apperror of "missing".
+func echo(ctx context.Context, value string) (*wrapperspb.StringValue, error) {
+	switch value {
+	case "panic":
+		panic("boom")
+	case "missing":
+		return nil, apperror.GRPCError(apperror.NotFound("echo.not_found", "nothing to echo"))
+	}
+	subject := ""
+	if p, ok := auth.PrincipalFrom(ctx); ok {
+		subject = p.Subject
+	}
+	return wrapperspb.String(strings.Join([]string{subject, value, otel.GetTraceID(ctx)}, " ")), nil
+}
+
+var echoService = grpc.ServiceDesc{
+	ServiceName: "test.Echo",
+	HandlerType: (*any)(nil),
+	Methods: []grpc.MethodDesc{{
+		MethodName: "Echo",
+		Handler: func(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+			in := &echoRequest{StringValue: &wrapperspb.StringValue{}}
+			if err := dec(in); err != nil {
+				return nil, err
+			}
+			info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/test.Echo/Echo"}
+			return interceptor(ctx, in, info, func(ctx context.Context, req any) (any, error) {
+				return echo(ctx, req.(*echoRequest).GetValue())
+			})
+		},
+	}},
+	Streams: []grpc.StreamDesc{{
+		StreamName:    "Collect",
+		ClientStreams: true,
+		// Collect joins every received value
+		Handler: func(srv any, stream grpc.ServerStream) error {
+			var values []string
+			for {
+				in := &echoRequest{StringValue: &wrapperspb.StringValue{}}
+				err := stream.RecvMsg(in)
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				if err != nil {
+					return err
+				}
+				values = append(values, in.GetValue())
+			}
+			return stream.SendMsg(wrapperspb.String(strings.Join(values, ",")))
+		},
+	}},
+}
+
+type verifierFunc func(ctx context.Context, raw string) (*auth.Principal, error)
+
+func (f verifierFunc) Verify(ctx context.Context, raw string) (*auth.Principal, error) {
+	return f(ctx, raw)
+}
+
+// testVerifier accepts the token "good" as user-1.
+var testVerifier = verifierFunc(func(ctx context.Context, raw string) (*auth.Principal, error) {
+	if raw != "good" {
+		return nil, auth.ErrInvalidToken
+	}
+	return &auth.Principal{Subject: "user-1"}, nil
+})
+
+// newTestGrpcClient serves the echo and health services over bufconn with
+// the interceptors of cfg.
+func newTestGrpcClient(t *testing.T, cfg *config.Grpc) *grpc.ClientConn {
+	t.Helper()
+	tracer := sdktrace.NewTracerProvider().Tracer("test")
+	unary, stream := grpcInterceptors(cfg, tracer, testVerifier)
+	s := grpc.NewServer(grpc.ChainUnaryInterceptor(unary), grpc.ChainStreamInterceptor(stream))
+	s.RegisterService(&echoService, struct{}{})
+	healthpb.RegisterHealthServer(s, healthgrpc.NewServer())
+
+	lis := bufconn.Listen(1 << 20)
+	go func() { _ = s.Serve(lis) }()
+	t.Cleanup(s.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	return conn
+}
+
+func callEcho(conn *grpc.ClientConn, token, value string, md ...string) (string, error) {
+	ctx := context.Background()
+	if token != "" {
+		md = append(md, "authorization", "Bearer "+token)
+	}
+	ctx = metadata.AppendToOutgoingContext(ctx, md...)
+	out := &wrapperspb.StringValue{}
+	err := conn.Invoke(ctx, "/test.Echo/Echo", wrapperspb.String(value), out)
+	return out.GetValue(), err
+}
+
+func TestGrpcInterceptorChain(t *testing.T) {
+	cfg := config.NewConfig().Grpc
+	cfg.Auth.Enabled = true
+	conn := newTestGrpcClient(t, &cfg)
+
+	for _, tc := range []struct {
+		name  string
+		token string
+		value string
+		code  codes.Code
+	}{
+		{name: "authenticated", token: "good", value: "hi", code: codes.OK},
+		{name: "missing token", value: "hi", code: codes.Unauthenticated},
+		{name: "invalid token", token: "bad", value: "hi", code: codes.Unauthenticated},
+		// auth runs before validation, anonymous callers learn nothing
+		{name: "invalid message of anonymous caller", value: "", code: codes.Unauthenticated},
+		{name: "invalid message", token: "good", value: "", code: codes.InvalidArgument},
+		{name: "handler error", token: "good", value: "missing", code: codes.NotFound},
+		{name: "panic", token: "good", value: "panic", code: codes.Internal},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			logs := observeLogs(t)
+			got, err := callEcho(conn, tc.token, tc.value)
+			if code := status.Code(err); code != tc.code {
+				t.Fatalf("code = %s, want %s: %v", code, tc.code, err)
+			}
+			if strings.Contains(status.Convert(err).Message(), "boom") {
+				t.Errorf("panic message exposed: %v", err)
+			}
+			if tc.code == codes.OK && !strings.HasPrefix(got, "user-1 hi ") {
+				t.Errorf("response = %q, want the principal in the handler context", got)
+			}
+
+			// the access log wraps recovery and auth, so it sees their codes
+			entries := logs.FilterMessage("grpc call").All()
+			if len(entries) != 1 {
+				t.Fatalf("%d access log lines", len(entries))
+			}
+			fields := entries[0].ContextMap()
+			if fields["method"] != "/test.Echo/Echo" || fields["code"] != tc.code.String() {
+				t.Errorf("access log = %v", fields)
+			}
+			if tc.code == codes.OK && !strings.HasSuffix(got, " "+fields["trace_id"].(string)) {
+				t.Errorf("access log trace_id %v differs from the handler's in %q", fields["trace_id"], got)
+			}
+			if panics := logs.FilterMessage("panic recovered").All(); (len(panics) == 1) != (tc.code == codes.Internal) {
+				t.Errorf("%d panic log lines", len(panics))
+			} else if len(panics) == 1 && !strings.Contains(panics[0].ContextMap()["stack"].(string), "echo") {
+				t.Errorf("stack does not show the handler: %v", panics[0].ContextMap()["stack"])
+			}
+		})
+	}
+}
+
+func TestGrpcTraceparent(t *testing.T) {
+	previous := gootel.GetTextMapPropagator()
+	gootel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { gootel.SetTextMapPropagator(previous) })
+	observeLogs(t)
+
+	cfg := config.NewConfig().Grpc
+	conn := newTestGrpcClient(t, &cfg)
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	got, err := callEcho(conn, "", "hi", "traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := " hi " + traceID; got != want {
+		t.Errorf("response = %q, want %q", got, want)
+	}
+}
+
+func TestGrpcPublicMethods(t *testing.T) {
+	observeLogs(t)
+	cfg := config.NewConfig().Grpc
+	cfg.Auth.Enabled = true
+	conn := newTestGrpcClient(t, &cfg)
+
+	resp, err := healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{})
+	if err != nil {
+		t.Fatalf("public health check: %v", err)
+	}
+	if resp.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+		t.Errorf("status = %s", resp.GetStatus())
+	}
+}
+
+func TestGrpcStreamValidation(t *testing.T) {
+	observeLogs(t)
+	cfg := config.NewConfig().Grpc
+	cfg.Auth.Enabled = true
+	conn := newTestGrpcClient(t, &cfg)
+	desc := &echoService.Streams[0]
+
+	for _, tc := range []struct {
+		name   string
+		token  string
+		values []string
+		code   codes.Code
+	}{
+		{name: "valid", token: "good", values: []string{"a", "b"}, code: codes.OK},
+		{name: "missing token", values: []string{"a"}, code: codes.Unauthenticated},
+		{name: "invalid message", token: "good", values: []string{"a", ""}, code: codes.InvalidArgument},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tc.token != "" {
+				ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+tc.token)
+			}
+			stream, err := conn.NewStream(ctx, desc, "/test.Echo/Collect")
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, v := range tc.values {
+				// a rejected stream may close before every message is sent
+				if err := stream.SendMsg(wrapperspb.String(v)); err != nil {
+					break
+				}
+			}
+			_ = stream.CloseSend()
+			out := &wrapperspb.StringValue{}
+			err = stream.RecvMsg(out)
+			if code := status.Code(err); code != tc.code {
+				t.Fatalf("code = %s, want %s: %v", code, tc.code, err)
+			}
+			if tc.code == codes.OK && out.GetValue() != strings.Join(tc.values, ",") {
+				t.Errorf("response = %q", out.GetValue())
+			}
+		})
+	}
+}
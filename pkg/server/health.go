@@ -0,0 +1,51 @@
+package server
+
+import (
+	"context"
+	"github.com/gin-gonic/gin"
+	healthgrpc "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"net/http"
+	"service/pkg/health"
+	"time"
+)
+
+// registerHealthRoutes mounts the probes at the engine root so they do not
+// depend on cfg.Rest.Prefix.
+func registerHealthRoutes(r *gin.Engine, checks *health.Registry) {
+	// liveness only proves the process can serve requests, it must not
+	// fail because a dependency is down or the pod would be restarted
+	r.GET("/healthz", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"status": health.StatusUp})
+	})
+
+	r.GET("/readyz", func(c *gin.Context) {
+		report := checks.Check(c.Request.Context())
+		status := http.StatusOK
+		if report.Status == health.StatusDown {
+			status = http.StatusServiceUnavailable
+		}
+		c.JSON(status, report)
+	})
+}
+
+// watchGrpcHealth mirrors the readiness report into the gRPC health
+// service until ctx is done.
+func watchGrpcHealth(ctx context.Context, hs *healthgrpc.Server, checks *health.Registry, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		status := healthpb.HealthCheckResponse_SERVING
+		if checks.Check(ctx).Status == health.StatusDown {
+			status = healthpb.HealthCheckResponse_NOT_SERVING
+		}
+		hs.SetServingStatus("", status)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
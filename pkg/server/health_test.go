@@ -0,0 +1,44 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"service/pkg/health"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestReadyzStatus(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		critical bool
+		status   int
+	}{
+		{"degraded stays ready", false, http.StatusOK},
+		{"down is unavailable", true, http.StatusServiceUnavailable},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			checks := health.NewRegistry(time.Second)
+			checks.Add("elastic", tc.critical, func(ctx context.Context) error { return errors.New("timeout") })
+			r := gin.New()
+			registerHealthRoutes(r, checks)
+
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+			if rec.Code != tc.status {
+				t.Errorf("readyz = %d, want %d: %s", rec.Code, tc.status, rec.Body)
+			}
+
+			// liveness ignores dependencies
+			rec = httptest.NewRecorder()
+			r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+			if rec.Code != http.StatusOK {
+				t.Errorf("healthz = %d", rec.Code)
+			}
+		})
+	}
+}
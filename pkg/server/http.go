@@ -2,63 +2,121 @@ package server
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/gin-gonic/gin"
 	"go.opentelemetry.io/otel/trace"
-	"log"
+	"net"
 	"net/http"
 	"service/app/controllers/restapi"
 	"service/app/middlewares"
 	"service/config"
+	"service/pkg/health"
 	"service/pkg/otel"
 )
 
-type groupsHandlers func(group *gin.RouterGroup, restApi *restapi.Restapi, mid *middlewares.Middlewares)
+type HTTPServer struct {
+	srv      *http.Server
+	engine   *gin.Engine
+	versions []VersionRoutes
+}
 
-func RunHTTPServer(
-	ctx context.Context,
+func NewHTTPServer(
 	cfg *config.Config,
 	tracer trace.Tracer,
 	restApi *restapi.Restapi,
 	mid *middlewares.Middlewares,
-	groupsHandlers ...groupsHandlers,
-) {
+	checks *health.Registry,
+	versions ...Version,
+) (*HTTPServer, error) {
 	r := gin.New()
 
-	//tracing otel middleware
-	if tracer != nil {
-		r.Use(traceMiddleware(tracer))
-	}
+	registerHealthRoutes(r, checks)
 
-	setupMiddlewares(r)
+	if err := setupMiddlewares(r, &cfg.Rest, tracer); err != nil {
+		return nil, err
+	}
 
-	group := r.Group(cfg.Rest.Prefix)
-	for i := range groupsHandlers {
-		groupsHandlers[i](group, restApi, mid)
+	specValidator, err := setupOpenAPI(r, cfg, versions)
+	if err != nil {
+		return nil, err
 	}
 
-	s := http.Server{
-		Addr:                         fmt.Sprintf(":%s", cfg.Rest.Port),
-		Handler:                      r,
-		DisableGeneralOptionsHandler: false,
-		//BaseContext: func(net.Listener) context.Context {
-		//	return nil
-		//},
+	versions, err = resolveAliases(versions)
+	if err != nil {
+		return nil, err
+	}
+	reports := make([]VersionRoutes, 0, len(versions))
+	for _, version := range versions {
+		report, err := mountVersion(r, &cfg.Rest, version, restApi, mid, specValidator)
+		if err != nil {
+			return nil, err
+		}
+		reports = append(reports, report)
 	}
 
-	if err := s.ListenAndServe(); err != nil {
-		log.Fatal(fmt.Sprintf("failed to start server: %v", err))
+	return &HTTPServer{
+		engine:   r,
+		versions: reports,
+		srv: &http.Server{
+			Addr:                         fmt.Sprintf(":%d", cfg.Rest.Port),
+			Handler:                      r,
+			DisableGeneralOptionsHandler: false,
+			//BaseContext: func(net.Listener) context.Context {
+			//	return nil
+			//},
+		},
+	}, nil
+}
+
+// Routes lists the routes registered on the engine.
+func (s *HTTPServer) Routes() gin.RoutesInfo {
+	return s.engine.Routes()
+}
+
+// Versions reports the routes mounted by each API version.
+func (s *HTTPServer) Versions() []VersionRoutes {
+	return s.versions
+}
+
+// OnShutdown calls f when Shutdown starts, to end long-lived responses that
+// would otherwise hold it up until its deadline.
+func (s *HTTPServer) OnShutdown(f func()) {
+	s.srv.RegisterOnShutdown(f)
+}
+
+// Run serves until Shutdown is called. A clean shutdown returns nil.
+func (s *HTTPServer) Run() error {
+	lis, err := net.Listen("tcp", s.srv.Addr)
+	if err != nil {
+		return fmt.Errorf("failed to start server: %w", err)
 	}
+	return s.serve(lis)
 }
 
-func setupMiddlewares(route *gin.Engine) {
+func (s *HTTPServer) serve(lis net.Listener) error {
+	if err := s.srv.Serve(lis); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("failed to start server: %w", err)
+	}
+	return nil
+}
 
+// Shutdown stops accepting connections and waits for in-flight requests.
+// When ctx expires first the remaining connections are closed.
+func (s *HTTPServer) Shutdown(ctx context.Context) error {
+	if err := s.srv.Shutdown(ctx); err != nil {
+		_ = s.srv.Close()
+		return err
+	}
+	return nil
 }
 
 func traceMiddleware(tracer trace.Tracer) gin.HandlerFunc {
 
 	return func(c *gin.Context) {
-		otelCtx := otel.InjectTracing(c, tracer, "")
+		// the request context carries the request ID and ends when the
+		// client goes away, *gin.Context has neither
+		otelCtx := otel.InjectTracing(c.Request.Context(), tracer, "")
 		name := fmt.Sprintf("[%s] %s", c.Request.Method, c.Request.URL.Path)
 		ctxStart, span := otel.AddSpan(otelCtx, name)
 		defer span.End()
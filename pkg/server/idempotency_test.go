@@ -0,0 +1,105 @@
+package server
+
+import (
+	"compress/gzip"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"service/app/middlewares"
+	"service/config"
+	"service/pkg/datastore/redis"
+	"service/pkg/httpcache"
+	"strings"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+)
+
+func newTestRedis(t *testing.T) *redis.Redis {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	cfg := config.NewConfig()
+	cfg.Redis.Host = mr.Addr()
+	rdb := redis.NewRedis(context.Background(), &cfg)
+	t.Cleanup(func() { _ = rdb.Close() })
+	return rdb
+}
+
+func TestIdempotentReplayThroughCompression(t *testing.T) {
+	observeLogs(t)
+	cfg := config.NewConfig().Rest
+	cfg.Compression.MinSize = 1
+	r := newTestEngine(t, &cfg, false)
+
+	rdb := newTestRedis(t)
+	mid := middlewares.NewMiddlewares(&cfg, nil, nil, nil, rdb, httpcache.NewStore(rdb))
+	calls := 0
+	r.POST("/orders", mid.Idempotent(), func(c *gin.Context) {
+		calls++
+		c.Header("Location", "/orders/7")
+		c.Header("X-Order-Status", "created")
+		c.JSON(http.StatusCreated, gin.H{"id": 7, "note": strings.Repeat("x", 64)})
+	})
+
+	send := func(acceptEncoding string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(`{"item":"book"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(middlewares.IdempotencyKeyHeader, "order-1")
+		if acceptEncoding != "" {
+			req.Header.Set("Accept-Encoding", acceptEncoding)
+		}
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		return rec
+	}
+	body := func(rec *httptest.ResponseRecorder) string {
+		if rec.Header().Get("Content-Encoding") != "gzip" {
+			return rec.Body.String()
+		}
+		zr, err := gzip.NewReader(rec.Body)
+		if err != nil {
+			t.Fatalf("body labelled gzip is not: %v", err)
+		}
+		raw, err := io.ReadAll(zr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(raw)
+	}
+
+	first := send("gzip")
+	want := body(first)
+	if first.Code != http.StatusCreated || first.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatalf("first: %d %v", first.Code, first.Header())
+	}
+
+	for _, tc := range []struct {
+		acceptEncoding string
+		encoding       string
+	}{
+		{"gzip", "gzip"},
+		{"", ""},
+	} {
+		rec := send(tc.acceptEncoding)
+		if rec.Header().Get("Idempotent-Replayed") != "true" {
+			t.Errorf("Accept-Encoding %q: not replayed", tc.acceptEncoding)
+		}
+		if got := rec.Header().Get("Content-Encoding"); got != tc.encoding {
+			t.Errorf("Accept-Encoding %q: Content-Encoding = %q", tc.acceptEncoding, got)
+		}
+		if got := body(rec); rec.Code != http.StatusCreated || got != want {
+			t.Errorf("Accept-Encoding %q: replayed %d %q, want %q", tc.acceptEncoding, rec.Code, got, want)
+		}
+		if rec.Header().Get("Location") != "/orders/7" || rec.Header().Get("X-Order-Status") != "created" {
+			t.Errorf("Accept-Encoding %q: lost headers %v", tc.acceptEncoding, rec.Header())
+		}
+		if vary := rec.Header().Values("Vary"); len(vary) != 1 {
+			t.Errorf("Accept-Encoding %q: Vary = %v", tc.acceptEncoding, vary)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("handler ran %d times", calls)
+	}
+}
@@ -0,0 +1,189 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
+	"net/http"
+	"runtime/debug"
+	"service/config"
+	"service/pkg/apperror"
+	"service/pkg/logger"
+	"service/pkg/otel"
+	"service/pkg/requestid"
+	"service/pkg/validation"
+	"strings"
+	"time"
+)
+
+var errTimeout = apperror.Unavailable("request.timeout", "request timed out")
+
+// setupMiddlewares installs the middlewares enabled in cfg. Recovery and the
+// request ID come first so every later middleware and handler is covered.
+func setupMiddlewares(r *gin.Engine, cfg *config.Rest, tracer trace.Tracer) error {
+	if err := r.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		return fmt.Errorf("trusted proxies: %w", err)
+	}
+
+	if cfg.Recovery {
+		r.Use(recoveryMiddleware())
+	}
+	if cfg.RequestID {
+		r.Use(requestIDMiddleware())
+	}
+	//tracing otel middleware
+	if tracer != nil {
+		r.Use(traceMiddleware(tracer))
+	}
+	if cfg.AccessLog {
+		r.Use(accessLogMiddleware())
+	}
+	if cfg.Compression.Enabled {
+		r.Use(compressMiddleware(cfg.Compression.MinSize))
+	}
+	if cfg.MaxBodySize > 0 {
+		r.Use(maxBodyMiddleware(cfg.MaxBodySize))
+	}
+	if cfg.Timeout > 0 || len(cfg.RouteTimeouts) > 0 {
+		r.Use(timeoutMiddleware(cfg.Timeout, cfg.RouteTimeouts))
+	}
+	return nil
+}
+
+func recoveryMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// later middlewares derive cancellable contexts, only the server's
+		// own one tells whether the client went away
+		connCtx := c.Request.Context()
+
+		defer func() {
+			rec := recover()
+			if rec == nil {
+				return
+			}
+			ctx := c.Request.Context()
+			logger.Logger.Error("panic recovered",
+				logger.F("panic", fmt.Sprint(rec)),
+				logger.F("stack", string(debug.Stack())),
+				logger.F("method", c.Request.Method),
+				logger.F("path", c.Request.URL.Path),
+				logger.F("trace_id", otel.GetTraceID(ctx)),
+				logger.F("request_id", requestid.From(ctx)))
+
+			// when the client is gone there is nobody to answer
+			if c.Writer.Written() || errors.Is(connCtx.Err(), context.Canceled) {
+				c.Abort()
+				return
+			}
+			apperror.WriteHTTP(c, fmt.Errorf("panic: %v", rec))
+		}()
+
+		c.Next()
+	}
+}
+
+// requestIDMiddleware keeps a sane caller supplied X-Request-ID so a request
+// can be followed across services, or generates one.
+func requestIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(requestid.Header)
+		if !validRequestID(id) {
+			id = uuid.NewString()
+		}
+		c.Header(requestid.Header, id)
+		c.Request = c.Request.WithContext(requestid.With(c.Request.Context(), id))
+
+		c.Next()
+	}
+}
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for _, r := range id {
+		if r < 0x21 || r > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+func accessLogMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		c.Next()
+
+		ctx := c.Request.Context()
+		status := c.Writer.Status()
+		lvl := logger.LevelInfo
+		if status >= http.StatusInternalServerError {
+			lvl = logger.LevelError
+		}
+		logger.Logger.Log(lvl, "http request",
+			logger.F("method", c.Request.Method),
+			logger.F("path", c.Request.URL.Path),
+			logger.F("route", c.FullPath()),
+			logger.F("status", status),
+			logger.F("latency", time.Since(start).String()),
+			logger.F("bytes", c.Writer.Size()),
+			logger.F("client_ip", c.ClientIP()),
+			logger.F("user_agent", c.Request.UserAgent()),
+			logger.F("trace_id", otel.GetTraceID(ctx)),
+			logger.F("request_id", requestid.From(ctx)))
+	}
+}
+
+// isStream reports whether r asks for a long-lived response.
+func isStream(r *http.Request) bool {
+	return strings.EqualFold(r.Header.Get("Upgrade"), "websocket") ||
+		strings.Contains(r.Header.Get("Accept"), "text/event-stream")
+}
+
+// maxBodyMiddleware rejects a declared oversized body up front and caps
+// reads of chunked ones, which then fail to bind with ErrTooLarge.
+func maxBodyMiddleware(limit int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.ContentLength > limit {
+			apperror.WriteHTTP(c, validation.ErrTooLarge.WithDetail("limit", limit))
+			return
+		}
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit)
+
+		c.Next()
+	}
+}
+
+// timeoutMiddleware puts a deadline on the request context. Handlers stop
+// by honouring ctx; when one returns past the deadline without answering,
+// the client gets a timeout error. Event streams and WebSockets are only
+// bounded by a route timeout.
+func timeoutMiddleware(def time.Duration, routes map[string]time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		timeout := def
+		if isStream(c.Request) {
+			timeout = 0
+		}
+		if d, ok := routes[c.Request.Method+" "+c.FullPath()]; ok {
+			timeout = d
+		}
+		if timeout <= 0 {
+			c.Next()
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) && !c.Writer.Written() {
+			apperror.WriteHTTP(c, errTimeout.WithDetail("timeout", timeout.String()))
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"service/config"
+	"service/pkg/logger"
+	"service/pkg/requestid"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// observeLogs sends the global logger to an observer for the test.
+func observeLogs(t *testing.T) *observer.ObservedLogs {
+	t.Helper()
+	core, logs := observer.New(zapcore.DebugLevel)
+	previous := logger.Logger
+	logger.Use(zap.New(core))
+	t.Cleanup(func() { logger.Logger = previous })
+	return logs
+}
+
+func newTestEngine(t *testing.T, cfg *config.Rest, traced bool) *gin.Engine {
+	t.Helper()
+	r := gin.New()
+	tracer := sdktrace.NewTracerProvider().Tracer("test")
+	if !traced {
+		tracer = nil
+	}
+	if err := setupMiddlewares(r, cfg, tracer); err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func TestRequestContextSurvivesTracing(t *testing.T) {
+	for _, traced := range []bool{false, true} {
+		logs := observeLogs(t)
+		cfg := config.NewConfig().Rest
+		r := newTestEngine(t, &cfg, traced)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		var handlerID string
+		var canceled bool
+		r.GET("/ping", func(c *gin.Context) {
+			handlerID = requestid.From(c.Request.Context())
+			// the client going away reaches the handler's context
+			cancel()
+			select {
+			case <-c.Request.Context().Done():
+				canceled = true
+			case <-time.After(time.Second):
+			}
+			c.Status(http.StatusNoContent)
+		})
+
+		req := httptest.NewRequest(http.MethodGet, "/ping", nil).WithContext(ctx)
+		req.Header.Set(requestid.Header, "req-42")
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+
+		if got := rec.Header().Get(requestid.Header); got != "req-42" {
+			t.Errorf("traced=%v: response X-Request-ID = %q", traced, got)
+		}
+		if handlerID != "req-42" {
+			t.Errorf("traced=%v: handler request ID = %q", traced, handlerID)
+		}
+		entries := logs.FilterMessage("http request").All()
+		if len(entries) != 1 {
+			t.Fatalf("traced=%v: %d access log entries", traced, len(entries))
+		}
+		if got := entries[0].ContextMap()["request_id"]; got != "req-42" {
+			t.Errorf("traced=%v: logged request_id = %q", traced, got)
+		}
+		if !canceled {
+			t.Errorf("traced=%v: handler context not canceled with the client", traced)
+		}
+	}
+}
+
+func TestRecoveryLogsPanicOfGoneClient(t *testing.T) {
+	logs := observeLogs(t)
+	cfg := config.NewConfig().Rest
+	r := newTestEngine(t, &cfg, false)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r.GET("/panic", func(c *gin.Context) {
+		cancel()
+		panic("boom")
+	})
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/panic", nil).WithContext(ctx))
+
+	entries := logs.FilterMessage("panic recovered").All()
+	if len(entries) != 1 {
+		t.Fatalf("%d panic log entries", len(entries))
+	}
+	fields := entries[0].ContextMap()
+	if fields["panic"] != "boom" || fields["stack"] == "" {
+		t.Errorf("logged %v", fields)
+	}
+	if rec.Body.Len() != 0 {
+		t.Errorf("answered a gone client with %q", rec.Body.String())
+	}
+}
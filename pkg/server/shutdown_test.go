@@ -0,0 +1,282 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"service/pkg/health"
+	"service/pkg/lifecycle"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/ThreeDotsLabs/watermill/pubsub/gochannel"
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	healthgrpc "google.golang.org/grpc/health"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// slowHandler holds a request until it returns.
+type slowHandler func(ctx context.Context) error
+
+var slowService = grpc.ServiceDesc{
+	ServiceName: "test.Slow",
+	HandlerType: (*any)(nil),
+	Methods: []grpc.MethodDesc{{
+		MethodName: "Wait",
+		Handler: func(srv any, ctx context.Context, dec func(any) error, _ grpc.UnaryServerInterceptor) (any, error) {
+			if err := dec(&wrapperspb.StringValue{}); err != nil {
+				return nil, err
+			}
+			if err := srv.(slowHandler)(ctx); err != nil {
+				return nil, err
+			}
+			return wrapperspb.String("done"), nil
+		},
+	}},
+}
+
+// shutdownStack is an HTTP server, a gRPC server and a message router
+// registered as modules on top of a "database" module, the way the container
+// wires them.
+type shutdownStack struct {
+	mu     sync.Mutex
+	events []string
+
+	started chan string
+	httpURL string
+	conn    *grpc.ClientConn
+	pubsub  *gochannel.GoChannel
+	router  *message.Router
+}
+
+func (s *shutdownStack) add(event string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, event)
+}
+
+func (s *shutdownStack) index(t *testing.T, event string) int {
+	t.Helper()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := slices.Index(s.events, event)
+	if i < 0 {
+		t.Fatalf("no %q in %v", event, s.events)
+	}
+	return i
+}
+
+// hold wraps wait so every module records when its request starts and ends.
+func (s *shutdownStack) hold(name string, wait slowHandler) slowHandler {
+	return func(ctx context.Context) error {
+		s.add(name + " started")
+		s.started <- name
+		err := wait(ctx)
+		s.add(name + " done")
+		return err
+	}
+}
+
+// newShutdownStack registers the modules on reg. Each handler calls wait.
+func newShutdownStack(t *testing.T, reg *lifecycle.Registry, timeout time.Duration, wait slowHandler) *shutdownStack {
+	t.Helper()
+	s := &shutdownStack{started: make(chan string, 3)}
+
+	reg.Register(lifecycle.Module{
+		Name: "database",
+		Stop: func(ctx context.Context) error {
+			s.add("database stopped")
+			return nil
+		},
+	})
+
+	engine := gin.New()
+	httpWait := s.hold("http", wait)
+	engine.GET("/slow", func(c *gin.Context) {
+		if err := httpWait(c.Request.Context()); err != nil {
+			c.Status(http.StatusServiceUnavailable)
+			return
+		}
+		c.String(http.StatusOK, "done")
+	})
+	httpServer := &HTTPServer{srv: &http.Server{Handler: engine}, engine: engine}
+	httpLis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.httpURL = "http://" + httpLis.Addr().String() + "/slow"
+	reg.Register(lifecycle.Module{
+		Name:      "http",
+		DependsOn: []string{"database"},
+		Run:       func() error { return httpServer.serve(httpLis) },
+		Stop:      httpServer.Shutdown,
+	})
+
+	grpcSrv := grpc.NewServer()
+	grpcSrv.RegisterService(&slowService, s.hold("grpc", wait))
+	grpcServer := &GrpcServer{srv: grpcSrv, health: healthgrpc.NewServer(), checks: health.NewRegistry(time.Second), interval: time.Hour}
+	grpcLis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.conn, err = grpc.NewClient(grpcLis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = s.conn.Close() })
+	reg.Register(lifecycle.Module{
+		Name:      "grpc",
+		DependsOn: []string{"database"},
+		Run:       func() error { return grpcServer.serve(grpcLis) },
+		Stop:      grpcServer.Shutdown,
+	})
+
+	s.pubsub = gochannel.NewGoChannel(gochannel.Config{}, watermill.NopLogger{})
+	t.Cleanup(func() { _ = s.pubsub.Close() })
+	s.router, err = message.NewRouter(message.RouterConfig{CloseTimeout: timeout}, watermill.NopLogger{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	brokerWait := s.hold("broker", wait)
+	s.router.AddNoPublisherHandler("slow", "events", s.pubsub, func(msg *message.Message) error {
+		return brokerWait(msg.Context())
+	})
+	reg.Register(lifecycle.Module{
+		Name:      "broker",
+		DependsOn: []string{"database"},
+		Run:       func() error { return s.router.Run(context.Background()) },
+		Stop:      func(ctx context.Context) error { return s.router.Close() },
+	})
+	return s
+}
+
+type shutdownResult struct {
+	httpErr   error
+	httpCode  int
+	grpcErr   error
+	runErr    error
+	runReturn time.Duration
+}
+
+// inFlight runs reg, starts one request on each module, then cancels the
+// run once all three handlers hold their request.
+func (s *shutdownStack) inFlight(t *testing.T, reg *lifecycle.Registry, timeout time.Duration) shutdownResult {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	runErr := make(chan error, 1)
+	go func() { runErr <- reg.Run(ctx, timeout) }()
+	<-s.router.Running()
+
+	var (
+		res shutdownResult
+		wg  sync.WaitGroup
+	)
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		resp, err := http.Get(s.httpURL)
+		if err != nil {
+			res.httpErr = err
+			return
+		}
+		_ = resp.Body.Close()
+		res.httpCode = resp.StatusCode
+	}()
+	go func() {
+		defer wg.Done()
+		res.grpcErr = s.conn.Invoke(context.Background(), "/test.Slow/Wait", wrapperspb.String(""), &wrapperspb.StringValue{})
+	}()
+	if err := s.pubsub.Publish("events", message.NewMessage(watermill.NewUUID(), nil)); err != nil {
+		t.Fatal(err)
+	}
+
+	for range 3 {
+		select {
+		case <-s.started:
+		case <-time.After(5 * time.Second):
+			t.Fatal("requests did not start")
+		}
+	}
+
+	cancel()
+	stopped := time.Now()
+	select {
+	case res.runErr = <-runErr:
+		res.runReturn = time.Since(stopped)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not return")
+	}
+	wg.Wait()
+	return res
+}
+
+func TestShutdownDrainsBeforeDatastores(t *testing.T) {
+	reg := lifecycle.NewRegistry()
+	s := newShutdownStack(t, reg, 5*time.Second, func(ctx context.Context) error {
+		select {
+		case <-time.After(200 * time.Millisecond):
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+
+	res := s.inFlight(t, reg, 5*time.Second)
+	if res.runErr != nil {
+		t.Fatalf("Run: %v", res.runErr)
+	}
+	if res.httpErr != nil || res.httpCode != http.StatusOK {
+		t.Errorf("http = %d, %v, want 200", res.httpCode, res.httpErr)
+	}
+	if res.grpcErr != nil {
+		t.Errorf("grpc: %v", res.grpcErr)
+	}
+
+	closed := s.index(t, "database stopped")
+	for _, name := range []string{"http", "grpc", "broker"} {
+		if done := s.index(t, name+" done"); done > closed {
+			t.Errorf("%s finished after the database stopped: %v", name, s.events)
+		}
+	}
+}
+
+func TestShutdownDeadline(t *testing.T) {
+	const timeout = 100 * time.Millisecond
+	release := make(chan struct{})
+	t.Cleanup(func() { close(release) })
+
+	reg := lifecycle.NewRegistry()
+	s := newShutdownStack(t, reg, timeout, func(ctx context.Context) error {
+		select {
+		case <-release:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+
+	res := s.inFlight(t, reg, timeout)
+	if !errors.Is(res.runErr, context.DeadlineExceeded) {
+		t.Errorf("Run = %v, want deadline exceeded", res.runErr)
+	}
+	if res.runReturn > 2*time.Second {
+		t.Errorf("Run returned %v after cancel, want about %v", res.runReturn, timeout)
+	}
+	// the servers were stopped: the held requests fail instead of waiting
+	// for the handlers
+	if res.httpErr == nil {
+		t.Errorf("http = %d, want the connection closed", res.httpCode)
+	}
+	if res.grpcErr == nil {
+		t.Error("grpc succeeded, want the RPC cancelled")
+	}
+	s.index(t, "database stopped")
+}
@@ -0,0 +1,154 @@
+package server
+
+import (
+	"fmt"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"service/app/controllers/restapi"
+	"service/app/middlewares"
+	"service/config"
+	"service/pkg/openapi"
+	"slices"
+	"strings"
+)
+
+// GroupsHandler mounts a set of routes on the group of a version.
+type GroupsHandler func(group *gin.RouterGroup, restApi *restapi.Restapi, mid *middlewares.Middlewares)
+
+// Version is one API version. Versions share the usecases; a version that
+// changes a response shape mounts its own handlers for those routes.
+type Version struct {
+	// Name keys router.versions and the version report.
+	Name string
+	// Path is the segment under router.prefix, empty to mount on the prefix.
+	Path     string
+	Handlers []GroupsHandler
+	// AliasOf mounts the handlers of the named version under Path as well,
+	// e.g. to keep serving clients from before that version had a path.
+	// The alias has its own lifecycle in router.versions.
+	AliasOf string
+	// Spec marks the version the API document describes.
+	Spec bool
+}
+
+// VersionRoutes reports the routes of one version, relative to its group so
+// two versions can be compared.
+type VersionRoutes struct {
+	Name       string   `json:"name"`
+	BasePath   string   `json:"base_path"`
+	AliasOf    string   `json:"alias_of,omitempty"`
+	Deprecated string   `json:"deprecated,omitempty"`
+	Sunset     string   `json:"sunset,omitempty"`
+	Routes     []string `json:"routes"`
+}
+
+// mountVersion registers the routes of version and returns its report.
+func mountVersion(r *gin.Engine, cfg *config.Rest, version Version, restApi *restapi.Restapi, mid *middlewares.Middlewares, specValidator gin.HandlerFunc) (VersionRoutes, error) {
+	group := versionGroup(r, cfg, version)
+
+	lifecycle := cfg.Versions[version.Name]
+	if lifecycle != (config.ApiVersion{}) {
+		group.Use(deprecationHeaders(lifecycle))
+	}
+	if version.Spec && specValidator != nil {
+		group.Use(specValidator)
+	}
+
+	before := map[string]bool{}
+	for _, route := range r.Routes() {
+		before[route.Method+" "+route.Path] = true
+	}
+	for _, handler := range version.Handlers {
+		handler(group, restApi, mid)
+	}
+
+	report := VersionRoutes{
+		Name:       version.Name,
+		BasePath:   group.BasePath(),
+		AliasOf:    version.AliasOf,
+		Deprecated: lifecycle.Deprecated,
+		Sunset:     lifecycle.Sunset,
+		Routes:     []string{},
+	}
+	base := strings.TrimSuffix(group.BasePath(), "/")
+	for _, route := range r.Routes() {
+		key := route.Method + " " + route.Path
+		if before[key] {
+			continue
+		}
+		report.Routes = append(report.Routes, route.Method+" "+strings.TrimPrefix(route.Path, base))
+	}
+	if len(report.Routes) == 0 {
+		return report, fmt.Errorf("version %q mounts no routes", version.Name)
+	}
+	return report, nil
+}
+
+// resolveAliases fills in the handlers of alias versions.
+func resolveAliases(versions []Version) ([]Version, error) {
+	resolved := slices.Clone(versions)
+	for i, version := range resolved {
+		if version.AliasOf == "" {
+			continue
+		}
+		j := slices.IndexFunc(versions, func(v Version) bool { return v.Name == version.AliasOf })
+		switch {
+		case len(version.Handlers) > 0:
+			return nil, fmt.Errorf("version %q is an alias and cannot have handlers", version.Name)
+		case j < 0:
+			return nil, fmt.Errorf("version %q is an alias of unknown version %q", version.Name, version.AliasOf)
+		case versions[j].AliasOf != "":
+			return nil, fmt.Errorf("version %q is an alias of alias %q", version.Name, version.AliasOf)
+		}
+		resolved[i].Handlers = versions[j].Handlers
+	}
+	return resolved, nil
+}
+
+func versionGroup(r *gin.Engine, cfg *config.Rest, version Version) *gin.RouterGroup {
+	return r.Group(cfg.Prefix).Group(version.Path)
+}
+
+// setupOpenAPI serves the API document of the Spec version and returns the
+// middleware validating its traffic, nil when validation is off.
+func setupOpenAPI(r *gin.Engine, cfg *config.Config, versions []Version) (gin.HandlerFunc, error) {
+	i := slices.IndexFunc(versions, func(v Version) bool { return v.Spec })
+	if i < 0 {
+		return nil, nil
+	}
+	openapi.SetBasePath(versionGroup(r, &cfg.Rest, versions[i]).BasePath())
+
+	if cfg.Rest.OpenAPI.Enabled {
+		openapi.Serve(r, cfg.Rest.OpenAPI.Path)
+	}
+	if !cfg.Rest.OpenAPI.Validate || cfg.App.Environment == config.EnvProduction {
+		return nil, nil
+	}
+	doc, err := openapi.Load()
+	if err != nil {
+		return nil, err
+	}
+	// report which value failed, not the whole schema
+	openapi3.SchemaErrorDetailsDisabled = true
+	return openapi.Validate(doc)
+}
+
+// deprecationHeaders announces the retirement of a version, see RFC 9745
+// and RFC 8594. The dates were checked by config validation.
+func deprecationHeaders(lifecycle config.ApiVersion) gin.HandlerFunc {
+	deprecated, _ := lifecycle.DeprecatedAt()
+	sunset, _ := lifecycle.SunsetAt()
+	return func(c *gin.Context) {
+		if !deprecated.IsZero() {
+			c.Header("Deprecation", fmt.Sprintf("@%d", deprecated.Unix()))
+		}
+		if !sunset.IsZero() {
+			c.Header("Sunset", sunset.Format(http.TimeFormat))
+		}
+		if lifecycle.Link != "" {
+			c.Writer.Header().Add("Link", fmt.Sprintf("<%s>; rel=\"deprecation\"", lifecycle.Link))
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,90 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"service/app/controllers/restapi"
+	"service/app/middlewares"
+	"service/config"
+	"service/pkg/health"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func pingGroup(body string) GroupsHandler {
+	return func(group *gin.RouterGroup, restApi *restapi.Restapi, mid *middlewares.Middlewares) {
+		group.GET("/ping", func(c *gin.Context) { c.String(http.StatusOK, body) })
+	}
+}
+
+func TestVersionLifecycleHeaders(t *testing.T) {
+	observeLogs(t)
+	cfg := config.NewConfig()
+	cfg.Rest.Prefix = "/api"
+	cfg.Rest.Versions = map[string]config.ApiVersion{
+		"v1":          {Deprecated: "2026-01-01", Sunset: "2027-01-01", Link: "https://example.com/migrate"},
+		"unversioned": {Deprecated: "2025-06-01"},
+	}
+	srv, err := NewHTTPServer(&cfg, nil, nil, nil, health.NewRegistry(time.Second),
+		Version{Name: "v1", Path: "v1", Handlers: []GroupsHandler{pingGroup("v1")}},
+		Version{Name: "v2", Path: "v2", Handlers: []GroupsHandler{pingGroup("v2")}},
+		Version{Name: "unversioned", AliasOf: "v1"},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		path, body, deprecation, sunset, link string
+	}{
+		{"/api/v1/ping", "v1", "@1767225600", "Fri, 01 Jan 2027 00:00:00 GMT", `<https://example.com/migrate>; rel="deprecation"`},
+		{"/api/v2/ping", "v2", "", "", ""},
+		// the alias serves v1 with a lifecycle of its own
+		{"/api/ping", "v1", "@1748736000", "", ""},
+	} {
+		rec := httptest.NewRecorder()
+		srv.engine.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tc.path, nil))
+
+		if rec.Code != http.StatusOK || rec.Body.String() != tc.body {
+			t.Errorf("%s: %d %q, want %q", tc.path, rec.Code, rec.Body, tc.body)
+		}
+		for header, want := range map[string]string{"Deprecation": tc.deprecation, "Sunset": tc.sunset, "Link": tc.link} {
+			if got := rec.Header().Get(header); got != want {
+				t.Errorf("%s: %s = %q, want %q", tc.path, header, got, want)
+			}
+		}
+	}
+
+	reports := srv.Versions()
+	if len(reports) != 3 {
+		t.Fatalf("%d reports", len(reports))
+	}
+	alias := reports[2]
+	if alias.AliasOf != "v1" || alias.BasePath != "/api" || alias.Deprecated != "2025-06-01" ||
+		strings.Join(alias.Routes, ",") != strings.Join(reports[0].Routes, ",") {
+		t.Errorf("alias report = %+v, v1 report = %+v", alias, reports[0])
+	}
+}
+
+func TestResolveAliasesRejects(t *testing.T) {
+	v1 := Version{Name: "v1", Path: "v1", Handlers: []GroupsHandler{pingGroup("v1")}}
+	for _, tc := range []struct {
+		name    string
+		version Version
+		want    string
+	}{
+		{"unknown target", Version{Name: "old", AliasOf: "v0"}, `unknown version "v0"`},
+		{"alias with handlers", Version{Name: "old", AliasOf: "v1", Handlers: v1.Handlers}, "cannot have handlers"},
+		{"alias of alias", Version{Name: "older", AliasOf: "old"}, `alias of alias "old"`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := resolveAliases([]Version{v1, {Name: "old", Path: "old", AliasOf: "v1"}, tc.version})
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("err = %v, want %q", err, tc.want)
+			}
+		})
+	}
+}
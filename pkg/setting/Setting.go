@@ -2,27 +2,50 @@ package setting
 
 import (
 	"service/config"
+	"sync/atomic"
 )
 
 type setting struct {
 	Name         string
-	Env          string
+	Env          config.Environment
 	QueueProgram string
+	Features     map[string]bool
 }
 
-var Setting setting
+var current atomic.Pointer[setting]
 
 func (s *setting) IsProduction() bool {
-	if s.Env == "production" {
+	if s.Env == config.EnvProduction {
 		return true
 	}
 	return false
 }
 
+// Enabled reports whether the feature toggle name is switched on.
+func (s *setting) Enabled(name string) bool {
+	return s.Features[name]
+}
+
+// Get returns the current settings snapshot. It is safe to call while a
+// config reload is in progress.
+func Get() *setting {
+	if s := current.Load(); s != nil {
+		return s
+	}
+	return &setting{}
+}
+
 func NewSetting(config *config.Config) {
-	Setting = setting{
+	current.Store(&setting{
 		Name:         config.App.Name,
 		Env:          config.App.Environment,
 		QueueProgram: config.Setting.QueueProgram,
-	}
+		Features:     config.Setting.Features,
+	})
+}
+
+// SubscribeConfig keeps the settings in sync with reloads of the setting
+// section.
+func SubscribeConfig(store *config.Store) {
+	store.Subscribe("setting", NewSetting)
 }
@@ -0,0 +1,49 @@
+package validation
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"net/http"
+)
+
+// bindings decode the request body by Content-Type. A request without one
+// is read as JSON.
+var bindings = map[string]binding.Binding{
+	"":                            binding.JSON,
+	binding.MIMEJSON:              binding.JSON,
+	binding.MIMEMSGPACK:           binding.MsgPack,
+	binding.MIMEMSGPACK2:          binding.MsgPack,
+	binding.MIMEPROTOBUF:          binding.ProtoBuf,
+	"application/protobuf":        binding.ProtoBuf,
+	binding.MIMEPOSTForm:          binding.Form,
+	binding.MIMEMultipartPOSTForm: binding.FormMultipart,
+}
+
+// Bind decodes the request into dst by Content-Type (JSON, MessagePack,
+// protobuf when dst is a message, or a form) and validates it with
+// messages in the language from Accept-Language.
+func Bind(c *gin.Context, dst any) error {
+	b, ok := bindings[c.ContentType()]
+	if !ok && c.Request.Method != http.MethodGet {
+		return ErrUnsupportedMedia.WithDetail("content_type", c.ContentType())
+	}
+	if err := decode(c, dst, b); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return ErrTooLarge.WithDetail("limit", tooLarge.Limit)
+		}
+		return ErrMalformed.Wrap(err)
+	}
+	ctx := WithLanguage(c.Request.Context(), Language(c.GetHeader("Accept-Language")))
+	return Struct(ctx, dst)
+}
+
+func decode(c *gin.Context, dst any, b binding.Binding) error {
+	// queries carry no body, gin binds them from the URL
+	if c.Request.Method == http.MethodGet {
+		return c.ShouldBindQuery(dst)
+	}
+	// gin only checks `binding` tags, the rules live in `validate` tags
+	return c.ShouldBindWith(dst, b)
+}
@@ -0,0 +1,62 @@
+package validation
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"service/pkg/apperror"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+type bindRequest struct {
+	Name string `json:"name" form:"name" validate:"required,max=5"`
+}
+
+func TestBindStatus(t *testing.T) {
+	r := gin.New()
+	r.POST("/", func(c *gin.Context) {
+		var req bindRequest
+		if err := Bind(c, &req); err != nil {
+			apperror.WriteHTTP(c, err)
+			return
+		}
+		c.Status(http.StatusNoContent)
+	})
+
+	for _, tc := range []struct {
+		name        string
+		contentType string
+		body        string
+		status      int
+		code        string
+	}{
+		{"json", "application/json", `{"name":"ana"}`, http.StatusNoContent, ""},
+		{"form", "application/x-www-form-urlencoded", `name=ana`, http.StatusNoContent, ""},
+		{"no content type is json", "", `{"name":"ana"}`, http.StatusNoContent, ""},
+		{"invalid", "application/json", `{"name":"too long"}`, http.StatusBadRequest, "request.invalid"},
+		{"malformed", "application/json", `{"name":`, http.StatusBadRequest, "request.malformed"},
+		{"unsupported", "text/plain", `ana`, http.StatusUnsupportedMediaType, "request.unsupported_media_type"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tc.body))
+			if tc.contentType != "" {
+				req.Header.Set("Content-Type", tc.contentType)
+			}
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+
+			if rec.Code != tc.status {
+				t.Errorf("status = %d, want %d: %s", rec.Code, tc.status, rec.Body)
+			}
+			if tc.code != "" && !strings.Contains(rec.Body.String(), `"code":"`+tc.code+`"`) {
+				t.Errorf("body = %s, want code %s", rec.Body, tc.code)
+			}
+		})
+	}
+}
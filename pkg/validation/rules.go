@@ -0,0 +1,6 @@
+package validation
+
+import "regexp"
+
+// indonesianPhone accepts mobile numbers as 08xx, 628xx or +628xx.
+var indonesianPhone = regexp.MustCompile(`^(\+62|62|0)8[1-9][0-9]{6,11}$`)
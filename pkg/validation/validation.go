@@ -0,0 +1,198 @@
+// Package validation validates request DTOs declared with `validate` struct
+// tags and reports failures as a localized list of field errors. It is
+// shared by the REST, gRPC and broker entry points.
+package validation
+
+import (
+	"context"
+	"errors"
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/id"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	entrans "github.com/go-playground/validator/v10/translations/en"
+	idtrans "github.com/go-playground/validator/v10/translations/id"
+	"reflect"
+	"service/pkg/apperror"
+	"strings"
+	"sync"
+)
+
+const (
+	LangEnglish    = "en"
+	LangIndonesian = "id"
+)
+
+// ErrInvalid is returned, with the field errors under the "errors" detail,
+// when a request does not pass validation.
+var ErrInvalid = apperror.Validation("request.invalid", "request validation failed")
+
+// ErrMalformed is returned when a payload cannot be decoded at all.
+var ErrMalformed = apperror.Validation("request.malformed", "request body is malformed")
+
+// ErrTooLarge is returned when a body exceeds the configured limit.
+var ErrTooLarge = apperror.Validation("request.too_large", "request body is too large")
+
+// ErrUnsupportedMedia is returned for a body in an encoding Bind does not
+// decode.
+var ErrUnsupportedMedia = apperror.UnsupportedMedia("request.unsupported_media_type", "request body encoding is not supported")
+
+// FieldError describes one failed rule. Field is the dotted json path.
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+var (
+	validate   = validator.New(validator.WithRequiredStructEnabled())
+	translator = ut.New(en.New(), en.New(), id.New())
+)
+
+type ctxKey int
+
+const (
+	langKey ctxKey = iota + 1
+	lookupErrKey
+)
+
+func init() {
+	// report json names so clients see the fields they sent
+	validate.RegisterTagNameFunc(func(f reflect.StructField) string {
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		if name == "" {
+			return f.Name
+		}
+		return name
+	})
+
+	enT, _ := translator.GetTranslator(LangEnglish)
+	idT, _ := translator.GetTranslator(LangIndonesian)
+	if err := entrans.RegisterDefaultTranslations(validate, enT); err != nil {
+		panic(err)
+	}
+	if err := idtrans.RegisterDefaultTranslations(validate, idT); err != nil {
+		panic(err)
+	}
+
+	mustRegister("phone_id", func(_ context.Context, fl validator.FieldLevel) bool {
+		return indonesianPhone.MatchString(fl.Field().String())
+	}, map[string]string{
+		LangEnglish:    "{0} must be a valid Indonesian phone number",
+		LangIndonesian: "{0} harus berupa nomor telepon Indonesia yang valid",
+	})
+}
+
+// Register adds a custom rule with a message per language, {0} being the
+// field name. Rules must be registered before the servers start.
+func Register(tag string, fn validator.FuncCtx, messages map[string]string) error {
+	if err := validate.RegisterValidationCtx(tag, fn); err != nil {
+		return err
+	}
+	for lang, msg := range messages {
+		trans, found := translator.GetTranslator(lang)
+		if !found {
+			continue
+		}
+		err := validate.RegisterTranslation(tag, trans,
+			func(ut ut.Translator) error {
+				return ut.Add(tag, msg, true)
+			},
+			func(ut ut.Translator, fe validator.FieldError) string {
+				t, _ := ut.T(tag, fe.Field())
+				return t
+			})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func mustRegister(tag string, fn validator.FuncCtx, messages map[string]string) {
+	if err := Register(tag, fn, messages); err != nil {
+		panic(err)
+	}
+}
+
+// UniqueFunc reports whether value is still free. An error fails the
+// request as unavailable instead of blaming the client.
+type UniqueFunc func(ctx context.Context, value string) (bool, error)
+
+// RegisterUnique adds a rule backed by a lookup, e.g. unique_email.
+func RegisterUnique(tag string, exists UniqueFunc, messages map[string]string) error {
+	return Register(tag, func(ctx context.Context, fl validator.FieldLevel) bool {
+		free, err := exists(ctx, fl.Field().String())
+		if err != nil {
+			if lookupErr, ok := ctx.Value(lookupErrKey).(*lookupError); ok {
+				lookupErr.set(err)
+			}
+			return false
+		}
+		return free
+	}, messages)
+}
+
+type lookupError struct {
+	once sync.Once
+	err  error
+}
+
+func (l *lookupError) set(err error) {
+	l.once.Do(func() { l.err = err })
+}
+
+// WithLanguage sets the language messages are translated to.
+func WithLanguage(ctx context.Context, lang string) context.Context {
+	return context.WithValue(ctx, langKey, lang)
+}
+
+// Language picks the best supported language from an Accept-Language
+// header, English when none matches.
+func Language(acceptLanguage string) string {
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, _, _ := strings.Cut(strings.TrimSpace(part), ";")
+		base, _, _ := strings.Cut(strings.ToLower(tag), "-")
+		if _, found := translator.GetTranslator(base); found && base != "" {
+			return base
+		}
+	}
+	return LangEnglish
+}
+
+// Struct validates s. It returns nil, ErrInvalid with the field errors in
+// the language set by WithLanguage, or an unavailable error when a lookup
+// rule could not run.
+func Struct(ctx context.Context, s any) error {
+	lookupErr := &lookupError{}
+	err := validate.StructCtx(context.WithValue(ctx, lookupErrKey, lookupErr), s)
+	if lookupErr.err != nil {
+		return apperror.Unavailable("validation.unavailable", "validation could not complete").Wrap(lookupErr.err)
+	}
+	if err == nil {
+		return nil
+	}
+
+	var invalid validator.ValidationErrors
+	if !errors.As(err, &invalid) {
+		return err
+	}
+
+	lang, _ := ctx.Value(langKey).(string)
+	trans, _ := translator.GetTranslator(lang)
+
+	fields := make([]FieldError, len(invalid))
+	for i, fe := range invalid {
+		// drop the struct name the namespace starts with
+		_, field, _ := strings.Cut(fe.Namespace(), ".")
+		fields[i] = FieldError{
+			Field:   field,
+			Rule:    fe.Tag(),
+			Message: fe.Translate(trans),
+		}
+	}
+	return ErrInvalid.WithDetail("errors", fields)
+}
@@ -0,0 +1,137 @@
+package validation
+
+import (
+	"context"
+	"errors"
+	"service/pkg/apperror"
+	"testing"
+)
+
+func TestLanguage(t *testing.T) {
+	for header, want := range map[string]string{
+		"":                        LangEnglish,
+		"id":                      LangIndonesian,
+		"id-ID,id;q=0.9,en;q=0.8": LangIndonesian,
+		"ID-id":                   LangIndonesian,
+		"fr-FR, id;q=0.5":         LangIndonesian,
+		"en-US,en;q=0.9":          LangEnglish,
+		"fr, de":                  LangEnglish,
+		" ;q=0.1, id":             LangIndonesian,
+	} {
+		if got := Language(header); got != want {
+			t.Errorf("%q: got %s, want %s", header, got, want)
+		}
+	}
+}
+
+type address struct {
+	City string `json:"city" validate:"required"`
+}
+
+type profile struct {
+	Name    string  `json:"name" validate:"required"`
+	Phone   string  `json:"phone" validate:"phone_id"`
+	Address address `json:"address"`
+	Secret  string  `json:"-" validate:"required"`
+}
+
+// fieldErrors returns the field errors of an ErrInvalid.
+func fieldErrors(t *testing.T, err error) map[string]FieldError {
+	t.Helper()
+	if !errors.Is(err, ErrInvalid) {
+		t.Fatalf("err = %v, want ErrInvalid", err)
+	}
+	out := map[string]FieldError{}
+	for _, fe := range apperror.From(err).Details["errors"].([]FieldError) {
+		out[fe.Field] = fe
+	}
+	return out
+}
+
+func TestStructTranslations(t *testing.T) {
+	invalid := profile{Phone: "12345"}
+	for _, tc := range []struct {
+		lang string
+		want map[string]string
+	}{
+		{LangEnglish, map[string]string{
+			"name":         "name is a required field",
+			"phone":        "phone must be a valid Indonesian phone number",
+			"address.city": "city is a required field",
+			"Secret":       "Secret is a required field",
+		}},
+		{LangIndonesian, map[string]string{
+			"name":         "name wajib diisi",
+			"phone":        "phone harus berupa nomor telepon Indonesia yang valid",
+			"address.city": "city wajib diisi",
+			"Secret":       "Secret wajib diisi",
+		}},
+		// an unsupported language falls back to English
+		{"fr", map[string]string{"name": "name is a required field"}},
+	} {
+		t.Run(tc.lang, func(t *testing.T) {
+			fields := fieldErrors(t, Struct(WithLanguage(context.Background(), tc.lang), invalid))
+			for field, msg := range tc.want {
+				if fields[field].Message != msg {
+					t.Errorf("%s: message = %q, want %q", field, fields[field].Message, msg)
+				}
+			}
+			if fields["phone"].Rule != "phone_id" {
+				t.Errorf("phone rule = %q", fields["phone"].Rule)
+			}
+		})
+	}
+}
+
+func TestPhoneID(t *testing.T) {
+	for phone, valid := range map[string]bool{
+		"081234567890":   true,
+		"6281234567890":  true,
+		"+6281234567890": true,
+		"0812345":        false,
+		"080234567890":   false,
+		"+6221234567":    false,
+		"+65812345678":   false,
+		"0812-3456-7890": false,
+	} {
+		err := Struct(context.Background(), profile{Name: "Ana", Phone: phone, Address: address{City: "Bandung"}, Secret: "x"})
+		if (err == nil) != valid {
+			t.Errorf("%s: err = %v, want valid %v", phone, err, valid)
+		}
+	}
+}
+
+type signup struct {
+	Code string `json:"code" validate:"required,unique_test_code"`
+}
+
+func TestRegisterUnique(t *testing.T) {
+	lookupErr := errors.New("connection refused")
+	err := RegisterUnique("unique_test_code", func(ctx context.Context, code string) (bool, error) {
+		switch code {
+		case "down":
+			return false, lookupErr
+		case "taken":
+			return false, nil
+		}
+		return true, nil
+	}, map[string]string{LangEnglish: "{0} is already in use", LangIndonesian: "{0} sudah digunakan"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Struct(context.Background(), signup{Code: "free"}); err != nil {
+		t.Errorf("free: %v", err)
+	}
+
+	fields := fieldErrors(t, Struct(WithLanguage(context.Background(), LangIndonesian), signup{Code: "taken"}))
+	if fields["code"].Message != "code sudah digunakan" {
+		t.Errorf("taken: %+v", fields["code"])
+	}
+
+	// a failed lookup is not the client's fault
+	err = Struct(context.Background(), signup{Code: "down"})
+	if apperror.KindOf(err) != apperror.KindUnavailable || !errors.Is(err, lookupErr) {
+		t.Errorf("down: err = %v", err)
+	}
+}
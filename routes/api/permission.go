@@ -3,26 +3,62 @@ package api
 import (
 	"github.com/gin-gonic/gin"
 	"service/app/controllers/restapi"
+	"service/app/controllers/restapi/permission"
 	"service/app/middlewares"
+	"time"
 )
 
 func NewPermissionApi(r *gin.RouterGroup, restApi *restapi.Restapi, mid *middlewares.Middlewares) {
 	api := r.Group("/permission")
+
+	manage := api.Group("", mid.Authenticate(), mid.Require("permission:manage"))
+	{
+		handler := restApi.PermissionHandler
+
+		manage.GET("/roles", mid.CacheResponse(middlewares.CachePolicy{
+			Resource:     permission.RolesCacheResource,
+			CacheControl: "private, no-cache",
+			TTL:          5 * time.Minute,
+		}), handler.ListRoles)
+		manage.POST("/roles", handler.CreateRole)
+		manage.DELETE("/roles/:id", handler.DeleteRole)
+		manage.POST("/roles/:id/permissions", handler.Grant)
+		manage.DELETE("/roles/:id/permissions/:permission", handler.Revoke)
+
+		manage.GET("/users/:user_id/permissions", handler.UserPermissions)
+		manage.POST("/users/:user_id/roles", handler.AssignRole)
+		manage.DELETE("/users/:user_id/roles/:role_id", handler.UnassignRole)
+	}
+
 	noAuth := api.Group("/noauth")
 	{
-		noAuth.GET("", func(c *gin.Context) {
-			c.JSON(200, gin.H{
-				"message": "ini permission",
-			})
-		})
+		noAuth.GET("", permissionHello)
 
 		noAuthUser := noAuth.Group("/user")
 		{
-			noAuthUser.GET("", func(c *gin.Context) {
-				c.JSON(200, gin.H{
-					"message": "Hello World",
-				})
-			})
+			noAuthUser.GET("", permissionUserHello)
 		}
 	}
 }
+
+// @Summary Hello
+// @Tags permissions
+// @Produce json
+// @Success 200 {object} map[string]string
+// @Router /permission/noauth [get]
+func permissionHello(c *gin.Context) {
+	c.JSON(200, gin.H{
+		"message": "ini permission",
+	})
+}
+
+// @Summary Hello
+// @Tags permissions
+// @Produce json
+// @Success 200 {object} map[string]string
+// @Router /permission/noauth/user [get]
+func permissionUserHello(c *gin.Context) {
+	c.JSON(200, gin.H{
+		"message": "Hello World",
+	})
+}
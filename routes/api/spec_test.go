@@ -0,0 +1,70 @@
+package api
+
+import (
+	"regexp"
+	"service/app/controllers/restapi"
+	"service/app/middlewares"
+	"service/app/usecases"
+	"service/config"
+	"service/pkg/health"
+	"service/pkg/openapi"
+	"service/pkg/server"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+var pathParam = regexp.MustCompile(`\{([^}]+)\}`)
+
+// TestRoutesMatchSpec fails when a route of the documented version is added,
+// removed or renamed without updating its annotations and regenerating
+// docs/ with `swag init -g main.go -o docs`.
+func TestRoutesMatchSpec(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := config.NewConfig()
+	rest := restapi.NewRestapi(&cfg, &usecases.Usecase{})
+	mid := middlewares.NewMiddlewares(&cfg.Rest, nil, nil, nil, nil, nil)
+
+	srv, err := server.NewHTTPServer(&cfg, nil, rest, mid, health.NewRegistry(time.Second), Versions()...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	versions := Versions()
+	spec := versions[slices.IndexFunc(versions, func(v server.Version) bool { return v.Spec })]
+
+	var routes []string
+	for _, report := range srv.Versions() {
+		if report.Name == spec.Name {
+			routes = report.Routes
+		}
+	}
+
+	doc, err := openapi.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var documented []string
+	for path, item := range doc.Paths.Map() {
+		for method := range item.Operations() {
+			documented = append(documented, method+" "+pathParam.ReplaceAllString(path, ":$1"))
+		}
+	}
+
+	for _, route := range routes {
+		if !slices.Contains(documented, route) {
+			t.Errorf("route %s is not documented", route)
+		}
+	}
+	for _, route := range documented {
+		if !slices.Contains(routes, route) {
+			t.Errorf("documented %s is not routed", route)
+		}
+	}
+	if t.Failed() {
+		t.Log("annotate the handlers and run: swag init -g main.go -o docs")
+		t.Logf("routes:\n%s", strings.Join(routes, "\n"))
+	}
+}
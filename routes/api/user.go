@@ -3,25 +3,44 @@ package api
 import (
 	"github.com/gin-gonic/gin"
 	"service/app/controllers/restapi"
+	"service/app/controllers/restapi/user"
 	"service/app/middlewares"
+	"time"
 )
 
 func NewUserApi(r *gin.RouterGroup, rest *restapi.Restapi, mid *middlewares.Middlewares) {
 	api := r.Group("/user")
 
-	api.POST("/register", rest.UserHandler.Register)
+	api.POST("/register", mid.RateLimit("register"), mid.Idempotent(), rest.UserHandler.Register)
+
+	events := api.Group("/events", mid.Authenticate(), mid.Require("user:read"))
+	{
+		events.GET("", rest.UserHandler.Events)
+		events.GET("/ws", rest.UserHandler.EventsSocket)
+	}
 
 	noAuth := api.Group("/data")
 	{
-		noAuth.GET("", rest.UserHandler.List)
+		noAuth.GET("", mid.CacheResponse(middlewares.CachePolicy{
+			Resource:     user.CacheResource,
+			CacheControl: "private, no-cache",
+			TTL:          30 * time.Second,
+		}), rest.UserHandler.List)
 
 		noAuthUser := noAuth.Group("/user")
 		{
-			noAuthUser.GET("", func(c *gin.Context) {
-				c.JSON(200, gin.H{
-					"message": "Hello World",
-				})
-			})
+			noAuthUser.GET("", userHello)
 		}
 	}
 }
+
+// @Summary Hello
+// @Tags users
+// @Produce json
+// @Success 200 {object} map[string]string
+// @Router /user/data/user [get]
+func userHello(c *gin.Context) {
+	c.JSON(200, gin.H{
+		"message": "Hello World",
+	})
+}
@@ -0,0 +1,15 @@
+package api
+
+import "service/pkg/server"
+
+// Versions are the mounted API versions. A new version lists the route
+// groups it keeps from the previous one and its own for the routes whose
+// shape changed.
+func Versions() []server.Version {
+	return []server.Version{
+		{Name: "v1", Path: "v1", Handlers: []server.GroupsHandler{NewUserApi, NewPermissionApi}, Spec: true},
+		// the routes of clients from before /v1, set its deprecation and
+		// sunset dates in router.versions.unversioned
+		{Name: "unversioned", AliasOf: "v1"},
+	}
+}